@@ -0,0 +1,150 @@
+package calendar
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	// Embed the timezone database so settings.timezone works on systems without zoneinfo
+	_ "time/tzdata"
+)
+
+// Calendar computes day, week and month boundaries in a configured timezone.
+// Days start at RolloverHour rather than midnight, so work done shortly after
+// midnight can count towards the previous day.
+type Calendar struct {
+	Location     *time.Location
+	WeekStart    time.Weekday
+	RolloverHour int
+}
+
+// Period is a half-open time range [Start, End)
+type Period struct {
+	Start time.Time
+	End   time.Time
+}
+
+// Contains reports whether t falls within the period
+func (p Period) Contains(t time.Time) bool {
+	return !t.Before(p.Start) && t.Before(p.End)
+}
+
+// Duration returns the length of the period, which varies across DST transitions
+func (p Period) Duration() time.Duration {
+	return p.End.Sub(p.Start)
+}
+
+// Default returns a calendar in the local timezone with weeks starting on Sunday and days at midnight
+func Default() Calendar {
+	return Calendar{Location: time.Local, WeekStart: time.Sunday}
+}
+
+// New creates a calendar from configuration values. An empty timezone means
+// the local timezone and an empty week start means Sunday.
+func New(timezone, weekStart string, rolloverHour int) (Calendar, error) {
+	cal := Default()
+
+	if timezone != "" {
+		loc, err := time.LoadLocation(timezone)
+		if err != nil {
+			return cal, fmt.Errorf("invalid timezone %q: %w", timezone, err)
+		}
+		cal.Location = loc
+	}
+
+	if weekStart != "" {
+		day, err := ParseWeekday(weekStart)
+		if err != nil {
+			return cal, err
+		}
+		cal.WeekStart = day
+	}
+
+	if rolloverHour < 0 || rolloverHour > 23 {
+		return cal, fmt.Errorf("day rollover hour must be between 0 and 23, got: %d", rolloverHour)
+	}
+	cal.RolloverHour = rolloverHour
+
+	return cal, nil
+}
+
+// ParseWeekday parses a weekday name such as "monday" or "Mon"
+func ParseWeekday(name string) (time.Weekday, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		full := strings.ToLower(day.String())
+		if name == full || (len(name) == 3 && strings.HasPrefix(full, name)) {
+			return day, nil
+		}
+	}
+	return time.Sunday, fmt.Errorf("invalid week start %q (expected a weekday such as monday)", name)
+}
+
+// Date returns the calendar date t belongs to, as midnight in the calendar's timezone
+func (c Calendar) Date(t time.Time) time.Time {
+	local := t.In(c.location())
+	year, month, day := local.Date()
+	if local.Hour() < c.RolloverHour {
+		day--
+	}
+	return time.Date(year, month, day, 0, 0, 0, 0, c.location())
+}
+
+// Day returns the day containing t
+func (c Calendar) Day(t time.Time) Period {
+	date := c.Date(t)
+	return Period{Start: c.dayStart(date, 0), End: c.dayStart(date, 1)}
+}
+
+// Week returns the week containing t
+func (c Calendar) Week(t time.Time) Period {
+	date := c.Date(t)
+	offset := (int(date.Weekday()) - int(c.WeekStart) + 7) % 7
+	return Period{Start: c.dayStart(date, -offset), End: c.dayStart(date, 7-offset)}
+}
+
+// Month returns the month containing t
+func (c Calendar) Month(t time.Time) Period {
+	date := c.Date(t)
+	first := time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, c.location())
+	next := time.Date(date.Year(), date.Month()+1, 1, 0, 0, 0, 0, c.location())
+	return Period{Start: c.dayStart(first, 0), End: c.dayStart(next, 0)}
+}
+
+// Dates returns the period covering the calendar dates first through last, inclusive.
+// Only the year, month and day of first and last are used.
+func (c Calendar) Dates(first, last time.Time) Period {
+	first = time.Date(first.Year(), first.Month(), first.Day(), 0, 0, 0, 0, c.location())
+	last = time.Date(last.Year(), last.Month(), last.Day(), 0, 0, 0, 0, c.location())
+	return Period{Start: c.dayStart(first, 0), End: c.dayStart(last, 1)}
+}
+
+// Today returns the current day
+func (c Calendar) Today() Period {
+	return c.Day(time.Now())
+}
+
+// ThisWeek returns the current week
+func (c Calendar) ThisWeek() Period {
+	return c.Week(time.Now())
+}
+
+// ThisMonth returns the current month
+func (c Calendar) ThisMonth() Period {
+	return c.Month(time.Now())
+}
+
+// dayStart returns the instant the day `days` after date begins. Building the
+// time from calendar fields keeps days aligned across DST transitions, where a
+// day is 23 or 25 hours long.
+func (c Calendar) dayStart(date time.Time, days int) time.Time {
+	return time.Date(date.Year(), date.Month(), date.Day()+days, c.RolloverHour, 0, 0, 0, c.location())
+}
+
+// location returns the calendar's timezone, defaulting to local time
+func (c Calendar) location() *time.Location {
+	if c.Location == nil {
+		return time.Local
+	}
+	return c.Location
+}
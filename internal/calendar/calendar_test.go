@@ -0,0 +1,262 @@
+package calendar
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mustLoad(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	require.NoError(t, err)
+	return loc
+}
+
+func TestCalendar_Day(t *testing.T) {
+	newYork := mustLoad(t, "America/New_York")
+	london := mustLoad(t, "Europe/London")
+	tokyo := mustLoad(t, "Asia/Tokyo")
+
+	tests := []struct {
+		name      string
+		cal       Calendar
+		at        time.Time
+		wantStart time.Time
+		wantEnd   time.Time
+		wantLen   time.Duration
+	}{
+		{
+			name:      "ordinary day in New York",
+			cal:       Calendar{Location: newYork},
+			at:        time.Date(2025, 6, 10, 15, 0, 0, 0, newYork),
+			wantStart: time.Date(2025, 6, 10, 0, 0, 0, 0, newYork),
+			wantEnd:   time.Date(2025, 6, 11, 0, 0, 0, 0, newYork),
+			wantLen:   24 * time.Hour,
+		},
+		{
+			name:      "UTC instant early in the day belongs to the previous New York day",
+			cal:       Calendar{Location: newYork},
+			at:        time.Date(2025, 6, 11, 2, 0, 0, 0, time.UTC),
+			wantStart: time.Date(2025, 6, 10, 0, 0, 0, 0, newYork),
+			wantEnd:   time.Date(2025, 6, 11, 0, 0, 0, 0, newYork),
+			wantLen:   24 * time.Hour,
+		},
+		{
+			name:      "spring forward day is 23 hours",
+			cal:       Calendar{Location: newYork},
+			at:        time.Date(2025, 3, 9, 12, 0, 0, 0, newYork),
+			wantStart: time.Date(2025, 3, 9, 0, 0, 0, 0, newYork),
+			wantEnd:   time.Date(2025, 3, 10, 0, 0, 0, 0, newYork),
+			wantLen:   23 * time.Hour,
+		},
+		{
+			name:      "fall back day is 25 hours",
+			cal:       Calendar{Location: newYork},
+			at:        time.Date(2025, 11, 2, 23, 30, 0, 0, newYork),
+			wantStart: time.Date(2025, 11, 2, 0, 0, 0, 0, newYork),
+			wantEnd:   time.Date(2025, 11, 3, 0, 0, 0, 0, newYork),
+			wantLen:   25 * time.Hour,
+		},
+		{
+			name:      "London spring forward",
+			cal:       Calendar{Location: london},
+			at:        time.Date(2025, 3, 30, 0, 30, 0, 0, london),
+			wantStart: time.Date(2025, 3, 30, 0, 0, 0, 0, london),
+			wantEnd:   time.Date(2025, 3, 31, 0, 0, 0, 0, london),
+			wantLen:   23 * time.Hour,
+		},
+		{
+			name:      "rollover hour keeps late night work on the previous day",
+			cal:       Calendar{Location: tokyo, RolloverHour: 4},
+			at:        time.Date(2025, 6, 11, 2, 30, 0, 0, tokyo),
+			wantStart: time.Date(2025, 6, 10, 4, 0, 0, 0, tokyo),
+			wantEnd:   time.Date(2025, 6, 11, 4, 0, 0, 0, tokyo),
+			wantLen:   24 * time.Hour,
+		},
+		{
+			name:      "rollover hour after it has passed",
+			cal:       Calendar{Location: tokyo, RolloverHour: 4},
+			at:        time.Date(2025, 6, 11, 4, 0, 0, 0, tokyo),
+			wantStart: time.Date(2025, 6, 11, 4, 0, 0, 0, tokyo),
+			wantEnd:   time.Date(2025, 6, 12, 4, 0, 0, 0, tokyo),
+			wantLen:   24 * time.Hour,
+		},
+		{
+			name:      "rollover hour across fall back",
+			cal:       Calendar{Location: newYork, RolloverHour: 3},
+			at:        time.Date(2025, 11, 3, 1, 0, 0, 0, newYork),
+			wantStart: time.Date(2025, 11, 2, 3, 0, 0, 0, newYork),
+			wantEnd:   time.Date(2025, 11, 3, 3, 0, 0, 0, newYork),
+			wantLen:   24 * time.Hour,
+		},
+		{
+			name:      "rollover hour on the day of the fall back",
+			cal:       Calendar{Location: newYork, RolloverHour: 3},
+			at:        time.Date(2025, 11, 2, 12, 0, 0, 0, newYork),
+			wantStart: time.Date(2025, 11, 2, 3, 0, 0, 0, newYork),
+			wantEnd:   time.Date(2025, 11, 3, 3, 0, 0, 0, newYork),
+			wantLen:   24 * time.Hour,
+		},
+		{
+			name:      "rollover window containing the fall back transition",
+			cal:       Calendar{Location: newYork, RolloverHour: 3},
+			at:        time.Date(2025, 11, 2, 1, 30, 0, 0, newYork),
+			wantStart: time.Date(2025, 11, 1, 3, 0, 0, 0, newYork),
+			wantEnd:   time.Date(2025, 11, 2, 3, 0, 0, 0, newYork),
+			wantLen:   25 * time.Hour,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			day := tt.cal.Day(tt.at)
+			assert.True(t, tt.wantStart.Equal(day.Start), "start: want %s, got %s", tt.wantStart, day.Start)
+			assert.True(t, tt.wantEnd.Equal(day.End), "end: want %s, got %s", tt.wantEnd, day.End)
+			assert.Equal(t, tt.wantLen, day.Duration())
+			assert.True(t, day.Contains(tt.at))
+		})
+	}
+}
+
+func TestCalendar_Week(t *testing.T) {
+	newYork := mustLoad(t, "America/New_York")
+	berlin := mustLoad(t, "Europe/Berlin")
+
+	tests := []struct {
+		name      string
+		cal       Calendar
+		at        time.Time
+		wantStart time.Time
+		wantLen   time.Duration
+	}{
+		{
+			name:      "weeks start on Sunday by default",
+			cal:       Calendar{Location: newYork},
+			at:        time.Date(2025, 6, 11, 12, 0, 0, 0, newYork), // Wednesday
+			wantStart: time.Date(2025, 6, 8, 0, 0, 0, 0, newYork),
+			wantLen:   7 * 24 * time.Hour,
+		},
+		{
+			name:      "Monday week start",
+			cal:       Calendar{Location: berlin, WeekStart: time.Monday},
+			at:        time.Date(2025, 6, 15, 22, 0, 0, 0, berlin), // Sunday
+			wantStart: time.Date(2025, 6, 9, 0, 0, 0, 0, berlin),
+			wantLen:   7 * 24 * time.Hour,
+		},
+		{
+			name:      "Monday itself starts the week",
+			cal:       Calendar{Location: berlin, WeekStart: time.Monday},
+			at:        time.Date(2025, 6, 9, 0, 0, 0, 0, berlin),
+			wantStart: time.Date(2025, 6, 9, 0, 0, 0, 0, berlin),
+			wantLen:   7 * 24 * time.Hour,
+		},
+		{
+			name:      "week containing spring forward",
+			cal:       Calendar{Location: berlin, WeekStart: time.Monday},
+			at:        time.Date(2025, 3, 30, 12, 0, 0, 0, berlin),
+			wantStart: time.Date(2025, 3, 24, 0, 0, 0, 0, berlin),
+			wantLen:   7*24*time.Hour - time.Hour,
+		},
+		{
+			name:      "week containing fall back",
+			cal:       Calendar{Location: newYork},
+			at:        time.Date(2025, 11, 5, 12, 0, 0, 0, newYork),
+			wantStart: time.Date(2025, 11, 2, 0, 0, 0, 0, newYork),
+			wantLen:   7*24*time.Hour + time.Hour,
+		},
+		{
+			name:      "rollover hour moves early Monday back into the previous week",
+			cal:       Calendar{Location: berlin, WeekStart: time.Monday, RolloverHour: 5},
+			at:        time.Date(2025, 6, 16, 1, 0, 0, 0, berlin), // Monday 01:00
+			wantStart: time.Date(2025, 6, 9, 5, 0, 0, 0, berlin),
+			wantLen:   7 * 24 * time.Hour,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			week := tt.cal.Week(tt.at)
+			assert.True(t, tt.wantStart.Equal(week.Start), "start: want %s, got %s", tt.wantStart, week.Start)
+			assert.Equal(t, tt.wantLen, week.Duration())
+			assert.True(t, week.Contains(tt.at))
+		})
+	}
+}
+
+func TestCalendar_Month(t *testing.T) {
+	newYork := mustLoad(t, "America/New_York")
+
+	tests := []struct {
+		name      string
+		cal       Calendar
+		at        time.Time
+		wantStart time.Time
+		wantEnd   time.Time
+	}{
+		{
+			name:      "month containing spring forward",
+			cal:       Calendar{Location: newYork},
+			at:        time.Date(2025, 3, 15, 12, 0, 0, 0, newYork),
+			wantStart: time.Date(2025, 3, 1, 0, 0, 0, 0, newYork),
+			wantEnd:   time.Date(2025, 4, 1, 0, 0, 0, 0, newYork),
+		},
+		{
+			name:      "December rolls into the next year",
+			cal:       Calendar{Location: newYork},
+			at:        time.Date(2025, 12, 31, 23, 0, 0, 0, newYork),
+			wantStart: time.Date(2025, 12, 1, 0, 0, 0, 0, newYork),
+			wantEnd:   time.Date(2026, 1, 1, 0, 0, 0, 0, newYork),
+		},
+		{
+			name:      "rollover hour keeps the first night in the previous month",
+			cal:       Calendar{Location: newYork, RolloverHour: 4},
+			at:        time.Date(2025, 7, 1, 2, 0, 0, 0, newYork),
+			wantStart: time.Date(2025, 6, 1, 4, 0, 0, 0, newYork),
+			wantEnd:   time.Date(2025, 7, 1, 4, 0, 0, 0, newYork),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			month := tt.cal.Month(tt.at)
+			assert.True(t, tt.wantStart.Equal(month.Start), "start: want %s, got %s", tt.wantStart, month.Start)
+			assert.True(t, tt.wantEnd.Equal(month.End), "end: want %s, got %s", tt.wantEnd, month.End)
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	cal, err := New("Europe/Paris", "Mon", 4)
+	require.NoError(t, err)
+	assert.Equal(t, "Europe/Paris", cal.Location.String())
+	assert.Equal(t, time.Monday, cal.WeekStart)
+	assert.Equal(t, 4, cal.RolloverHour)
+
+	cal, err = New("", "", 0)
+	require.NoError(t, err)
+	assert.Equal(t, time.Local, cal.Location)
+	assert.Equal(t, time.Sunday, cal.WeekStart)
+
+	_, err = New("Mars/Olympus", "", 0)
+	assert.ErrorContains(t, err, "invalid timezone")
+
+	_, err = New("", "someday", 0)
+	assert.ErrorContains(t, err, "invalid week start")
+
+	_, err = New("", "", 24)
+	assert.ErrorContains(t, err, "rollover hour")
+}
+
+func TestCalendar_Dates(t *testing.T) {
+	newYork := mustLoad(t, "America/New_York")
+	cal := Calendar{Location: newYork, RolloverHour: 4}
+
+	// Dates are taken from the calendar fields, whatever the input's timezone
+	period := cal.Dates(time.Date(2025, 3, 8, 0, 0, 0, 0, time.UTC), time.Date(2025, 3, 9, 0, 0, 0, 0, time.UTC))
+	assert.True(t, time.Date(2025, 3, 8, 4, 0, 0, 0, newYork).Equal(period.Start))
+	assert.True(t, time.Date(2025, 3, 10, 4, 0, 0, 0, newYork).Equal(period.End))
+	assert.Equal(t, 47*time.Hour, period.Duration())
+}
@@ -6,7 +6,9 @@ import (
 	"os/exec"
 
 	"github.com/ferg-cod3s/rune/internal/config"
+	"github.com/ferg-cod3s/rune/internal/daemon"
 	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
 )
 
 var configCmd = &cobra.Command{
@@ -39,11 +41,97 @@ var configShowCmd = &cobra.Command{
 	RunE:  runConfigShow,
 }
 
+var configResolvedCmd = &cobra.Command{
+	Use:   "resolved",
+	Short: "Show the configuration merged with its includes",
+	Long: `Display the configuration merged with the files it includes, with a
+comment on each value naming the file it came from.`,
+	Args: cobra.NoArgs,
+	RunE: runConfigResolved,
+}
+
+var configSchemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Print a JSON Schema for the configuration file",
+	Long: `Print a JSON Schema describing the configuration file. Editors with a
+YAML language server can use it to complete and check your config, e.g.
+by saving it and adding this line to the top of config.yaml:
+
+  # yaml-language-server: $schema=./config.schema.json`,
+	Args: cobra.NoArgs,
+	RunE: runConfigSchema,
+}
+
+var configGetCmd = &cobra.Command{
+	Use:   "get <key>",
+	Short: "Print a configuration value",
+	Long: `Print the value at a key, as rune reads it with includes merged.
+
+Keys are dotted paths; list items are picked by index and keys containing
+dots are quoted in brackets:
+
+  rune config get settings.work_hours
+  rune config get rituals.start.global[0].command
+  rune config get 'rituals.start.per_project["docs.site"]'`,
+	Args: cobra.ExactArgs(1),
+	RunE: runConfigGet,
+}
+
+var configSetCmd = &cobra.Command{
+	Use:   "set <key> <value>",
+	Short: "Set a configuration value",
+	Long: `Set the value at a key in the configuration file. The value is read as
+YAML, so numbers, booleans, lists and mappings can be given as well as
+strings; quote a string that would read as something else.
+
+The file is edited in place: comments, key order and blank lines are kept.
+The change is only written if the resulting configuration is valid.
+
+  rune config set settings.work_hours 7.5
+  rune config set settings.break_interval 45m
+  rune config set settings.idle_threshold '"10m"'
+  rune config set rituals.start.global '[{name: Pull, command: git pull}]'`,
+	Args: cobra.ExactArgs(2),
+	RunE: runConfigSet,
+}
+
+var configUnsetCmd = &cobra.Command{
+	Use:   "unset <key>",
+	Short: "Remove a configuration value",
+	Long: `Remove the key, or list item, at a key from the configuration file,
+keeping the rest of the file as written. The change is only written if the
+resulting configuration is valid.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runConfigUnset,
+}
+
+var configMigrateDryRun bool
+
+var configMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Upgrade the configuration file to the current version",
+	Long: fmt.Sprintf(`Upgrade the configuration file to config version %d, one version at a
+time, keeping a copy of the original next to it as config.yaml.v<N>.bak.
+
+Older files are already upgraded in memory whenever rune reads them; this
+writes the upgrade to disk. Use --dry-run to see the changes as a diff
+without writing anything.`, config.CurrentVersion),
+	Args: cobra.NoArgs,
+	RunE: runConfigMigrate,
+}
+
 func init() {
 	rootCmd.AddCommand(configCmd)
 	configCmd.AddCommand(configEditCmd)
 	configCmd.AddCommand(configValidateCmd)
 	configCmd.AddCommand(configShowCmd)
+	configCmd.AddCommand(configResolvedCmd)
+	configCmd.AddCommand(configMigrateCmd)
+	configCmd.AddCommand(configSchemaCmd)
+	configCmd.AddCommand(configGetCmd)
+	configCmd.AddCommand(configSetCmd)
+	configCmd.AddCommand(configUnsetCmd)
+	configMigrateCmd.Flags().BoolVar(&configMigrateDryRun, "dry-run", false, "Show the changes without writing them")
 }
 
 func runConfigEdit(cmd *cobra.Command, args []string) error {
@@ -86,9 +174,10 @@ func runConfigValidate(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
-	cfg, err := config.Load()
+	cfg, warnings, err := config.Check()
 	if err != nil {
 		fmt.Printf("❌ Configuration validation failed: %v\n", err)
+		printConfigWarnings(warnings)
 		return nil // Don't return error to avoid double error message
 	}
 
@@ -97,9 +186,29 @@ func runConfigValidate(cmd *cobra.Command, args []string) error {
 	fmt.Printf("   Projects: %d\n", len(cfg.Projects))
 	fmt.Printf("   Work hours: %.1f\n", cfg.Settings.WorkHours)
 
+	if configPath, err := config.GetConfigPath(); err == nil {
+		if data, err := os.ReadFile(configPath); err == nil {
+			if migration, err := config.Migrate(data); err == nil && len(migration.Applied) > 0 {
+				fmt.Printf("💡 The file is written for version %d; run 'rune config migrate' to upgrade it\n", migration.From)
+			}
+		}
+	}
+	printConfigWarnings(warnings)
+
 	return nil
 }
 
+// printConfigWarnings lists problems that do not stop the configuration from loading
+func printConfigWarnings(warnings []config.Issue) {
+	if len(warnings) == 0 {
+		return
+	}
+	fmt.Println("⚠ Warnings:")
+	for _, warning := range warnings {
+		fmt.Printf("  %s\n", warning)
+	}
+}
+
 func runConfigShow(cmd *cobra.Command, args []string) error {
 	exists, err := config.Exists()
 	if err != nil {
@@ -127,3 +236,187 @@ func runConfigShow(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+func runConfigResolved(cmd *cobra.Command, args []string) error {
+	exists, err := config.Exists()
+	if err != nil {
+		return err
+	}
+	if !exists {
+		fmt.Println("⚠ Configuration file does not exist.")
+		fmt.Println("Run 'rune init' to create a new configuration.")
+		return nil
+	}
+
+	configPath, err := config.GetConfigPath()
+	if err != nil {
+		return err
+	}
+	resolved, err := config.Resolve(configPath)
+	if err != nil {
+		return err
+	}
+	content, err := resolved.YAML()
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("# Merged from, lowest precedence first:")
+	for _, source := range resolved.Sources {
+		fmt.Printf("#   %s\n", source)
+	}
+	fmt.Print(string(content))
+
+	return nil
+}
+
+func runConfigMigrate(cmd *cobra.Command, args []string) error {
+	exists, err := config.Exists()
+	if err != nil {
+		return err
+	}
+	if !exists {
+		fmt.Println("⚠ Configuration file does not exist.")
+		fmt.Println("Run 'rune init' to create a new configuration.")
+		return nil
+	}
+
+	configPath, err := config.GetConfigPath()
+	if err != nil {
+		return err
+	}
+
+	if configMigrateDryRun {
+		data, err := os.ReadFile(configPath)
+		if err != nil {
+			return fmt.Errorf("failed to read config file: %w", err)
+		}
+		migration, err := config.Migrate(data)
+		if err != nil {
+			return err
+		}
+		if len(migration.Applied) == 0 {
+			fmt.Printf("✓ Configuration is already at version %d\n", migration.To)
+			return nil
+		}
+		printMigrationSteps(migration)
+		fmt.Println()
+		fmt.Print(config.UnifiedDiff(configPath, configPath+" (migrated)", data, migration.Data))
+		return nil
+	}
+
+	migration, backup, err := config.MigrateFile(configPath)
+	if err != nil {
+		return err
+	}
+	if len(migration.Applied) == 0 {
+		fmt.Printf("✓ Configuration is already at version %d\n", migration.To)
+		return nil
+	}
+	printMigrationSteps(migration)
+	fmt.Printf("✓ Upgraded %s from version %d to %d\n", configPath, migration.From, migration.To)
+	fmt.Printf("   Backup: %s\n", backup)
+	return nil
+}
+
+func runConfigSchema(cmd *cobra.Command, args []string) error {
+	schema, err := config.Schema()
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.Write(schema)
+	return err
+}
+
+func runConfigGet(cmd *cobra.Command, args []string) error {
+	configPath, err := config.GetConfigPath()
+	if err != nil {
+		return err
+	}
+	resolved, err := config.Resolve(configPath)
+	if err != nil {
+		return err
+	}
+	value, err := resolved.Get(args[0])
+	if err != nil {
+		return err
+	}
+	if value == nil {
+		return fmt.Errorf("%s is not set", args[0])
+	}
+	if value.Kind == yaml.ScalarNode {
+		fmt.Println(value.Value)
+		return nil
+	}
+	content, err := yaml.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", args[0], err)
+	}
+	fmt.Print(string(content))
+	return nil
+}
+
+func runConfigSet(cmd *cobra.Command, args []string) error {
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(args[1]), &doc); err != nil {
+		return fmt.Errorf("invalid value %q: %w", args[1], err)
+	}
+	var value interface{} = args[1]
+	if len(doc.Content) > 0 {
+		value = doc.Content[0]
+	}
+	return editConfig(func(file *config.File) error {
+		return file.Set(args[0], value)
+	}, fmt.Sprintf("✓ Set %s to %s", args[0], args[1]))
+}
+
+func runConfigUnset(cmd *cobra.Command, args []string) error {
+	return editConfig(func(file *config.File) error {
+		return file.Unset(args[0])
+	}, fmt.Sprintf("✓ Removed %s", args[0]))
+}
+
+// editConfig applies an edit to the configuration file and saves it if the
+// result is still valid
+func editConfig(edit func(*config.File) error, done string) error {
+	exists, err := config.Exists()
+	if err != nil {
+		return err
+	}
+	if !exists {
+		fmt.Println("⚠ Configuration file does not exist.")
+		fmt.Println("Run 'rune init' to create a new configuration.")
+		return nil
+	}
+
+	configPath, err := config.GetConfigPath()
+	if err != nil {
+		return err
+	}
+	file, err := config.OpenFile(configPath)
+	if err != nil {
+		return err
+	}
+	if err := edit(file); err != nil {
+		return err
+	}
+	if err := file.Validate(); err != nil {
+		return fmt.Errorf("not saved, the change would make the configuration invalid: %w", err)
+	}
+	if err := file.Save(); err != nil {
+		return err
+	}
+	fmt.Println(done)
+	if daemon.IsRunning() {
+		fmt.Println("💡 The daemon reads the configuration when it starts; run 'rune daemon stop' and 'rune daemon start' to apply the change to it")
+	}
+	return nil
+}
+
+// printMigrationSteps lists the migrations that upgrade the configuration
+func printMigrationSteps(migration *config.Migration) {
+	fmt.Printf("Migrating configuration from version %d to %d:\n", migration.From, migration.To)
+	for _, step := range migration.Applied {
+		fmt.Printf("  • %s\n", step)
+	}
+}
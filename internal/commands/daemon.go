@@ -0,0 +1,157 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/ferg-cod3s/rune/internal/config"
+	"github.com/ferg-cod3s/rune/internal/daemon"
+	"github.com/spf13/cobra"
+)
+
+var daemonCmd = &cobra.Command{
+	Use:   "daemon",
+	Short: "Manage the background rune daemon",
+	Long: `Manage the long-running rune daemon.
+
+While running, the daemon owns the session database and:
+- Automatically pauses your session when you go idle
+- Sends break reminders every break_interval
+- Sends an end-of-day reminder once work_hours is reached
+
+Other rune commands talk to the daemon over a local Unix socket and fall
+back to accessing the database directly when it is not running.
+
+The daemon reads the configuration when it starts. Restart it after
+changing idle, reminder, calendar or hook settings.`,
+}
+
+var daemonStartCmd = &cobra.Command{
+	Use:   "start",
+	Short: "Start the daemon in the background",
+	RunE:  runDaemonStart,
+}
+
+var daemonStopCmd = &cobra.Command{
+	Use:   "stop",
+	Short: "Stop the running daemon",
+	RunE:  runDaemonStop,
+}
+
+var daemonStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show whether the daemon is running",
+	RunE:  runDaemonStatus,
+}
+
+var daemonRunCmd = &cobra.Command{
+	Use:    "run",
+	Short:  "Run the daemon in the foreground",
+	Long:   `Run the daemon in the foreground. This is what 'rune daemon start' launches, and is suitable for launchd or systemd units.`,
+	Hidden: true,
+	RunE:   runDaemonRun,
+}
+
+func init() {
+	rootCmd.AddCommand(daemonCmd)
+	daemonCmd.AddCommand(daemonStartCmd)
+	daemonCmd.AddCommand(daemonStopCmd)
+	daemonCmd.AddCommand(daemonStatusCmd)
+	daemonCmd.AddCommand(daemonRunCmd)
+}
+
+func runDaemonStart(cmd *cobra.Command, args []string) error {
+	if daemon.IsRunning() {
+		fmt.Println("✓ Daemon is already running")
+		return nil
+	}
+
+	pid, err := daemon.Spawn()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("✓ Daemon started (pid %d)\n", pid)
+	return nil
+}
+
+func runDaemonStop(cmd *cobra.Command, args []string) error {
+	client, err := daemon.Dial()
+	if err != nil {
+		fmt.Println("Daemon is not running")
+		return nil
+	}
+	defer client.Close()
+
+	if err := client.Shutdown(); err != nil {
+		return fmt.Errorf("failed to stop daemon: %w", err)
+	}
+
+	// Wait for the daemon to release the socket
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) && daemon.IsRunning() {
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	fmt.Println("✓ Daemon stopped")
+	return nil
+}
+
+func runDaemonStatus(cmd *cobra.Command, args []string) error {
+	client, err := daemon.Dial()
+	if err != nil {
+		fmt.Println("Daemon:       Stopped")
+		return nil
+	}
+	defer client.Close()
+
+	status, err := client.Status()
+	if err != nil {
+		return fmt.Errorf("failed to get daemon status: %w", err)
+	}
+
+	fmt.Println("Daemon:       Running")
+	fmt.Printf("PID:          %d\n", status.PID)
+	fmt.Printf("Uptime:       %s\n", formatDuration(time.Since(status.StartedAt)))
+	fmt.Printf("Socket:       %s\n", status.SocketPath)
+	return nil
+}
+
+func runDaemonRun(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Printf("⚠ Could not load config, reminders disabled: %v\n", err)
+		cfg = nil
+	}
+
+	server, err := daemon.NewServer(cfg)
+	if err != nil {
+		return err
+	}
+
+	socketPath, err := daemon.SocketPath()
+	if err != nil {
+		return err
+	}
+
+	if err := daemon.WritePIDFile(); err != nil {
+		return fmt.Errorf("failed to write PID file: %w", err)
+	}
+	defer daemon.RemovePIDFile()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		server.Shutdown()
+	}()
+
+	fmt.Printf("rune daemon listening on %s\n", socketPath)
+	err = server.Serve(socketPath)
+	server.Shutdown()
+	_ = os.Remove(socketPath)
+	return err
+}
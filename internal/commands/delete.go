@@ -0,0 +1,42 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/ferg-cod3s/rune/internal/telemetry"
+	"github.com/spf13/cobra"
+)
+
+var deleteCmd = &cobra.Command{
+	Use:   "delete <session-id>",
+	Short: "Delete a completed session",
+	Long:  `Delete a completed session. The deletion can be reverted with 'rune undo'.`,
+	Args:  cobra.ExactArgs(1),
+	RunE:  runDelete,
+}
+
+func init() {
+	rootCmd.AddCommand(deleteCmd)
+
+	// Wrap command with telemetry
+	telemetry.WrapCommand(deleteCmd, runDelete)
+}
+
+func runDelete(cmd *cobra.Command, args []string) error {
+	tracker, err := openTracker(loadConfig())
+	if err != nil {
+		return err
+	}
+	defer tracker.Close()
+
+	session, err := tracker.DeleteSession(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to delete session: %w", err)
+	}
+
+	fmt.Printf("✓ Deleted session %s\n", session.ID)
+	printSessionDetails(session)
+	fmt.Println("💡 Use 'rune undo' to restore it")
+
+	return nil
+}
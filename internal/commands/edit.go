@@ -0,0 +1,108 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/ferg-cod3s/rune/internal/telemetry"
+	"github.com/ferg-cod3s/rune/internal/tracking"
+	"github.com/spf13/cobra"
+)
+
+var editCmd = &cobra.Command{
+	Use:   "edit <session-id>",
+	Short: "Edit a completed session",
+	Long: `Change the start time, end time, project or note of a completed session.
+
+HH:MM times are interpreted on the day the session started. Changes are
+checked against overlapping sessions and can be reverted with 'rune undo'.
+
+Examples:
+  rune edit session_1736150400000000000 --start 08:45
+  rune edit session_1736150400000000000 --project api --note "code review"`,
+	Args: cobra.ExactArgs(1),
+	RunE: runEdit,
+}
+
+var (
+	editStart   string
+	editEnd     string
+	editProject string
+	editNote    string
+)
+
+func init() {
+	rootCmd.AddCommand(editCmd)
+	editCmd.Flags().StringVar(&editStart, "start", "", "New start time")
+	editCmd.Flags().StringVar(&editEnd, "end", "", "New end time")
+	editCmd.Flags().StringVar(&editProject, "project", "", "New project name")
+	editCmd.Flags().StringVar(&editNote, "note", "", "New note")
+
+	// Wrap command with telemetry
+	telemetry.WrapCommand(editCmd, runEdit)
+}
+
+func runEdit(cmd *cobra.Command, args []string) error {
+	cfg := loadConfig()
+	tracker, err := openTracker(cfg)
+	if err != nil {
+		return err
+	}
+	defer tracker.Close()
+
+	session, err := tracker.GetSession(args[0])
+	if err != nil {
+		return err
+	}
+
+	// Bare HH:MM times refer to the day the session started
+	day := session.StartTime.In(calendarFor(cfg).Location)
+
+	var edit tracking.SessionEdit
+	if cmd.Flags().Changed("start") {
+		start, err := parseTimeFlag(editStart, day)
+		if err != nil {
+			return err
+		}
+		edit.Start = &start
+	}
+	if cmd.Flags().Changed("end") {
+		end, err := parseTimeFlag(editEnd, day)
+		if err != nil {
+			return err
+		}
+		edit.End = &end
+	}
+	if cmd.Flags().Changed("project") {
+		edit.Project = &editProject
+	}
+	if cmd.Flags().Changed("note") {
+		edit.Note = &editNote
+	}
+	if edit == (tracking.SessionEdit{}) {
+		return fmt.Errorf("nothing to change (use --start, --end, --project or --note)")
+	}
+
+	updated, err := tracker.EditSession(session.ID, edit)
+	if err != nil {
+		return fmt.Errorf("failed to edit session: %w", err)
+	}
+
+	fmt.Printf("✓ Updated session %s\n", updated.ID)
+	printSessionDetails(updated)
+	fmt.Println("💡 Use 'rune undo' to revert this change")
+
+	return nil
+}
+
+// printSessionDetails prints the fields of a completed session
+func printSessionDetails(session *tracking.Session) {
+	fmt.Printf("   Project:  %s\n", session.Project)
+	fmt.Printf("   Start:    %s\n", session.StartTime.Format("2006-01-02 15:04"))
+	if session.EndTime != nil {
+		fmt.Printf("   End:      %s\n", session.EndTime.Format("2006-01-02 15:04"))
+	}
+	fmt.Printf("   Duration: %s\n", formatDuration(session.Duration))
+	if session.Note != "" {
+		fmt.Printf("   Note:     %s\n", session.Note)
+	}
+}
@@ -0,0 +1,149 @@
+package commands
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ferg-cod3s/rune/internal/importer"
+	"github.com/ferg-cod3s/rune/internal/telemetry"
+	"github.com/ferg-cod3s/rune/internal/tracking"
+	"github.com/spf13/cobra"
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import <path>",
+	Short: "Import history from Watson, Timewarrior or Toggl",
+	Long: `Import completed sessions from another time tracker.
+
+Supported sources:
+  watson       Watson's frames file or its config directory (~/.config/watson)
+  timewarrior  Timewarrior's directory (~/.timewarrior), a data file or 'timew export' JSON.
+               The first tag of each interval becomes the project.
+  toggl-csv    A Toggl Track detailed report CSV export, read in the configured timezone
+
+Tags and notes are preserved. Sessions already recorded with the same start
+and end are skipped, as are sessions overlapping existing ones. Use --dry-run
+to preview the import.
+
+Examples:
+  rune import --from watson ~/.config/watson --dry-run
+  rune import --from timewarrior ~/.timewarrior --map client-a=acme
+  rune import --from toggl-csv Toggl_time_entries.csv --default-project misc`,
+	Args: cobra.ExactArgs(1),
+	RunE: runImport,
+}
+
+var (
+	importFrom           string
+	importMap            []string
+	importDefaultProject string
+	importDryRun         bool
+)
+
+func init() {
+	rootCmd.AddCommand(importCmd)
+	importCmd.Flags().StringVar(&importFrom, "from", "", "Source format: "+strings.Join(importer.Formats, ", ")+" (required)")
+	importCmd.Flags().StringArrayVar(&importMap, "map", nil, "Rename a source project, as source=project (repeatable)")
+	importCmd.Flags().StringVar(&importDefaultProject, "default-project", "imported", "Project for entries without one")
+	importCmd.Flags().BoolVar(&importDryRun, "dry-run", false, "Show what would be imported without storing anything")
+	_ = importCmd.MarkFlagRequired("from")
+
+	// Wrap command with telemetry
+	telemetry.WrapCommand(importCmd, runImport)
+}
+
+func runImport(cmd *cobra.Command, args []string) error {
+	projectMap, err := importer.ParseProjectMap(importMap)
+	if err != nil {
+		return err
+	}
+
+	cfg := loadConfig()
+	cal := calendarFor(cfg)
+	loaded, err := importer.Load(importFrom, args[0], importer.Options{
+		ProjectMap:     projectMap,
+		DefaultProject: importDefaultProject,
+		Location:       cal.Location,
+	})
+	if err != nil {
+		return err
+	}
+
+	tracker, err := openTracker(cfg)
+	if err != nil {
+		return err
+	}
+	defer tracker.Close()
+
+	result, err := tracker.ImportSessions(loaded.Sessions, importDryRun)
+	if err != nil {
+		return fmt.Errorf("failed to import sessions: %w", err)
+	}
+
+	if !importDryRun {
+		telemetry.Track("sessions_imported", map[string]interface{}{
+			"format":     importFrom,
+			"imported":   len(result.Imported),
+			"duplicates": len(result.Duplicates),
+			"conflicts":  len(result.Conflicts),
+		})
+	}
+
+	printImportSummary(result, loaded.Skipped, cal.Location)
+	return nil
+}
+
+// printImportSummary prints per-project totals and everything that was skipped
+func printImportSummary(result *tracking.ImportResult, skipped []importer.Skipped, loc *time.Location) {
+	var total time.Duration
+	counts := make(map[string]int)
+	durations := make(map[string]time.Duration)
+	for _, session := range result.Imported {
+		total += session.Duration
+		counts[session.Project]++
+		durations[session.Project] += session.Duration
+	}
+
+	verb := "✓ Imported"
+	if importDryRun {
+		verb = "🔍 Would import"
+	}
+	fmt.Printf("%s %d sessions (%s) from %s\n", verb, len(result.Imported), formatDuration(total), importFrom)
+
+	projects := make([]string, 0, len(counts))
+	for name := range counts {
+		projects = append(projects, name)
+	}
+	sort.Strings(projects)
+	for _, name := range projects {
+		fmt.Printf("  %-20s %5d sessions  %s\n", name, counts[name], formatDuration(durations[name]))
+	}
+
+	if len(result.Duplicates) > 0 {
+		fmt.Printf("\nSkipped %d sessions already recorded\n", len(result.Duplicates))
+	}
+
+	if len(result.Conflicts) > 0 {
+		fmt.Printf("\n⚠️  Skipped %d sessions overlapping recorded sessions:\n", len(result.Conflicts))
+		for _, conflict := range result.Conflicts {
+			session := conflict.Session
+			fmt.Printf("  %s - %s  %-15s %s\n",
+				session.StartTime.In(loc).Format("2006-01-02 15:04"),
+				session.EndTime.In(loc).Format("15:04"),
+				session.Project, conflict.Reason)
+		}
+	}
+
+	if len(skipped) > 0 {
+		fmt.Printf("\n⚠️  Skipped %d unreadable entries:\n", len(skipped))
+		for _, entry := range skipped {
+			fmt.Printf("  %s: %s\n", entry.Source, entry.Reason)
+		}
+	}
+
+	if importDryRun && len(result.Imported) > 0 {
+		fmt.Println("\n💡 Run again without --dry-run to import")
+	}
+}
@@ -103,15 +103,24 @@ func createDefaultConfigWithTelemetry(configPath string, telemetryEnabled bool)
 		telemetryConfig = "true"
 	}
 
-	defaultConfig := fmt.Sprintf(`version: 1
+	defaultConfig := fmt.Sprintf(`version: %d
 settings:
   work_hours: 8.0
   break_interval: 50m
   idle_threshold: 10m
-
-projects:
-  - name: "default"
-    detect: ["git:.*", "dir:~/"]
+  # Day and week boundaries for totals and reports. An empty timezone uses
+  # local time; day_rollover_hour lets work past midnight count towards the
+  # previous day (e.g. 4 means days run from 04:00 to 04:00).
+  timezone: ""
+  week_start: sunday
+  day_rollover_hour: 0
+
+# Map directories or repositories to project names. Rules are checked in
+# order before rune falls back to package.json, go.mod or the git repo name:
+# projects:
+#   - name: "main-app"
+#     detect: ["git:main-app", "dir:~/projects/main-app", "branch:release/*"]
+projects: []
 
 rituals:
   start:
@@ -129,13 +138,9 @@ integrations:
     auto_detect_project: true
   telemetry:
     enabled: %s
-`, telemetryConfig)
-
-	if err := os.WriteFile(configPath, []byte(defaultConfig), 0644); err != nil {
-		return fmt.Errorf("failed to write config file: %w", err)
-	}
+`, config.CurrentVersion, telemetryConfig)
 
-	return nil
+	return config.WriteConfigFile(configPath, []byte(defaultConfig))
 }
 
 func promptTelemetryOptIn() bool {
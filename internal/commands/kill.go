@@ -0,0 +1,72 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/ferg-cod3s/rune/internal/procs"
+	"github.com/ferg-cod3s/rune/internal/telemetry"
+	"github.com/spf13/cobra"
+)
+
+var killCmd = &cobra.Command{
+	Use:   "kill [name...]",
+	Short: "Stop background processes started by rituals",
+	Long: `Stop background processes started by rituals, along with the processes they started.
+Processes that don't exit within the grace period are killed. Their logs are kept.`,
+	RunE: runKill,
+}
+
+func init() {
+	rootCmd.AddCommand(killCmd)
+
+	killCmd.Flags().Bool("all", false, "Stop every background process")
+	killCmd.Flags().String("project", "", "Stop the background processes started for a project")
+	killCmd.Flags().Duration("grace", procs.DefaultGracePeriod, "How long to wait before killing a process that ignores the stop signal")
+
+	// Wrap command with telemetry
+	telemetry.WrapCommand(killCmd, runKill)
+}
+
+func runKill(cmd *cobra.Command, args []string) error {
+	all, _ := cmd.Flags().GetBool("all")
+	project, _ := cmd.Flags().GetString("project")
+	grace, _ := cmd.Flags().GetDuration("grace")
+
+	names := args
+	if all || project != "" {
+		if len(args) > 0 {
+			return fmt.Errorf("give process names or --all/--project, not both")
+		}
+		processes, err := procs.List()
+		if err != nil {
+			return fmt.Errorf("failed to list background processes: %w", err)
+		}
+		for _, proc := range processes {
+			if all || proc.Project == project {
+				names = append(names, proc.Name)
+			}
+		}
+		if len(names) == 0 {
+			fmt.Println("No background processes to stop")
+			return nil
+		}
+	}
+	if len(names) == 0 {
+		return fmt.Errorf("give the name of a process to stop (see 'rune ps'), or use --all")
+	}
+
+	var failed int
+	for _, name := range names {
+		proc, err := procs.Kill(name, grace)
+		if err != nil {
+			fmt.Printf("❌ %v\n", err)
+			failed++
+			continue
+		}
+		fmt.Printf("🛑 Stopped %s (pid %d)\n", proc.Name, proc.PID)
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed to stop %d of %d processes", failed, len(names))
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package commands
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ferg-cod3s/rune/internal/telemetry"
+	"github.com/spf13/cobra"
+)
+
+var logCmd = &cobra.Command{
+	Use:   "log <project>",
+	Short: "Record a session you forgot to track",
+	Long: `Record a completed work session retroactively.
+
+Times can be given as HH:MM (on --date, default today), "YYYY-MM-DD HH:MM"
+or RFC 3339. If --to is earlier than --from the session is assumed to end
+the following day. Sessions may not overlap existing ones.
+
+Examples:
+  rune log api --from 09:00 --to 11:30
+  rune log api --from 22:00 --to 01:15 --date 2025-01-06 --note "release"`,
+	Args: cobra.ExactArgs(1),
+	RunE: runLog,
+}
+
+var (
+	logFrom string
+	logTo   string
+	logDate string
+	logNote string
+	logTags []string
+)
+
+func init() {
+	rootCmd.AddCommand(logCmd)
+	logCmd.Flags().StringVar(&logFrom, "from", "", "Session start time (required)")
+	logCmd.Flags().StringVar(&logTo, "to", "", "Session end time (required)")
+	logCmd.Flags().StringVar(&logDate, "date", "", "Day for HH:MM times, as YYYY-MM-DD (default: today)")
+	logCmd.Flags().StringVar(&logNote, "note", "", "Note to attach to the session")
+	logCmd.Flags().StringArrayVar(&logTags, "tag", nil, "Tag to attach to the session (repeatable)")
+	_ = logCmd.MarkFlagRequired("from")
+	_ = logCmd.MarkFlagRequired("to")
+
+	// Wrap command with telemetry
+	telemetry.WrapCommand(logCmd, runLog)
+}
+
+func runLog(cmd *cobra.Command, args []string) error {
+	cfg := loadConfig()
+	cal := calendarFor(cfg)
+	day := cal.Date(time.Now())
+	if logDate != "" {
+		var err error
+		day, err = time.ParseInLocation("2006-01-02", logDate, cal.Location)
+		if err != nil {
+			return fmt.Errorf("invalid --date %q (use YYYY-MM-DD): %w", logDate, err)
+		}
+	}
+
+	start, err := parseTimeFlag(logFrom, day)
+	if err != nil {
+		return err
+	}
+	end, err := parseTimeFlag(logTo, day)
+	if err != nil {
+		return err
+	}
+	if !end.After(start) {
+		// "--from 22:00 --to 01:00" means the session ran past midnight
+		end = end.AddDate(0, 0, 1)
+	}
+
+	tracker, err := openTracker(cfg)
+	if err != nil {
+		return err
+	}
+	defer tracker.Close()
+
+	session, err := tracker.LogSession(args[0], start, end, logNote, logTags)
+	if err != nil {
+		return fmt.Errorf("failed to log session: %w", err)
+	}
+
+	telemetry.Track("session_logged", map[string]interface{}{
+		"project":  session.Project,
+		"duration": session.Duration.Milliseconds(),
+	})
+
+	fmt.Printf("✓ Logged %s for %s (%s - %s)\n",
+		formatDuration(session.Duration), session.Project,
+		session.StartTime.Format("2006-01-02 15:04"), session.EndTime.Format("15:04"))
+	fmt.Printf("   Session ID: %s\n", session.ID)
+
+	return nil
+}
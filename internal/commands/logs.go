@@ -0,0 +1,133 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/ferg-cod3s/rune/internal/procs"
+	"github.com/ferg-cod3s/rune/internal/telemetry"
+	"github.com/spf13/cobra"
+)
+
+var logsCmd = &cobra.Command{
+	Use:   "logs <name>",
+	Short: "Show the output of a background process",
+	Long: `Show the stdout and stderr logs of a background process started by a ritual.
+Logs are kept in ~/.rune/procs after the process exits or is stopped.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runLogs,
+}
+
+func init() {
+	rootCmd.AddCommand(logsCmd)
+
+	logsCmd.Flags().IntP("lines", "n", 50, "Number of lines to show from the end of each log")
+	logsCmd.Flags().BoolP("follow", "f", false, "Keep printing new output until interrupted")
+	logsCmd.Flags().Bool("stdout", false, "Only show standard output")
+	logsCmd.Flags().Bool("stderr", false, "Only show standard error")
+	logsCmd.MarkFlagsMutuallyExclusive("stdout", "stderr")
+
+	// Wrap command with telemetry
+	telemetry.WrapCommand(logsCmd, runLogs)
+}
+
+// logFile is a log being printed, with the size already shown
+type logFile struct {
+	label  string
+	path   string
+	offset int64
+}
+
+func runLogs(cmd *cobra.Command, args []string) error {
+	lines, _ := cmd.Flags().GetInt("lines")
+	follow, _ := cmd.Flags().GetBool("follow")
+	onlyStdout, _ := cmd.Flags().GetBool("stdout")
+	onlyStderr, _ := cmd.Flags().GetBool("stderr")
+
+	name := args[0]
+	stdoutPath, stderrPath, err := procs.LogPaths(name)
+	if err != nil {
+		return err
+	}
+	var logs []*logFile
+	if !onlyStderr {
+		logs = append(logs, &logFile{label: "stdout", path: stdoutPath})
+	}
+	if !onlyStdout {
+		logs = append(logs, &logFile{label: "stderr", path: stderrPath})
+	}
+
+	found := false
+	for _, log := range logs {
+		output, size, err := procs.Tail(log.path, lines)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read %s log: %w", log.label, err)
+		}
+		found = true
+		log.offset = size
+		if len(logs) > 1 {
+			fmt.Printf("==> %s (%s) <==\n", name, log.label)
+		}
+		os.Stdout.Write(output)
+	}
+	if !found {
+		return fmt.Errorf("no logs for background process %q", name)
+	}
+	if !follow {
+		return nil
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	ticker := time.NewTicker(250 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			for _, log := range logs {
+				if err := printNewOutput(log); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+// printNewOutput prints what was written to a log since it was last read,
+// starting over if the log was truncated
+func printNewOutput(log *logFile) error {
+	file, err := os.Open(log.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s log: %w", log.label, err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	if info.Size() < log.offset {
+		log.offset = 0
+	}
+	if info.Size() == log.offset {
+		return nil
+	}
+	if _, err := file.Seek(log.offset, io.SeekStart); err != nil {
+		return err
+	}
+	written, err := io.Copy(os.Stdout, file)
+	log.offset += written
+	return err
+}
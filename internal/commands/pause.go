@@ -3,6 +3,7 @@ package commands
 import (
 	"fmt"
 
+	"github.com/ferg-cod3s/rune/internal/rituals"
 	"github.com/ferg-cod3s/rune/internal/telemetry"
 	"github.com/ferg-cod3s/rune/internal/tracking"
 	"github.com/spf13/cobra"
@@ -20,8 +21,11 @@ This command will:
 	RunE: runPause,
 }
 
+var pauseForBreak bool
+
 func init() {
 	rootCmd.AddCommand(pauseCmd)
+	pauseCmd.Flags().BoolVar(&pauseForBreak, "break", false, "Record this pause as a break")
 
 	// Wrap command with telemetry
 	telemetry.WrapCommand(pauseCmd, runPause)
@@ -31,14 +35,19 @@ func runPause(cmd *cobra.Command, args []string) error {
 	fmt.Println("⏸ Pausing work timer...")
 
 	// Initialize tracker
-	tracker, err := tracking.NewTracker()
+	cfg := loadConfig()
+	tracker, err := openTracker(cfg)
 	if err != nil {
-		return fmt.Errorf("failed to initialize tracker: %w", err)
+		return err
 	}
 	defer tracker.Close()
 
 	// Pause the session
-	session, err := tracker.Pause()
+	reason := tracking.PauseManual
+	if pauseForBreak {
+		reason = tracking.PauseBreak
+	}
+	session, err := tracker.PauseWithReason(reason)
 	if err != nil {
 		telemetry.TrackError(err, "pause", map[string]interface{}{
 			"step": "tracker_pause",
@@ -49,9 +58,16 @@ func runPause(cmd *cobra.Command, args []string) error {
 	// Track successful pause
 	telemetry.Track("session_paused", map[string]interface{}{
 		"project": session.Project,
+		"reason":  string(reason),
 	})
 
 	fmt.Println("✓ Timer paused")
+
+	// Run the pause or break_start hook, falling back to pause for breaks
+	if cfg != nil {
+		runRitualHook(cfg, rituals.PauseHook(cfg.Rituals.Hooks, reason), tracker, session)
+	}
+
 	fmt.Println("💡 Use 'rune resume' to continue your session")
 
 	return nil
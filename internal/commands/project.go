@@ -0,0 +1,76 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/ferg-cod3s/rune/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var projectCmd = &cobra.Command{
+	Use:   "project",
+	Short: "Inspect project detection",
+	Long: `Inspect how rune detects the project for the current directory.
+
+Projects are matched using the detect rules in your configuration, in
+order, then the project named by the .rune.yaml at the root of the git
+repository, before falling back to package.json, go.mod, Cargo.toml, Python
+project files, the git repository name and finally the directory name.`,
+}
+
+var projectWhichCmd = &cobra.Command{
+	Use:   "which",
+	Short: "Show which project the current directory maps to and why",
+	Args:  cobra.NoArgs,
+	RunE:  runProjectWhich,
+}
+
+func init() {
+	rootCmd.AddCommand(projectCmd)
+	projectCmd.AddCommand(projectWhichCmd)
+}
+
+func runProjectWhich(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Printf("⚠ Could not load config, using built-in detection only: %v\n", err)
+	}
+
+	// An untrusted .rune.yaml does not name the project
+	repo := loadRepoConfig()
+	if repo != nil && repo.Project != "" {
+		if trusted, err := repo.Trusted(); err != nil || !trusted {
+			fmt.Printf("💡 %s names the project %q; run 'rune trust' to use it\n\n", repo.Path, repo.Project)
+			repo = nil
+		}
+	}
+
+	detector := newProjectDetector(cfg, repo)
+	detection := detector.Explain()
+
+	if len(detection.Evaluated) > 0 {
+		fmt.Println("Rules evaluated:")
+		for _, result := range detection.Evaluated {
+			mark := "✗"
+			if result.Matched {
+				mark = "✓"
+			}
+			fmt.Printf("  %s %-15s %-30s %s\n", mark, result.Rule.Project, result.Rule.Pattern, result.Reason)
+		}
+		fmt.Println()
+	}
+
+	switch {
+	case detection.Rule != nil:
+		fmt.Printf("Project:      %s\n", detection.Project)
+		fmt.Printf("Matched by:   %s (%s)\n", detection.Rule.Pattern, detection.Reason)
+	case detection.Declared:
+		fmt.Printf("Project:      %s\n", detection.Project)
+		fmt.Printf("Declared by:  %s (no configured rule matched)\n", detection.Reason)
+	default:
+		fmt.Printf("Project:      %s\n", detector.SanitizeProjectName(detection.Project))
+		fmt.Printf("Detected by:  %s (no configured rule matched)\n", detection.Reason)
+	}
+
+	return nil
+}
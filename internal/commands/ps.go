@@ -0,0 +1,54 @@
+package commands
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ferg-cod3s/rune/internal/procs"
+	"github.com/ferg-cod3s/rune/internal/telemetry"
+	"github.com/spf13/cobra"
+)
+
+var psCmd = &cobra.Command{
+	Use:   "ps",
+	Short: "List background processes started by rituals",
+	Long: `List the background processes started by rituals, with their PID, status and uptime.
+Use 'rune logs <name>' to read a process's output and 'rune kill <name>' to stop it.`,
+	Args: cobra.NoArgs,
+	RunE: runPs,
+}
+
+func init() {
+	rootCmd.AddCommand(psCmd)
+
+	// Wrap command with telemetry
+	telemetry.WrapCommand(psCmd, runPs)
+}
+
+func runPs(cmd *cobra.Command, args []string) error {
+	// Exited processes are listed once and then no longer tracked
+	exited, err := procs.Prune()
+	if err != nil {
+		return fmt.Errorf("failed to list background processes: %w", err)
+	}
+	processes, err := procs.List()
+	if err != nil {
+		return fmt.Errorf("failed to list background processes: %w", err)
+	}
+	if len(processes) == 0 && len(exited) == 0 {
+		fmt.Println("No background processes")
+		return nil
+	}
+
+	fmt.Printf("  %-20s %-8s %-8s %-15s %-10s %s\n", "NAME", "PID", "STATUS", "PROJECT", "UPTIME", "COMMAND")
+	for _, proc := range processes {
+		fmt.Printf("  %-20s %-8d %-8s %-15s %-10s %s\n", proc.Name, proc.PID, "running", proc.Project, formatDuration(time.Since(proc.Started)), proc.Command)
+	}
+	for _, proc := range exited {
+		fmt.Printf("  %-20s %-8d %-8s %-15s %-10s %s\n", proc.Name, proc.PID, "exited", proc.Project, "-", proc.Command)
+	}
+	if len(exited) > 0 {
+		fmt.Println("💡 Exited processes are no longer tracked; their logs are kept (see 'rune logs <name>')")
+	}
+	return nil
+}
@@ -4,10 +4,15 @@ import (
 	"encoding/csv"
 	"encoding/json"
 	"fmt"
+	"math"
 	"os"
+	"regexp"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/ferg-cod3s/rune/internal/calendar"
 	"github.com/ferg-cod3s/rune/internal/tracking"
 	"github.com/spf13/cobra"
 )
@@ -21,347 +26,500 @@ This command can show:
 - Daily work summaries
 - Weekly productivity reports
 - Project-based time allocation
-- Monthly trends and insights`,
+- Monthly trends and insights
+
+Periods can be a calendar period (--today, --week, --month, --last-month),
+a date range (--from 2025-06-01 --to 2025-06-30) or a relative span
+(--since 2w, where d, w, m and y are days, weeks, months and years).
+
+Examples:
+  rune report --week --group-by day,project
+  rune report --since 30d --project api --group-by tag
+  rune report --last-month --format csv --group-by week`,
 	RunE: runReport,
 }
 
 var (
-	today   bool
-	week    bool
-	month   bool
-	project string
-	format  string
-	output  string
+	today     bool
+	week      bool
+	month     bool
+	lastMonth bool
+	fromDate  string
+	toDate    string
+	since     string
+	project   string
+	format    string
+	output    string
+	tags      []string
+	groupBy   []string
+
+	splitByDay bool
 )
 
+// groupByLevels are the dimensions accepted by --group-by
+var groupByLevels = []string{"day", "week", "project", "tag"}
+
+// sinceSpec matches relative --since values such as "2w" or "30d"
+var sinceSpec = regexp.MustCompile(`^(\d+)([dwmy])$`)
+
 func init() {
 	rootCmd.AddCommand(reportCmd)
 
 	reportCmd.Flags().BoolVar(&today, "today", false, "Show today's report")
 	reportCmd.Flags().BoolVar(&week, "week", false, "Show this week's report")
 	reportCmd.Flags().BoolVar(&month, "month", false, "Show this month's report")
+	reportCmd.Flags().BoolVar(&lastMonth, "last-month", false, "Show last month's report")
+	reportCmd.Flags().StringVar(&fromDate, "from", "", "Start date of the report (YYYY-MM-DD)")
+	reportCmd.Flags().StringVar(&toDate, "to", "", "End date of the report, inclusive (YYYY-MM-DD, default: today)")
+	reportCmd.Flags().StringVar(&since, "since", "", "Report from a relative point until today, e.g. 3d, 2w, 1m, 1y, or a date")
 	reportCmd.Flags().StringVar(&project, "project", "", "Filter by project name")
+	reportCmd.Flags().StringArrayVar(&tags, "tag", nil, "Filter by tag and break down time by tag (repeatable)")
+	reportCmd.Flags().StringSliceVar(&groupBy, "group-by", nil, "Nested breakdown by day, week, project or tag (e.g. week,project)")
 	reportCmd.Flags().StringVar(&format, "format", "text", "Output format: text, csv, json")
 	reportCmd.Flags().StringVar(&output, "output", "", "Output file (default: stdout)")
+	reportCmd.Flags().BoolVar(&splitByDay, "split-by-day", false, "Export one row per session per day for sessions spanning midnight (json, and csv without --group-by)")
+
+	reportCmd.MarkFlagsMutuallyExclusive("today", "week", "month", "last-month", "from", "since")
+	reportCmd.MarkFlagsMutuallyExclusive("today", "week", "month", "last-month", "to", "since")
 }
 
 func runReport(cmd *cobra.Command, args []string) error {
-	var sessions []*tracking.Session
-	var totalDuration time.Duration
-	var err error
-
-	// Initialize tracker
-	tracker, err := tracking.NewTracker()
+	cfg := loadConfig()
+	cal := calendarFor(cfg)
+	period, title, err := resolveReportPeriod(cal, time.Now())
 	if err != nil {
-		return fmt.Errorf("failed to initialize tracker: %w", err)
+		return err
 	}
-	defer tracker.Close()
-
-	// Get data based on period
-	if today {
-		sessions, totalDuration, err = getTodayData(tracker)
-	} else if week {
-		sessions, totalDuration, err = getWeekData(tracker)
-	} else if month {
-		sessions, totalDuration, err = getMonthData(tracker)
-	} else {
-		// Default to today's report
-		sessions, totalDuration, err = getTodayData(tracker)
+	if err := validateGroupBy(groupBy); err != nil {
+		return err
+	}
+	if splitByDay && format == "csv" && len(groupBy) > 0 {
+		return fmt.Errorf("--split-by-day cannot be combined with --group-by for csv, whose rows are groups rather than sessions")
 	}
 
+	// Initialize tracker
+	tracker, err := openTracker(cfg)
 	if err != nil {
 		return err
 	}
+	defer tracker.Close()
 
-	// Filter by project if specified
-	if project != "" {
-		var filteredSessions []*tracking.Session
-		var filteredDuration time.Duration
-		for _, session := range sessions {
-			if session.Project == project {
-				filteredSessions = append(filteredSessions, session)
-				filteredDuration += session.Duration
-			}
-		}
-		sessions = filteredSessions
-		totalDuration = filteredDuration
+	// Every view below is derived from this one filtered, clipped session set
+	sessions, totalDuration, err := getPeriodData(tracker, period)
+	if err != nil {
+		return err
 	}
+	groups := groupSessions(sessions, groupBy, cal)
 
 	// Output based on format
 	switch format {
 	case "csv":
-		return exportCSV(sessions, totalDuration)
+		if len(groupBy) > 0 {
+			return exportGroupedCSV(groups, totalDuration)
+		}
+		if splitByDay {
+			sessions = splitSessionsByDay(sessions, cal)
+		}
+		return exportCSV(sessions, totalDuration, cal)
 	case "json":
-		return exportJSON(sessions, totalDuration)
-	default:
-		// Show text report
-		if today {
-			return showTodayReport()
-		} else if week {
-			return showWeekReport()
-		} else if month {
-			return showMonthReport()
-		} else {
-			return showTodayReport()
+		if splitByDay {
+			sessions = splitSessionsByDay(sessions, cal)
 		}
+		return exportJSON(sessions, totalDuration, period, groups, cal)
+	case "text":
+		showReport(title, period, sessions, totalDuration, groups, cal)
+		return nil
+	default:
+		return fmt.Errorf("unsupported format %q (expected text, csv or json)", format)
 	}
 }
 
-func showTodayReport() error {
-	fmt.Println("📈 Today's Report")
-	fmt.Println("=================")
-	fmt.Println()
+// resolveReportPeriod returns the period and title selected by the period flags, defaulting to today
+func resolveReportPeriod(cal calendar.Calendar, now time.Time) (calendar.Period, string, error) {
+	switch {
+	case fromDate != "" || toDate != "":
+		if fromDate == "" {
+			return calendar.Period{}, "", fmt.Errorf("--to requires --from")
+		}
+		first, err := parseReportDate(fromDate, cal)
+		if err != nil {
+			return calendar.Period{}, "", err
+		}
+		last := cal.Date(now)
+		if toDate != "" {
+			if last, err = parseReportDate(toDate, cal); err != nil {
+				return calendar.Period{}, "", err
+			}
+		}
+		if last.Before(first) {
+			return calendar.Period{}, "", fmt.Errorf("--to %s is before --from %s", last.Format("2006-01-02"), first.Format("2006-01-02"))
+		}
+		return cal.Dates(first, last), fmt.Sprintf("📈 Report: %s to %s", first.Format("2006-01-02"), last.Format("2006-01-02")), nil
 
-	// Initialize tracker
-	tracker, err := tracking.NewTracker()
-	if err != nil {
-		return fmt.Errorf("failed to initialize tracker: %w", err)
+	case since != "":
+		first, err := parseSince(since, cal.Date(now), cal)
+		if err != nil {
+			return calendar.Period{}, "", err
+		}
+		return cal.Dates(first, cal.Date(now)), fmt.Sprintf("📈 Report since %s", first.Format("2006-01-02")), nil
+
+	case lastMonth:
+		period := cal.Month(cal.Month(now).Start.Add(-time.Nanosecond))
+		return period, fmt.Sprintf("📈 Report for %s", cal.Date(period.Start).Format("January 2006")), nil
+	case week:
+		return cal.Week(now), "📈 This Week's Report", nil
+	case month:
+		return cal.Month(now), "📈 This Month's Report", nil
+	default:
+		return cal.Day(now), "📈 Today's Report", nil
 	}
-	defer tracker.Close()
+}
 
-	// Get daily total
-	dailyTotal, err := tracker.GetDailyTotal()
+// parseReportDate parses a YYYY-MM-DD date in the calendar's timezone
+func parseReportDate(value string, cal calendar.Calendar) (time.Time, error) {
+	date, err := time.ParseInLocation("2006-01-02", value, cal.Location)
 	if err != nil {
-		return fmt.Errorf("failed to get daily total: %w", err)
+		return time.Time{}, fmt.Errorf("invalid date %q (use YYYY-MM-DD)", value)
 	}
+	return date, nil
+}
 
-	// Get project stats for today
-	projectStats, err := tracker.GetProjectStats()
-	if err != nil {
-		return fmt.Errorf("failed to get project stats: %w", err)
+// parseSince resolves a --since value, either a date or a count of days,
+// weeks, months or years before the current date, to the first date of the report
+func parseSince(value string, current time.Time, cal calendar.Calendar) (time.Time, error) {
+	if date, err := parseReportDate(value, cal); err == nil {
+		return date, nil
 	}
 
-	// Get today's sessions
-	sessions, err := tracker.GetSessionHistory(50) // Get more to filter by today
+	matches := sinceSpec.FindStringSubmatch(value)
+	if matches == nil {
+		return time.Time{}, fmt.Errorf("invalid --since %q (use e.g. 3d, 2w, 1m, 1y or YYYY-MM-DD)", value)
+	}
+	n, err := strconv.Atoi(matches[1])
 	if err != nil {
-		return fmt.Errorf("failed to get session history: %w", err)
+		return time.Time{}, fmt.Errorf("invalid --since %q: %w", value, err)
 	}
 
-	// Filter sessions for today
-	today := time.Now().Truncate(24 * time.Hour)
-	tomorrow := today.Add(24 * time.Hour)
-	var todaySessions []*tracking.Session
-	for _, session := range sessions {
-		if session.StartTime.After(today) && session.StartTime.Before(tomorrow) {
-			if project == "" || session.Project == project {
-				todaySessions = append(todaySessions, session)
-			}
+	switch matches[2] {
+	case "d":
+		return current.AddDate(0, 0, -n), nil
+	case "w":
+		return current.AddDate(0, 0, -7*n), nil
+	case "m":
+		return current.AddDate(0, -n, 0), nil
+	default:
+		return current.AddDate(-n, 0, 0), nil
+	}
+}
+
+// validateGroupBy checks the --group-by levels
+func validateGroupBy(levels []string) error {
+	seen := make(map[string]bool)
+	for _, level := range levels {
+		valid := false
+		for _, known := range groupByLevels {
+			valid = valid || level == known
+		}
+		if !valid {
+			return fmt.Errorf("invalid --group-by %q (expected %s)", level, strings.Join(groupByLevels, ", "))
 		}
+		if seen[level] {
+			return fmt.Errorf("--group-by %q given more than once", level)
+		}
+		seen[level] = true
 	}
+	return nil
+}
 
-	fmt.Printf("Total Time:    %s\n", formatDuration(dailyTotal))
-	fmt.Printf("Sessions:      %d\n", len(todaySessions))
+// reportFilter builds the session filter for the --project and --tag flags
+func reportFilter() tracking.SessionFilter {
+	return tracking.SessionFilter{Project: project, Tags: tags}
+}
 
-	if len(projectStats) > 0 {
-		fmt.Println("\nProject Breakdown:")
-		for proj, duration := range projectStats {
-			if project == "" || proj == project {
-				fmt.Printf("  %-15s %s\n", proj, formatDuration(duration))
-			}
+// reportGroup is one node of a --group-by breakdown
+type reportGroup struct {
+	Name     string         `json:"name"`
+	Duration string         `json:"duration"`
+	Minutes  float64        `json:"minutes"`
+	Sessions int            `json:"sessions"`
+	Groups   []*reportGroup `json:"groups,omitempty"`
+
+	level    string
+	total    time.Duration
+	ids      map[string]bool
+	children map[string]*reportGroup
+}
+
+// groupSessions builds a nested breakdown of sessions, one tree level per
+// --group-by dimension. Day and week levels split sessions at day boundaries first.
+func groupSessions(sessions []*tracking.Session, levels []string, cal calendar.Calendar) []*reportGroup {
+	if len(levels) == 0 {
+		return nil
+	}
+	for _, level := range levels {
+		if level == "day" || level == "week" {
+			sessions = splitSessionsByDay(sessions, cal)
+			break
 		}
 	}
 
-	if len(todaySessions) > 0 {
-		fmt.Println("\nToday's Sessions:")
-		for _, session := range todaySessions {
-			fmt.Printf("  %s  %-15s  %s\n",
-				session.StartTime.Format("15:04"),
-				session.Project,
-				formatDuration(session.Duration))
+	root := &reportGroup{}
+	for _, session := range sessions {
+		root.add(session, levels, cal)
+	}
+	root.finish()
+	return root.Groups
+}
+
+// add records a session under the group's children for the next level
+func (g *reportGroup) add(session *tracking.Session, levels []string, cal calendar.Calendar) {
+	if len(levels) == 0 {
+		return
+	}
+	for _, key := range groupKeys(session, levels[0], cal) {
+		if g.children == nil {
+			g.children = make(map[string]*reportGroup)
+		}
+		child, ok := g.children[key]
+		if !ok {
+			child = &reportGroup{Name: key, level: levels[0], ids: make(map[string]bool)}
+			g.children[key] = child
 		}
+		child.total += session.Duration
+		child.ids[session.ID] = true
+		child.add(session, levels[1:], cal)
 	}
+}
 
-	return nil
+// finish fills in the exported fields and orders the nested groups: days and
+// weeks chronologically, projects and tags by time spent
+func (g *reportGroup) finish() {
+	for _, child := range g.children {
+		child.Duration = formatDuration(child.total)
+		child.Minutes = math.Round(child.total.Minutes()*100) / 100
+		child.Sessions = len(child.ids)
+		child.finish()
+		g.Groups = append(g.Groups, child)
+	}
+
+	sort.Slice(g.Groups, func(i, j int) bool {
+		a, b := g.Groups[i], g.Groups[j]
+		if a.level == "day" || a.level == "week" || a.total == b.total {
+			return a.Name < b.Name
+		}
+		return a.total > b.total
+	})
 }
 
-func showWeekReport() error {
-	fmt.Println("📈 This Week's Report")
-	fmt.Println("=====================")
-	fmt.Println()
+// groupKeys returns the groups a session belongs to at one level. Sessions
+// with several tags count towards each of them.
+func groupKeys(session *tracking.Session, level string, cal calendar.Calendar) []string {
+	switch level {
+	case "day":
+		return []string{cal.Date(session.StartTime).Format("2006-01-02 Mon")}
+	case "week":
+		return []string{"Week of " + cal.Date(cal.Week(session.StartTime).Start).Format("2006-01-02")}
+	case "tag":
+		if len(session.Tags) == 0 {
+			return []string{"(untagged)"}
+		}
+		return session.Tags
+	default:
+		return []string{session.Project}
+	}
+}
 
-	// Initialize tracker
-	tracker, err := tracking.NewTracker()
-	if err != nil {
-		return fmt.Errorf("failed to initialize tracker: %w", err)
+// tagBreakdown sums session durations per tag, sorted by tag
+func tagBreakdown(sessions []*tracking.Session) ([]string, map[string]time.Duration) {
+	durations := make(map[string]time.Duration)
+	for _, session := range sessions {
+		for _, tag := range session.Tags {
+			durations[tag] += session.Duration
+		}
 	}
-	defer tracker.Close()
 
-	// Get weekly total
-	weeklyTotal, err := tracker.GetWeeklyTotal()
-	if err != nil {
-		return fmt.Errorf("failed to get weekly total: %w", err)
+	names := make([]string, 0, len(durations))
+	for tag := range durations {
+		names = append(names, tag)
 	}
+	sort.Strings(names)
+	return names, durations
+}
 
-	// Calculate daily average (divide by 7 days)
-	dailyAverage := weeklyTotal / 7
+// projectBreakdown sums session durations per project, sorted by time spent
+func projectBreakdown(sessions []*tracking.Session) ([]string, map[string]time.Duration) {
+	durations := make(map[string]time.Duration)
+	for _, session := range sessions {
+		durations[session.Project] += session.Duration
+	}
 
-	// Get project stats
-	projectStats, err := tracker.GetProjectStats()
-	if err != nil {
-		return fmt.Errorf("failed to get project stats: %w", err)
+	names := make([]string, 0, len(durations))
+	for name := range durations {
+		names = append(names, name)
 	}
+	sort.Slice(names, func(i, j int) bool {
+		if durations[names[i]] == durations[names[j]] {
+			return names[i] < names[j]
+		}
+		return durations[names[i]] > durations[names[j]]
+	})
+	return names, durations
+}
 
-	fmt.Printf("Total Time:    %s\n", formatDuration(weeklyTotal))
-	fmt.Printf("Daily Average: %s\n", formatDuration(dailyAverage))
+// printTagBreakdown prints time per tag for sessions that carry tags
+func printTagBreakdown(sessions []*tracking.Session) {
+	names, durations := tagBreakdown(sessions)
+	if len(names) == 0 {
+		return
+	}
 
-	if len(projectStats) > 0 {
-		fmt.Println("\nProject Breakdown:")
-		for proj, duration := range projectStats {
-			if project == "" || proj == project {
-				fmt.Printf("  %-15s %s\n", proj, formatDuration(duration))
-			}
-		}
+	fmt.Println("\nTag Breakdown:")
+	for _, tag := range names {
+		fmt.Printf("  %-15s %s\n", tag, formatDuration(durations[tag]))
 	}
+}
 
-	return nil
+// printGroups prints a nested --group-by breakdown
+func printGroups(groups []*reportGroup, depth int) {
+	indent := strings.Repeat("  ", depth+1)
+	for _, group := range groups {
+		fmt.Printf("%s%-*s %s\n", indent, 28-2*depth, group.Name, group.Duration)
+		printGroups(group.Groups, depth+1)
+	}
 }
 
-func showMonthReport() error {
-	fmt.Println("📈 This Month's Report")
-	fmt.Println("======================")
+// showReport prints the text report for a period
+func showReport(title string, period calendar.Period, sessions []*tracking.Session, totalDuration time.Duration, groups []*reportGroup, cal calendar.Calendar) {
+	fmt.Println(title)
+	fmt.Println(strings.Repeat("=", len([]rune(title))))
 	fmt.Println()
 
-	// Initialize tracker
-	tracker, err := tracking.NewTracker()
-	if err != nil {
-		return fmt.Errorf("failed to initialize tracker: %w", err)
+	days := math.Round(period.Duration().Hours() / 24)
+	fmt.Printf("Total Time:    %s\n", formatDuration(totalDuration))
+	if days > 1 {
+		fmt.Printf("Daily Average: %s\n", formatDuration(time.Duration(float64(totalDuration)/days)))
 	}
-	defer tracker.Close()
+	fmt.Printf("Sessions:      %d\n", len(sessions))
 
-	// Get monthly total (approximate - get all sessions and filter)
-	sessions, err := tracker.GetSessionHistory(1000) // Get many sessions
-	if err != nil {
-		return fmt.Errorf("failed to get session history: %w", err)
+	var filters []string
+	if project != "" {
+		filters = append(filters, "project "+project)
+	}
+	for _, tag := range tags {
+		filters = append(filters, "tag "+tag)
+	}
+	if len(filters) > 0 {
+		fmt.Printf("Filters:       %s\n", strings.Join(filters, ", "))
 	}
 
-	// Filter for this month
-	now := time.Now()
-	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
-	monthEnd := monthStart.AddDate(0, 1, 0)
-
-	var monthlyTotal time.Duration
-	var monthlySessions []*tracking.Session
-	for _, session := range sessions {
-		if session.StartTime.After(monthStart) && session.StartTime.Before(monthEnd) {
-			if project == "" || session.Project == project {
-				monthlyTotal += session.Duration
-				monthlySessions = append(monthlySessions, session)
+	if len(groups) > 0 {
+		fmt.Printf("\nBreakdown by %s:\n", strings.Join(groupBy, " > "))
+		printGroups(groups, 0)
+	} else {
+		names, durations := projectBreakdown(sessions)
+		if len(names) > 0 {
+			fmt.Println("\nProject Breakdown:")
+			for _, name := range names {
+				fmt.Printf("  %-15s %s\n", name, formatDuration(durations[name]))
 			}
 		}
+		printTagBreakdown(sessions)
 	}
 
-	// Calculate daily average
-	daysInMonth := monthEnd.Sub(monthStart).Hours() / 24
-	dailyAverage := time.Duration(float64(monthlyTotal) / daysInMonth)
-
-	// Get project stats
-	projectStats, err := tracker.GetProjectStats()
-	if err != nil {
-		return fmt.Errorf("failed to get project stats: %w", err)
-	}
-
-	fmt.Printf("Total Time:    %s\n", formatDuration(monthlyTotal))
-	fmt.Printf("Daily Average: %s\n", formatDuration(dailyAverage))
-	fmt.Printf("Sessions:      %d\n", len(monthlySessions))
-
-	if len(projectStats) > 0 {
-		fmt.Println("\nProject Breakdown:")
-		for proj, duration := range projectStats {
-			if project == "" || proj == project {
-				fmt.Printf("  %-15s %s\n", proj, formatDuration(duration))
+	// List individual sessions for single days and tag reports
+	if len(sessions) > 0 && (days <= 1 || len(tags) > 0) {
+		layout := "15:04"
+		if days > 1 {
+			layout = "2006-01-02 15:04"
+		}
+		fmt.Println("\nSessions:")
+		for _, session := range sessions {
+			line := fmt.Sprintf("  %s  %-15s  %s  (%s)  %s",
+				session.StartTime.In(cal.Location).Format(layout),
+				session.Project,
+				formatDuration(session.Duration),
+				formatPauseSummary(session, cal),
+				session.ID)
+			if session.Note != "" {
+				line += "  " + session.Note
 			}
+			fmt.Println(line)
 		}
 	}
-
-	return nil
 }
 
-// getTodayData returns today's sessions and total duration
-func getTodayData(tracker *tracking.Tracker) ([]*tracking.Session, time.Duration, error) {
-	sessions, err := tracker.GetSessionHistory(50)
+// getPeriodData returns the sessions matching the report filters within the
+// period and their total duration. Sessions crossing the period's boundaries
+// are clipped so only the time inside the period is counted.
+func getPeriodData(tracker sessionTracker, period calendar.Period) ([]*tracking.Session, time.Duration, error) {
+	sessions, err := tracker.Sessions(period.Start, period.End, reportFilter())
 	if err != nil {
 		return nil, 0, err
 	}
 
-	today := time.Now().Truncate(24 * time.Hour)
-	tomorrow := today.Add(24 * time.Hour)
-	var todaySessions []*tracking.Session
+	var clipped []*tracking.Session
 	var totalDuration time.Duration
-
 	for _, session := range sessions {
-		if session.StartTime.After(today) && session.StartTime.Before(tomorrow) {
-			todaySessions = append(todaySessions, session)
-			totalDuration += session.Duration
+		if piece := session.Clip(period.Start, period.End); piece != nil {
+			clipped = append(clipped, piece)
+			totalDuration += piece.Duration
 		}
 	}
 
-	return todaySessions, totalDuration, nil
+	return clipped, totalDuration, nil
 }
 
-// getWeekData returns this week's sessions and total duration
-func getWeekData(tracker *tracking.Tracker) ([]*tracking.Session, time.Duration, error) {
-	sessions, err := tracker.GetSessionHistory(500)
-	if err != nil {
-		return nil, 0, err
+// splitSessionsByDay splits every session at the calendar's day boundaries
+func splitSessionsByDay(sessions []*tracking.Session, cal calendar.Calendar) []*tracking.Session {
+	var pieces []*tracking.Session
+	for _, session := range sessions {
+		pieces = append(pieces, session.SplitByDay(cal)...)
 	}
+	return pieces
+}
 
-	now := time.Now()
-	weekStart := now.AddDate(0, 0, -int(now.Weekday()))
-	weekStart = weekStart.Truncate(24 * time.Hour)
-	weekEnd := weekStart.Add(7 * 24 * time.Hour)
-	var weekSessions []*tracking.Session
-	var totalDuration time.Duration
-
+// dailyBreakdown sums session time per calendar day, keyed by date
+func dailyBreakdown(sessions []*tracking.Session, cal calendar.Calendar) map[string]time.Duration {
+	durations := make(map[string]time.Duration)
 	for _, session := range sessions {
-		if session.StartTime.After(weekStart) && session.StartTime.Before(weekEnd) {
-			weekSessions = append(weekSessions, session)
-			totalDuration += session.Duration
+		for _, piece := range session.SplitByDay(cal) {
+			durations[cal.Date(piece.StartTime).Format("2006-01-02")] += piece.Duration
 		}
 	}
-
-	return weekSessions, totalDuration, nil
+	return durations
 }
 
-// getMonthData returns this month's sessions and total duration
-func getMonthData(tracker *tracking.Tracker) ([]*tracking.Session, time.Duration, error) {
-	sessions, err := tracker.GetSessionHistory(1000)
+// newCSVWriter writes to the --output file, or stdout when none is given
+func newCSVWriter() (*csv.Writer, func(), error) {
+	if output == "" {
+		return csv.NewWriter(os.Stdout), func() {}, nil
+	}
+	file, err := os.Create(output)
 	if err != nil {
-		return nil, 0, err
+		return nil, nil, fmt.Errorf("failed to create output file: %w", err)
 	}
+	return csv.NewWriter(file), func() { file.Close() }, nil
+}
 
-	now := time.Now()
-	monthStart := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
-	monthEnd := monthStart.AddDate(0, 1, 0)
-	var monthSessions []*tracking.Session
-	var totalDuration time.Duration
-
-	for _, session := range sessions {
-		if session.StartTime.After(monthStart) && session.StartTime.Before(monthEnd) {
-			monthSessions = append(monthSessions, session)
-			totalDuration += session.Duration
-		}
+// flushCSV writes out what the writer buffered, returning any write error
+func flushCSV(writer *csv.Writer) error {
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("failed to write CSV: %w", err)
 	}
-
-	return monthSessions, totalDuration, nil
+	return nil
 }
 
 // exportCSV exports sessions to CSV format
-func exportCSV(sessions []*tracking.Session, totalDuration time.Duration) error {
-	var writer *csv.Writer
-	if output != "" {
-		file, err := os.Create(output)
-		if err != nil {
-			return fmt.Errorf("failed to create output file: %w", err)
-		}
-		defer file.Close()
-		writer = csv.NewWriter(file)
-	} else {
-		writer = csv.NewWriter(os.Stdout)
+func exportCSV(sessions []*tracking.Session, totalDuration time.Duration, cal calendar.Calendar) error {
+	writer, closeFile, err := newCSVWriter()
+	if err != nil {
+		return err
 	}
-	defer writer.Flush()
+	defer closeFile()
 
 	// Write header
-	if err := writer.Write([]string{"Date", "Start Time", "End Time", "Project", "Duration (minutes)", "State"}); err != nil {
+	if err := writer.Write([]string{"Date", "Start Time", "End Time", "Project", "Duration (minutes)", "State", "Tags", "Note"}); err != nil {
 		return fmt.Errorf("failed to write CSV header: %w", err)
 	}
 
@@ -369,18 +527,20 @@ func exportCSV(sessions []*tracking.Session, totalDuration time.Duration) error
 	for _, session := range sessions {
 		endTime := ""
 		if session.EndTime != nil {
-			endTime = session.EndTime.Format("15:04:05")
+			endTime = session.EndTime.In(cal.Location).Format("15:04:05")
 		}
 
 		durationMinutes := strconv.FormatFloat(session.Duration.Minutes(), 'f', 2, 64)
 
 		record := []string{
-			session.StartTime.Format("2006-01-02"),
-			session.StartTime.Format("15:04:05"),
+			cal.Date(session.StartTime).Format("2006-01-02"),
+			session.StartTime.In(cal.Location).Format("15:04:05"),
 			endTime,
 			session.Project,
 			durationMinutes,
 			session.State.String(),
+			strings.Join(session.Tags, ";"),
+			session.Note,
 		}
 
 		if err := writer.Write(record); err != nil {
@@ -390,43 +550,110 @@ func exportCSV(sessions []*tracking.Session, totalDuration time.Duration) error
 
 	// Write summary row
 	totalMinutes := strconv.FormatFloat(totalDuration.Minutes(), 'f', 2, 64)
-	summaryRecord := []string{"TOTAL", "", "", "", totalMinutes, ""}
+	summaryRecord := []string{"TOTAL", "", "", "", totalMinutes, "", "", ""}
 	if err := writer.Write(summaryRecord); err != nil {
 		return fmt.Errorf("failed to write CSV summary: %w", err)
 	}
 
-	return nil
+	return flushCSV(writer)
+}
+
+// exportGroupedCSV exports a --group-by breakdown to CSV, one row per innermost group
+func exportGroupedCSV(groups []*reportGroup, totalDuration time.Duration) error {
+	writer, closeFile, err := newCSVWriter()
+	if err != nil {
+		return err
+	}
+	defer closeFile()
+
+	// Write header
+	header := make([]string, 0, len(groupBy)+2)
+	for _, level := range groupBy {
+		header = append(header, strings.ToUpper(level[:1])+level[1:])
+	}
+	header = append(header, "Duration (minutes)", "Sessions")
+	if err := writer.Write(header); err != nil {
+		return fmt.Errorf("failed to write CSV header: %w", err)
+	}
+
+	// Write groups
+	var writeRows func(groups []*reportGroup, path []string) error
+	writeRows = func(groups []*reportGroup, path []string) error {
+		for _, group := range groups {
+			row := append(append([]string{}, path...), group.Name)
+			if len(group.Groups) > 0 {
+				if err := writeRows(group.Groups, row); err != nil {
+					return err
+				}
+				continue
+			}
+			row = append(row, strconv.FormatFloat(group.Minutes, 'f', 2, 64), strconv.Itoa(group.Sessions))
+			if err := writer.Write(row); err != nil {
+				return fmt.Errorf("failed to write CSV record: %w", err)
+			}
+		}
+		return nil
+	}
+	if err := writeRows(groups, nil); err != nil {
+		return err
+	}
+
+	// Write summary row
+	summaryRecord := make([]string, len(groupBy)+2)
+	summaryRecord[0] = "TOTAL"
+	summaryRecord[len(groupBy)] = strconv.FormatFloat(totalDuration.Minutes(), 'f', 2, 64)
+	if err := writer.Write(summaryRecord); err != nil {
+		return fmt.Errorf("failed to write CSV summary: %w", err)
+	}
+
+	return flushCSV(writer)
 }
 
 // ReportData represents the structure for JSON export
 type ReportData struct {
 	GeneratedAt   time.Time              `json:"generated_at"`
+	From          time.Time              `json:"from"`
+	To            time.Time              `json:"to"`
 	TotalDuration string                 `json:"total_duration"`
 	Sessions      []*tracking.Session    `json:"sessions"`
+	Groups        []*reportGroup         `json:"groups,omitempty"`
 	Summary       map[string]interface{} `json:"summary"`
 }
 
 // exportJSON exports sessions to JSON format
-func exportJSON(sessions []*tracking.Session, totalDuration time.Duration) error {
-	// Calculate project breakdown
-	projectStats := make(map[string]time.Duration)
-	for _, session := range sessions {
-		projectStats[session.Project] += session.Duration
-	}
-
+func exportJSON(sessions []*tracking.Session, totalDuration time.Duration, period calendar.Period, groups []*reportGroup, cal calendar.Calendar) error {
 	// Convert project stats to string format for JSON
+	projectNames, projectDurations := projectBreakdown(sessions)
 	projectStatsStr := make(map[string]string)
-	for project, duration := range projectStats {
-		projectStatsStr[project] = formatDuration(duration)
+	for _, name := range projectNames {
+		projectStatsStr[name] = formatDuration(projectDurations[name])
+	}
+
+	// Convert tag stats to string format for JSON
+	tagNames, tagDurations := tagBreakdown(sessions)
+	tagStatsStr := make(map[string]string)
+	for _, tag := range tagNames {
+		tagStatsStr[tag] = formatDuration(tagDurations[tag])
+	}
+
+	// Convert daily stats to string format for JSON
+	dailyStatsStr := make(map[string]string)
+	for date, duration := range dailyBreakdown(sessions, cal) {
+		dailyStatsStr[date] = formatDuration(duration)
 	}
 
 	data := ReportData{
 		GeneratedAt:   time.Now(),
+		From:          period.Start,
+		To:            period.End,
 		TotalDuration: formatDuration(totalDuration),
 		Sessions:      sessions,
+		Groups:        groups,
 		Summary: map[string]interface{}{
 			"total_sessions":    len(sessions),
 			"project_breakdown": projectStatsStr,
+			"tag_breakdown":     tagStatsStr,
+			"daily_breakdown":   dailyStatsStr,
 		},
 	}
 
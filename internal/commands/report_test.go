@@ -0,0 +1,213 @@
+package commands
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ferg-cod3s/rune/internal/calendar"
+	"github.com/ferg-cod3s/rune/internal/tracking"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSince(t *testing.T) {
+	cal := calendar.Calendar{Location: time.UTC}
+	current := time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value    string
+		expected time.Time
+		wantErr  bool
+	}{
+		{value: "3d", expected: time.Date(2025, 3, 28, 0, 0, 0, 0, time.UTC)},
+		{value: "2w", expected: time.Date(2025, 3, 17, 0, 0, 0, 0, time.UTC)},
+		{value: "1m", expected: time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC)},
+		{value: "1y", expected: time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC)},
+		{value: "2025-01-15", expected: time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC)},
+		{value: "2x", wantErr: true},
+		{value: "w", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseSince(tt.value, current, cal)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.True(t, tt.expected.Equal(got), "want %s, got %s", tt.expected, got)
+		})
+	}
+}
+
+func TestResolveReportPeriod(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+	cal := calendar.Calendar{Location: berlin, WeekStart: time.Monday}
+	now := time.Date(2025, 4, 16, 10, 0, 0, 0, berlin)
+
+	reset := func() {
+		today, week, month, lastMonth = false, false, false, false
+		fromDate, toDate, since = "", "", ""
+	}
+	defer reset()
+
+	tests := []struct {
+		name      string
+		set       func()
+		wantStart time.Time
+		wantEnd   time.Time
+		wantErr   string
+	}{
+		{
+			name:      "defaults to today",
+			set:       func() {},
+			wantStart: time.Date(2025, 4, 16, 0, 0, 0, 0, berlin),
+			wantEnd:   time.Date(2025, 4, 17, 0, 0, 0, 0, berlin),
+		},
+		{
+			name:      "last month",
+			set:       func() { lastMonth = true },
+			wantStart: time.Date(2025, 3, 1, 0, 0, 0, 0, berlin),
+			wantEnd:   time.Date(2025, 4, 1, 0, 0, 0, 0, berlin),
+		},
+		{
+			name:      "inclusive date range",
+			set:       func() { fromDate, toDate = "2025-03-29", "2025-03-30" },
+			wantStart: time.Date(2025, 3, 29, 0, 0, 0, 0, berlin),
+			wantEnd:   time.Date(2025, 3, 31, 0, 0, 0, 0, berlin),
+		},
+		{
+			name:      "from runs through today",
+			set:       func() { fromDate = "2025-04-14" },
+			wantStart: time.Date(2025, 4, 14, 0, 0, 0, 0, berlin),
+			wantEnd:   time.Date(2025, 4, 17, 0, 0, 0, 0, berlin),
+		},
+		{
+			name:      "since a relative span",
+			set:       func() { since = "1w" },
+			wantStart: time.Date(2025, 4, 9, 0, 0, 0, 0, berlin),
+			wantEnd:   time.Date(2025, 4, 17, 0, 0, 0, 0, berlin),
+		},
+		{
+			name:    "to without from",
+			set:     func() { toDate = "2025-04-01" },
+			wantErr: "--to requires --from",
+		},
+		{
+			name:    "to before from",
+			set:     func() { fromDate, toDate = "2025-04-02", "2025-04-01" },
+			wantErr: "is before",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reset()
+			tt.set()
+			period, title, err := resolveReportPeriod(cal, now)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.NotEmpty(t, title)
+			assert.True(t, tt.wantStart.Equal(period.Start), "start: want %s, got %s", tt.wantStart, period.Start)
+			assert.True(t, tt.wantEnd.Equal(period.End), "end: want %s, got %s", tt.wantEnd, period.End)
+		})
+	}
+}
+
+func TestValidateGroupBy(t *testing.T) {
+	assert.NoError(t, validateGroupBy([]string{"week", "project", "tag"}))
+	assert.ErrorContains(t, validateGroupBy([]string{"hour"}), "invalid --group-by")
+	assert.ErrorContains(t, validateGroupBy([]string{"day", "day"}), "more than once")
+}
+
+func TestGroupSessions(t *testing.T) {
+	cal := calendar.Calendar{Location: time.UTC, WeekStart: time.Monday}
+	session := func(id, project string, start time.Time, hours int, tags ...string) *tracking.Session {
+		end := start.Add(time.Duration(hours) * time.Hour)
+		return &tracking.Session{
+			ID:        id,
+			Project:   project,
+			StartTime: start,
+			EndTime:   &end,
+			Duration:  end.Sub(start),
+			State:     tracking.StateStopped,
+			Tags:      tags,
+			Intervals: []tracking.Interval{{Kind: tracking.IntervalWork, Start: start, End: &end}},
+		}
+	}
+
+	sessions := []*tracking.Session{
+		session("a", "api", time.Date(2025, 6, 9, 9, 0, 0, 0, time.UTC), 2, "review"),
+		session("b", "web", time.Date(2025, 6, 9, 13, 0, 0, 0, time.UTC), 4),
+		// Sunday night into Monday crosses into the next week
+		session("c", "api", time.Date(2025, 6, 15, 23, 0, 0, 0, time.UTC), 2, "review", "ops"),
+	}
+
+	t.Run("week then project", func(t *testing.T) {
+		groups := groupSessions(sessions, []string{"week", "project"}, cal)
+		require.Len(t, groups, 2)
+
+		assert.Equal(t, "Week of 2025-06-09", groups[0].Name)
+		assert.Equal(t, 3, groups[0].Sessions)
+		require.Len(t, groups[0].Groups, 2)
+		assert.Equal(t, "web", groups[0].Groups[0].Name, "projects are ordered by time spent")
+		assert.Equal(t, float64(240), groups[0].Groups[0].Minutes)
+		assert.Equal(t, "api", groups[0].Groups[1].Name)
+		assert.Equal(t, float64(180), groups[0].Groups[1].Minutes, "api has 2h on Monday and 1h on Sunday")
+
+		assert.Equal(t, "Week of 2025-06-16", groups[1].Name)
+		assert.Equal(t, float64(60), groups[1].Minutes)
+	})
+
+	t.Run("tag counts sessions towards each tag", func(t *testing.T) {
+		groups := groupSessions(sessions, []string{"tag"}, cal)
+		require.Len(t, groups, 3)
+
+		byName := make(map[string]*reportGroup)
+		for _, group := range groups {
+			byName[group.Name] = group
+		}
+		assert.Equal(t, float64(240), byName["review"].Minutes)
+		assert.Equal(t, 2, byName["review"].Sessions)
+		assert.Equal(t, float64(120), byName["ops"].Minutes)
+		assert.Equal(t, float64(240), byName["(untagged)"].Minutes)
+	})
+
+	t.Run("no levels", func(t *testing.T) {
+		assert.Nil(t, groupSessions(sessions, nil, cal))
+	})
+}
+
+func TestFormatPauseSummary(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	require.NoError(t, err)
+	cal := calendar.Calendar{Location: tokyo}
+
+	start := time.Date(2025, 3, 10, 0, 30, 0, 0, time.UTC)
+	end := start.Add(2 * time.Hour)
+	session := &tracking.Session{StartTime: start, EndTime: &end}
+	assert.Equal(t, "started 09:30", formatPauseSummary(session, cal))
+
+	pauseEnd := start.Add(20 * time.Minute)
+	session.Intervals = []tracking.Interval{{Kind: tracking.IntervalPause, Start: start.Add(5 * time.Minute), End: &pauseEnd}}
+	assert.Equal(t, "started 09:30, paused once for 0h 15m total", formatPauseSummary(session, cal))
+}
+
+func TestExportCSV_ReturnsWriteErrors(t *testing.T) {
+	if _, err := os.Stat("/dev/full"); err != nil {
+		t.Skip("needs /dev/full")
+	}
+	defer func(old string) { output = old }(output)
+	output = "/dev/full"
+
+	err := exportCSV(nil, time.Hour, calendar.Default())
+	assert.ErrorContains(t, err, "failed to write CSV")
+	err = exportGroupedCSV(nil, time.Hour)
+	assert.ErrorContains(t, err, "failed to write CSV")
+}
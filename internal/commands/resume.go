@@ -3,8 +3,8 @@ package commands
 import (
 	"fmt"
 
+	"github.com/ferg-cod3s/rune/internal/rituals"
 	"github.com/ferg-cod3s/rune/internal/telemetry"
-	"github.com/ferg-cod3s/rune/internal/tracking"
 	"github.com/spf13/cobra"
 )
 
@@ -31,9 +31,10 @@ func runResume(cmd *cobra.Command, args []string) error {
 	fmt.Println("▶️ Resuming work timer...")
 
 	// Initialize tracker
-	tracker, err := tracking.NewTracker()
+	cfg := loadConfig()
+	tracker, err := openTracker(cfg)
 	if err != nil {
-		return fmt.Errorf("failed to initialize tracker: %w", err)
+		return err
 	}
 	defer tracker.Close()
 
@@ -52,6 +53,12 @@ func runResume(cmd *cobra.Command, args []string) error {
 	})
 
 	fmt.Println("✓ Timer resumed")
+
+	// Run the resume or break_end hook, falling back to resume after breaks
+	if cfg != nil {
+		runRitualHook(cfg, rituals.ResumeHook(cfg.Rituals.Hooks, session), tracker, session)
+	}
+
 	fmt.Println("🎯 Back to work!")
 
 	return nil
@@ -2,6 +2,8 @@ package commands
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/ferg-cod3s/rune/internal/config"
 	"github.com/ferg-cod3s/rune/internal/rituals"
@@ -26,26 +28,56 @@ var ritualListCmd = &cobra.Command{
 }
 
 var ritualTestCmd = &cobra.Command{
-	Use:   "test <start|stop> [project]",
+	Use:   "test <name> [project]",
 	Short: "Test a ritual without executing it",
-	Long:  `Test a ritual configuration without actually executing the commands.`,
-	Args:  cobra.RangeArgs(1, 2),
-	RunE:  runRitualTest,
+	Long: `Test a ritual configuration without actually executing the commands.
+The ritual is start, stop or the name of a custom ritual.`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: runRitualTest,
 }
 
 var ritualRunCmd = &cobra.Command{
-	Use:   "run <start|stop> [project]",
+	Use:   "run <name> [project]",
 	Short: "Run a specific ritual",
-	Long:  `Run a specific ritual without affecting time tracking.`,
-	Args:  cobra.RangeArgs(1, 2),
-	RunE:  runRitualRun,
+	Long: `Run a specific ritual without affecting time tracking.
+The ritual is start, stop or the name of a custom ritual.`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: runRitualRun,
 }
 
+var ritualHistoryCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Show past ritual runs",
+	Long: `Show past ritual runs, newest first.
+Use 'rune ritual show <run-id>' to see the output of each command of a run.`,
+	Args: cobra.NoArgs,
+	RunE: runRitualHistory,
+}
+
+var ritualShowCmd = &cobra.Command{
+	Use:   "show [run-id]",
+	Short: "Show the details of a ritual run",
+	Long: `Show each command of a ritual run with its exit code, duration and output.
+Without a run ID the most recent run is shown.`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runRitualShow,
+}
+
+var (
+	ritualHistoryFailed bool
+	ritualHistoryLimit  int
+)
+
 func init() {
 	rootCmd.AddCommand(ritualCmd)
 	ritualCmd.AddCommand(ritualListCmd)
 	ritualCmd.AddCommand(ritualTestCmd)
 	ritualCmd.AddCommand(ritualRunCmd)
+	ritualCmd.AddCommand(ritualHistoryCmd)
+	ritualCmd.AddCommand(ritualShowCmd)
+
+	ritualHistoryCmd.Flags().BoolVar(&ritualHistoryFailed, "failed", false, "Only show failed runs")
+	ritualHistoryCmd.Flags().IntVarP(&ritualHistoryLimit, "limit", "n", 20, "Maximum number of runs to show (0 for all)")
 }
 
 func runRitualList(cmd *cobra.Command, args []string) error {
@@ -58,49 +90,67 @@ func runRitualList(cmd *cobra.Command, args []string) error {
 	fmt.Println("====================")
 	fmt.Println()
 
-	// Show start rituals
-	fmt.Println("Start Rituals:")
-	if len(cfg.Rituals.Start.Global) > 0 {
-		fmt.Println("  Global:")
-		for _, cmd := range cfg.Rituals.Start.Global {
-			fmt.Printf("    - %s: %s\n", cmd.Name, cmd.Command)
+	for _, name := range cfg.Rituals.RitualNames() {
+		set, _ := cfg.Rituals.Ritual(name)
+		fmt.Printf("%s:\n", ritualTitle(name))
+		if len(set.Global) > 0 {
+			fmt.Println("  Global:")
+			for _, cmd := range set.Global {
+				fmt.Printf("    - %s: %s\n", cmd.Name, cmd.Command)
+			}
 		}
-	}
 
-	if len(cfg.Rituals.Start.PerProject) > 0 {
-		fmt.Println("  Per-Project:")
-		for project, commands := range cfg.Rituals.Start.PerProject {
-			fmt.Printf("    %s:\n", project)
-			for _, cmd := range commands {
-				fmt.Printf("      - %s: %s\n", cmd.Name, cmd.Command)
+		if len(set.PerProject) > 0 {
+			fmt.Println("  Per-Project:")
+			for project, commands := range set.PerProject {
+				fmt.Printf("    %s:\n", project)
+				for _, cmd := range commands {
+					fmt.Printf("      - %s: %s\n", cmd.Name, cmd.Command)
+				}
 			}
 		}
-	}
 
-	fmt.Println()
-
-	// Show stop rituals
-	fmt.Println("Stop Rituals:")
-	if len(cfg.Rituals.Stop.Global) > 0 {
-		fmt.Println("  Global:")
-		for _, cmd := range cfg.Rituals.Stop.Global {
-			fmt.Printf("    - %s: %s\n", cmd.Name, cmd.Command)
+		if len(set.Finally) > 0 {
+			fmt.Println("  Finally:")
+			for _, cmd := range set.Finally {
+				fmt.Printf("    - %s: %s\n", cmd.Name, cmd.Command)
+			}
 		}
+
+		fmt.Println()
 	}
 
-	if len(cfg.Rituals.Stop.PerProject) > 0 {
-		fmt.Println("  Per-Project:")
-		for project, commands := range cfg.Rituals.Stop.PerProject {
-			fmt.Printf("    %s:\n", project)
-			for _, cmd := range commands {
-				fmt.Printf("      - %s: %s\n", cmd.Name, cmd.Command)
-			}
-		}
+	hooks := cfg.Rituals.Hooks
+	if hooks != (config.RitualHooks{}) {
+		fmt.Println("Hooks:")
+		printHook("pause", hooks.Pause)
+		printHook("resume", hooks.Resume)
+		printHook("break start", hooks.BreakStart)
+		printHook("break end", hooks.BreakEnd)
 	}
 
 	return nil
 }
 
+// ritualTitle returns the heading of a ritual in the ritual list
+func ritualTitle(name string) string {
+	switch name {
+	case "start":
+		return "Start Rituals"
+	case "stop":
+		return "Stop Rituals"
+	default:
+		return fmt.Sprintf("Custom Ritual '%s'", name)
+	}
+}
+
+// printHook prints which ritual a hook runs, if any
+func printHook(event, ritual string) {
+	if ritual != "" {
+		fmt.Printf("  On %s: %s\n", event, ritual)
+	}
+}
+
 func runRitualTest(cmd *cobra.Command, args []string) error {
 	cfg, err := config.Load()
 	if err != nil {
@@ -108,15 +158,8 @@ func runRitualTest(cmd *cobra.Command, args []string) error {
 	}
 
 	ritualType := args[0]
-	var project string
-
-	if len(args) > 1 {
-		project = args[1]
-	} else {
-		// Auto-detect project
-		detector := tracking.NewProjectDetector()
-		project = detector.SanitizeProjectName(detector.DetectProject())
-	}
+	project, repo := resolveProject(cfg, args[1:])
+	applyRepoConfig(cfg, repo, project)
 
 	engine := rituals.NewEngine(cfg)
 	return engine.TestRitual(ritualType, project)
@@ -129,24 +172,164 @@ func runRitualRun(cmd *cobra.Command, args []string) error {
 	}
 
 	ritualType := args[0]
-	var project string
+	project, repo := resolveProject(cfg, args[1:])
+	applyRepoConfig(cfg, repo, project)
+
+	engine := newRitualEngine(cfg)
+	// Record the run in the ritual history when the session database is available
+	if tracker, err := openTracker(cfg); err == nil {
+		defer tracker.Close()
+		engine.RecordRuns(tracker)
+		if session, err := tracker.GetCurrentSession(); err == nil && session != nil {
+			engine.SetSession(session)
+		}
+	}
+
+	return engine.ExecuteRitual(ritualType, project)
+}
+
+func runRitualHistory(cmd *cobra.Command, args []string) error {
+	tracker, err := openTracker(loadConfig())
+	if err != nil {
+		return err
+	}
+	defer tracker.Close()
 
-	if len(args) > 1 {
-		project = args[1]
+	runs, err := tracker.RitualRuns(ritualHistoryLimit, ritualHistoryFailed)
+	if err != nil {
+		return fmt.Errorf("failed to get ritual history: %w", err)
+	}
+	if len(runs) == 0 {
+		if ritualHistoryFailed {
+			fmt.Println("No failed ritual runs")
+		} else {
+			fmt.Println("No ritual runs recorded yet")
+		}
+		return nil
+	}
+
+	fmt.Printf("  %-27s %-16s %-6s %-15s %-10s %s\n", "ID", "STARTED", "RITUAL", "PROJECT", "DURATION", "RESULT")
+	for _, run := range runs {
+		fmt.Printf("  %-27s %-16s %-6s %-15s %-10s %s\n", run.ID, run.StartTime.Format("2006-01-02 15:04"),
+			run.Ritual, run.Project, formatDuration(run.Duration), ritualRunResult(run))
+	}
+	return nil
+}
+
+func runRitualShow(cmd *cobra.Command, args []string) error {
+	tracker, err := openTracker(loadConfig())
+	if err != nil {
+		return err
+	}
+	defer tracker.Close()
+
+	var run *tracking.RitualRun
+	if len(args) > 0 {
+		run, err = tracker.GetRitualRun(args[0])
+		if err != nil {
+			return err
+		}
 	} else {
-		// Auto-detect project
-		detector := tracking.NewProjectDetector()
-		project = detector.SanitizeProjectName(detector.DetectProject())
+		runs, err := tracker.RitualRuns(1, false)
+		if err != nil {
+			return fmt.Errorf("failed to get ritual history: %w", err)
+		}
+		if len(runs) == 0 {
+			return fmt.Errorf("no ritual runs recorded yet")
+		}
+		run = runs[0]
 	}
 
-	engine := rituals.NewEngine(cfg)
+	fmt.Printf("🔮 %s ritual for project: %s\n", run.Ritual, run.Project)
+	fmt.Printf("ID:       %s\n", run.ID)
+	fmt.Printf("Started:  %s\n", run.StartTime.Format("2006-01-02 15:04:05"))
+	fmt.Printf("Duration: %s\n", formatDuration(run.Duration))
+	if run.SessionID != "" {
+		fmt.Printf("Session:  %s\n", run.SessionID)
+	}
+	fmt.Printf("Result:   %s\n", ritualRunResult(run))
 
-	switch ritualType {
-	case "start":
-		return engine.ExecuteStartRituals(project)
-	case "stop":
-		return engine.ExecuteStopRituals(project)
+	for _, step := range run.Steps {
+		name := step.Name
+		if step.Finally {
+			name = "finally: " + name
+		}
+		fmt.Println()
+		fmt.Printf("%s %s: %s\n", ritualStepIcon(step.Outcome), name, step.Outcome)
+		fmt.Printf("  command:   %s\n", step.Command)
+		if step.Detail != "" {
+			fmt.Printf("  detail:    %s\n", step.Detail)
+		}
+		if step.Attempts > 0 {
+			fmt.Printf("  exit code: %d\n", step.ExitCode)
+			fmt.Printf("  duration:  %s\n", step.Duration.Round(time.Millisecond))
+		}
+		if step.Attempts > 1 {
+			fmt.Printf("  attempts:  %d\n", step.Attempts)
+		}
+		if step.OnFailure != "" {
+			fmt.Printf("  on_failure %s\n", step.OnFailure)
+		}
+		if step.Undo != "" {
+			fmt.Printf("  undo %s\n", step.Undo)
+		}
+		printRitualOutput("stdout", step.Stdout)
+		printRitualOutput("stderr", step.Stderr)
+	}
+	return nil
+}
+
+// ritualRunResult describes whether a ritual run succeeded
+func ritualRunResult(run *tracking.RitualRun) string {
+	if run.Failed() {
+		return "❌ " + run.Error
+	}
+	return "✓ succeeded"
+}
+
+// ritualStepIcon returns the icon for a step outcome
+func ritualStepIcon(outcome string) string {
+	switch outcome {
+	case tracking.StepSucceeded:
+		return "✓"
+	case tracking.StepFailed:
+		return "❌"
+	case tracking.StepFailedOptional:
+		return "⚠"
 	default:
-		return fmt.Errorf("unknown ritual type: %s (use 'start' or 'stop')", ritualType)
+		return "⏭ "
 	}
 }
+
+// printRitualOutput prints captured command output, indented
+func printRitualOutput(label, output string) {
+	output = strings.TrimRight(output, "\n")
+	if output == "" {
+		return
+	}
+	fmt.Printf("  %s:\n", label)
+	for _, line := range strings.Split(output, "\n") {
+		fmt.Printf("    %s\n", line)
+	}
+}
+
+// runRitualHook runs the ritual a hook names, if any, for the session's project.
+// A failing ritual is reported but does not undo the change that triggered it.
+func runRitualHook(cfg *config.Config, ritual string, tracker sessionTracker, session *tracking.Session) {
+	if ritual == "" {
+		return
+	}
+	engine := newRitualEngine(cfg)
+	engine.RecordRuns(tracker)
+	if err := engine.ExecuteHook(ritual, session); err != nil {
+		fmt.Printf("⚠ %v\n", err)
+	}
+}
+
+// newRitualEngine returns a ritual engine that answers confirmations and
+// prompts itself when --yes is set
+func newRitualEngine(cfg *config.Config) *rituals.Engine {
+	engine := rituals.NewEngine(cfg)
+	engine.AssumeYes(assumeYes)
+	return engine
+}
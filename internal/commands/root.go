@@ -13,6 +13,8 @@ import (
 var (
 	cfgFile string
 	version = "dev"
+	// assumeYes answers ritual confirmations and prompts without asking
+	assumeYes bool
 )
 
 // rootCmd represents the base command when called without any subcommands
@@ -57,6 +59,7 @@ version {{.Version}}
 	// Global flags
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.rune/config.yaml)")
 	rootCmd.PersistentFlags().BoolP("verbose", "v", false, "verbose output")
+	rootCmd.PersistentFlags().BoolVarP(&assumeYes, "yes", "y", false, "answer yes to ritual confirmations and use prompt defaults")
 
 	// Bind flags to viper
 	_ = viper.BindPFlag("verbose", rootCmd.PersistentFlags().Lookup("verbose"))
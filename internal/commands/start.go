@@ -2,11 +2,11 @@ package commands
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/ferg-cod3s/rune/internal/config"
 	"github.com/ferg-cod3s/rune/internal/dnd"
 	"github.com/ferg-cod3s/rune/internal/notifications"
-	"github.com/ferg-cod3s/rune/internal/rituals"
 	"github.com/ferg-cod3s/rune/internal/telemetry"
 	"github.com/ferg-cod3s/rune/internal/tracking"
 	"github.com/spf13/cobra"
@@ -23,12 +23,23 @@ This command will:
 - Execute project-specific start rituals (if detected)
 - Enable focus mode (Do Not Disturb) if configured
 
-If no project is specified, it will be auto-detected from the current directory.`,
+If no project is specified, it will be auto-detected from the current directory.
+
+Examples:
+  rune start
+  rune start api --tag bug --tag JIRA-123 --note "fix login"`,
 	RunE: runStart,
 }
 
+var (
+	startTags []string
+	startNote string
+)
+
 func init() {
 	rootCmd.AddCommand(startCmd)
+	startCmd.Flags().StringArrayVar(&startTags, "tag", nil, "Tag to attach to the session (repeatable)")
+	startCmd.Flags().StringVar(&startNote, "note", "", "Note to attach to the session")
 
 	// Wrap command with telemetry
 	telemetry.WrapCommand(startCmd, runStart)
@@ -37,39 +48,33 @@ func init() {
 func runStart(cmd *cobra.Command, args []string) error {
 	fmt.Println("🔮 Casting your start ritual...")
 
-	// Load configuration to get idle threshold
-	var tracker *tracking.Tracker
-	cfg, configErr := config.Load()
-	if configErr != nil {
-		// Use default tracker if config fails to load
-		var err error
-		tracker, err = tracking.NewTracker()
-		if err != nil {
-			return fmt.Errorf("failed to initialize tracker: %w", err)
-		}
-	} else {
-		// Use configured idle threshold
-		var err error
-		tracker, err = tracking.NewTrackerWithIdleThreshold(cfg.Settings.IdleThreshold)
-		if err != nil {
-			return fmt.Errorf("failed to initialize tracker: %w", err)
-		}
+	// Load configuration once for the tracker, project detection and rituals
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Printf("⚠ Could not load config for rituals: %v\n", err)
+	}
+
+	// Initialize tracker
+	tracker, err := openTracker(cfg)
+	if err != nil {
+		return err
 	}
 	defer tracker.Close()
 
-	// Determine project name
-	var project string
-	if len(args) > 0 {
-		// Use provided project name
-		project = args[0]
-	} else {
-		// Auto-detect project
-		detector := tracking.NewProjectDetector()
-		project = detector.SanitizeProjectName(detector.DetectProject())
+	// Determine project name, auto-detecting it unless one is given
+	project, repo := resolveProject(cfg, args)
+
+	// Sessions in the repository carry the tags its .rune.yaml declares
+	tags := startTags
+	if repo != nil {
+		tags = append(append([]string{}, repo.Tags...), startTags...)
 	}
 
 	// Start time tracking
-	session, err := tracker.Start(project)
+	session, err := tracker.StartWithOptions(project, tracking.StartOptions{
+		Tags: tags,
+		Note: startNote,
+	})
 	if err != nil {
 		telemetry.TrackError(err, "start", map[string]interface{}{
 			"project": project,
@@ -82,18 +87,15 @@ func runStart(cmd *cobra.Command, args []string) error {
 	telemetry.Track("session_started", map[string]interface{}{
 		"project":       project,
 		"auto_detected": len(args) == 0,
+		"tags":          len(session.Tags),
 	})
 
-	// Load configuration and execute start rituals (reuse cfg if already loaded)
-	if cfg == nil {
-		var err error
-		cfg, err = config.Load()
-		if err != nil {
-			fmt.Printf("⚠ Could not load config for rituals: %v\n", err)
-		}
-	}
+	// Execute start rituals
 	if cfg != nil {
-		engine := rituals.NewEngine(cfg)
+		applyRepoConfig(cfg, repo, project)
+		engine := newRitualEngine(cfg)
+		engine.RecordRuns(tracker)
+		engine.SetSession(session)
 		if err := engine.ExecuteStartRituals(project); err != nil {
 			fmt.Printf("⚠ Start rituals failed: %v\n", err)
 		}
@@ -133,6 +135,14 @@ func runStart(cmd *cobra.Command, args []string) error {
 
 	fmt.Println("✓ Start ritual complete")
 	fmt.Printf("⏰ Work timer started for project: %s\n", session.Project)
+	if len(session.Tags) > 0 {
+		fmt.Printf("🏷  Tags: %s\n", strings.Join(session.Tags, ", "))
+	}
+
+	// Idle detection and reminders only run inside the daemon
+	if _, local := tracker.(*tracking.Tracker); local {
+		fmt.Println("💡 Run 'rune daemon start' to enable idle detection and break reminders")
+	}
 
 	return nil
 }
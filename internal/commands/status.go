@@ -3,11 +3,9 @@ package commands
 import (
 	"fmt"
 
-	"github.com/ferg-cod3s/rune/internal/config"
 	"github.com/ferg-cod3s/rune/internal/dnd"
 	"github.com/ferg-cod3s/rune/internal/notifications"
 	"github.com/ferg-cod3s/rune/internal/telemetry"
-	"github.com/ferg-cod3s/rune/internal/tracking"
 	"github.com/spf13/cobra"
 )
 
@@ -38,9 +36,10 @@ func runStatus(cmd *cobra.Command, args []string) error {
 	fmt.Println()
 
 	// Initialize tracker
-	tracker, err := tracking.NewTracker()
+	cfg := loadConfig()
+	tracker, err := openTracker(cfg)
 	if err != nil {
-		return fmt.Errorf("failed to initialize tracker: %w", err)
+		return err
 	}
 	defer tracker.Close()
 
@@ -63,6 +62,7 @@ func runStatus(cmd *cobra.Command, args []string) error {
 		fmt.Printf("Timer:        %s\n", session.State)
 		fmt.Printf("Project:      %s\n", session.Project)
 		fmt.Printf("Session:      %s\n", formatDuration(duration))
+		fmt.Printf("Timeline:     %s\n", formatPauseSummary(session, calendarFor(cfg)))
 	}
 
 	// Get daily total
@@ -90,7 +90,6 @@ func runStatus(cmd *cobra.Command, args []string) error {
 	}
 
 	// Check DND status
-	cfg, _ := config.Load()
 	var notificationEnabled bool
 	if cfg != nil {
 		notificationEnabled = cfg.Settings.Notifications.Enabled
@@ -6,9 +6,7 @@ import (
 	"github.com/ferg-cod3s/rune/internal/config"
 	"github.com/ferg-cod3s/rune/internal/dnd"
 	"github.com/ferg-cod3s/rune/internal/notifications"
-	"github.com/ferg-cod3s/rune/internal/rituals"
 	"github.com/ferg-cod3s/rune/internal/telemetry"
-	"github.com/ferg-cod3s/rune/internal/tracking"
 	"github.com/spf13/cobra"
 )
 
@@ -36,10 +34,16 @@ func init() {
 func runStop(cmd *cobra.Command, args []string) error {
 	fmt.Println("🔮 Casting your stop ritual...")
 
+	// Load configuration once for the tracker and rituals
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Printf("⚠ Could not load config for rituals: %v\n", err)
+	}
+
 	// Initialize tracker
-	tracker, err := tracking.NewTracker()
+	tracker, err := openTracker(cfg)
 	if err != nil {
-		return fmt.Errorf("failed to initialize tracker: %w", err)
+		return err
 	}
 	defer tracker.Close()
 
@@ -58,12 +62,13 @@ func runStop(cmd *cobra.Command, args []string) error {
 		"duration": session.Duration.Milliseconds(),
 	})
 
-	// Load configuration and execute stop rituals
-	cfg, err := config.Load()
-	if err != nil {
-		fmt.Printf("⚠ Could not load config for rituals: %v\n", err)
-	} else {
-		engine := rituals.NewEngine(cfg)
+	// Execute stop rituals
+	if cfg != nil {
+		_, repo := resolveProject(cfg, []string{session.Project})
+		applyRepoConfig(cfg, repo, session.Project)
+		engine := newRitualEngine(cfg)
+		engine.RecordRuns(tracker)
+		engine.SetSession(session)
 		if err := engine.ExecuteStopRituals(session.Project); err != nil {
 			fmt.Printf("⚠ Stop rituals failed: %v\n", err)
 		}
@@ -0,0 +1,105 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ferg-cod3s/rune/internal/tracking"
+	"github.com/spf13/cobra"
+)
+
+var tagCmd = &cobra.Command{
+	Use:   "tag",
+	Short: "Manage tags on sessions",
+	Long: `Manage tags on the current session and list tags across your history.
+
+Tags let you bill by ticket or activity type, for example:
+  rune tag add bug JIRA-123
+  rune report --week --tag JIRA-123`,
+}
+
+var tagAddCmd = &cobra.Command{
+	Use:   "add <tag>...",
+	Short: "Add tags to the current session",
+	Args:  cobra.MinimumNArgs(1),
+	RunE:  runTagAdd,
+}
+
+var tagRemoveCmd = &cobra.Command{
+	Use:   "remove <tag>...",
+	Short: "Remove tags from the current session",
+	Args:  cobra.MinimumNArgs(1),
+	RunE:  runTagRemove,
+}
+
+var tagListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List all tags with their tracked time",
+	Args:  cobra.NoArgs,
+	RunE:  runTagList,
+}
+
+func init() {
+	rootCmd.AddCommand(tagCmd)
+	tagCmd.AddCommand(tagAddCmd)
+	tagCmd.AddCommand(tagRemoveCmd)
+	tagCmd.AddCommand(tagListCmd)
+}
+
+func runTagAdd(cmd *cobra.Command, args []string) error {
+	return updateTags(args, func(tracker sessionTracker) (*tracking.Session, error) {
+		return tracker.AddTags(args...)
+	})
+}
+
+func runTagRemove(cmd *cobra.Command, args []string) error {
+	return updateTags(args, func(tracker sessionTracker) (*tracking.Session, error) {
+		return tracker.RemoveTags(args...)
+	})
+}
+
+// updateTags applies a tag change to the current session and prints the result
+func updateTags(args []string, update func(tracker sessionTracker) (*tracking.Session, error)) error {
+	tracker, err := openTracker(loadConfig())
+	if err != nil {
+		return err
+	}
+	defer tracker.Close()
+
+	session, err := update(tracker)
+	if err != nil {
+		return fmt.Errorf("failed to update tags: %w", err)
+	}
+
+	if len(session.Tags) == 0 {
+		fmt.Printf("✓ Session for %s has no tags\n", session.Project)
+	} else {
+		fmt.Printf("✓ Session for %s tagged: %s\n", session.Project, strings.Join(session.Tags, ", "))
+	}
+	return nil
+}
+
+func runTagList(cmd *cobra.Command, args []string) error {
+	tracker, err := openTracker(loadConfig())
+	if err != nil {
+		return err
+	}
+	defer tracker.Close()
+
+	stats, err := tracker.GetTagStats()
+	if err != nil {
+		return fmt.Errorf("failed to get tag stats: %w", err)
+	}
+
+	if len(stats) == 0 {
+		fmt.Println("No tagged sessions yet")
+		return nil
+	}
+
+	fmt.Println("🏷  Tags")
+	fmt.Println("=======")
+	for _, stat := range stats {
+		fmt.Printf("  %-20s %-10s %d sessions\n", stat.Tag, formatDuration(stat.Duration), stat.Sessions)
+	}
+	return nil
+}
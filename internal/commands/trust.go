@@ -0,0 +1,278 @@
+package commands
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/ferg-cod3s/rune/internal/config"
+	"github.com/ferg-cod3s/rune/internal/tracking"
+	"github.com/spf13/cobra"
+)
+
+var trustRevoke bool
+
+var trustCmd = &cobra.Command{
+	Use:   "trust",
+	Short: "Trust the current repository's .rune.yaml",
+	Long: `Trust the .rune.yaml at the root of the current git repository so its
+start and stop commands run with your rituals.
+
+A repository's .rune.yaml can name the project, tag its sessions and add
+commands to its rituals. None of it is used until you trust the file, as
+the name alone picks which of your per_project rituals run. Trust is
+remembered under ~/.rune/trust for this exact content: if the file
+changes, rune asks again.`,
+	Args: cobra.NoArgs,
+	RunE: runTrust,
+}
+
+func init() {
+	rootCmd.AddCommand(trustCmd)
+	trustCmd.Flags().BoolVar(&trustRevoke, "revoke", false, "Forget trust in the repository's .rune.yaml")
+}
+
+func runTrust(cmd *cobra.Command, args []string) error {
+	path := repoConfigPath()
+	if path == "" {
+		return fmt.Errorf("no %s found at the root of a git repository", config.RepoConfigFile)
+	}
+
+	if trustRevoke {
+		removed, err := config.Untrust(path)
+		if err != nil {
+			return err
+		}
+		if removed == 0 {
+			fmt.Printf("%s was not trusted\n", path)
+			return nil
+		}
+		fmt.Printf("✓ No longer trusting %s\n", path)
+		return nil
+	}
+
+	repo, err := config.LoadRepoConfig(path)
+	if err != nil {
+		return err
+	}
+	if !repo.Empty() {
+		fmt.Printf("%s declares:\n", path)
+		printRepoConfig(repo)
+	}
+	if err := repo.Trust(); err != nil {
+		return err
+	}
+	fmt.Printf("✓ Trusted %s\n", path)
+	return nil
+}
+
+// repoConfigPath returns the .rune.yaml at the root of the current git
+// repository, or "" when there is none
+func repoConfigPath() string {
+	root := tracking.NewProjectDetector().GitRoot()
+	if root == "" {
+		return ""
+	}
+	path := filepath.Join(root, config.RepoConfigFile)
+	if _, err := os.Stat(path); err != nil {
+		return ""
+	}
+	return path
+}
+
+// loadRepoConfig reads the current repository's .rune.yaml, warning and
+// returning nil when it cannot be used
+func loadRepoConfig() *config.RepoConfig {
+	path := repoConfigPath()
+	if path == "" {
+		return nil
+	}
+	repo, err := config.LoadRepoConfig(path)
+	if err != nil {
+		fmt.Printf("⚠ Ignoring %s: %v\n", config.RepoConfigFile, err)
+		return nil
+	}
+	return repo
+}
+
+// trustRepoConfig reports whether the repository's .rune.yaml may be used,
+// asking the user to trust it first if they have not
+func trustRepoConfig(repo *config.RepoConfig) bool {
+	if repo.Empty() {
+		return true
+	}
+	trusted, err := repo.Trusted()
+	if err != nil {
+		fmt.Printf("⚠ Ignoring %s: %v\n", repo.Path, err)
+		return false
+	}
+	if trusted {
+		return true
+	}
+	if !askTrust(repo) {
+		return false
+	}
+	if err := repo.Trust(); err != nil {
+		fmt.Printf("⚠ %v\n", err)
+	}
+	return true
+}
+
+// applyRepoConfig adds the commands of project's trusted .rune.yaml, as
+// resolveProject returns it, to the rituals in cfg
+func applyRepoConfig(cfg *config.Config, repo *config.RepoConfig, project string) {
+	if cfg == nil || repo == nil || !repo.HasRituals() {
+		return
+	}
+	if err := repo.Apply(cfg, project); err != nil {
+		fmt.Printf("⚠ Skipping commands from %s: %v\n", repo.Path, err)
+	}
+}
+
+// askTrust shows what the repository's .rune.yaml declares and asks whether to use it
+func askTrust(repo *config.RepoConfig) bool {
+	info, err := os.Stdin.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		fmt.Printf("⚠ Ignoring untrusted %s (run 'rune trust' to use it)\n", repo.Path)
+		return false
+	}
+
+	fmt.Printf("🔐 %s wants to set up this repository's sessions:\n", repo.Path)
+	printRepoConfig(repo)
+
+	reader := bufio.NewReader(os.Stdin)
+	for {
+		fmt.Print("Trust this file? [y/N]: ")
+		response, err := reader.ReadString('\n')
+		if err != nil {
+			fmt.Println()
+			return false
+		}
+
+		switch strings.TrimSpace(strings.ToLower(response)) {
+		case "y", "yes":
+			return true
+		case "n", "no", "":
+			fmt.Println("Ignoring it. Run 'rune trust' to use it later.")
+			return false
+		default:
+			fmt.Println("Please answer 'y' for yes or 'n' for no.")
+		}
+	}
+}
+
+// printRepoConfig lists the project name and tags a repository declares and
+// the commands it adds to each ritual, with every option that changes what
+// runs or how
+func printRepoConfig(repo *config.RepoConfig) {
+	if repo.Project != "" {
+		fmt.Printf("  Project: %s\n", repo.Project)
+	}
+	if len(repo.Tags) > 0 {
+		fmt.Printf("  Tags: %s\n", strings.Join(repo.Tags, ", "))
+	}
+	rituals := []struct {
+		name     string
+		commands []config.Command
+	}{
+		{"start", repo.Rituals.Start},
+		{"stop", repo.Rituals.Stop},
+	}
+	for _, ritual := range rituals {
+		if len(ritual.commands) == 0 {
+			continue
+		}
+		fmt.Printf("  On %s:\n", ritual.name)
+		for _, cmd := range ritual.commands {
+			fmt.Printf("    • %s: %s\n", cmd.Name, cmd.Command)
+			for _, detail := range commandDetails(cmd) {
+				fmt.Printf("        %s\n", detail)
+			}
+		}
+	}
+}
+
+// commandDetails describes the options of a command besides its name and
+// command line, one per line
+func commandDetails(cmd config.Command) []string {
+	var details []string
+	add := func(format string, args ...interface{}) {
+		details = append(details, fmt.Sprintf(format, args...))
+	}
+
+	if cmd.ID != "" {
+		add("id: %s", cmd.ID)
+	}
+	if cmd.Shell != "" {
+		add("shell: %s", cmd.Shell)
+	}
+	if cmd.Dir != "" {
+		add("dir: %s", cmd.Dir)
+	}
+	keys := make([]string, 0, len(cmd.Env))
+	for key := range cmd.Env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		add("env: %s=%s", key, cmd.Env[key])
+	}
+	if cmd.Prompt != nil {
+		add("asks for %s: %q (default %q)", cmd.Prompt.Var, cmd.Prompt.Message, cmd.Prompt.Default)
+	}
+	if cmd.Confirm {
+		add("asks before running")
+	}
+	if cmd.Background {
+		add("runs in the background")
+	}
+	if cmd.Optional {
+		add("optional")
+	}
+	if len(cmd.Needs) > 0 {
+		add("needs: %s", strings.Join(cmd.Needs, ", "))
+	}
+	if cmd.Timeout != 0 {
+		add("timeout: %s", cmd.Timeout)
+	}
+	if cmd.Retries != 0 {
+		add("retries: %d", cmd.Retries)
+	}
+	if cmd.RetryDelay != 0 {
+		add("retry delay: %s", cmd.RetryDelay)
+	}
+
+	when := []struct {
+		name   string
+		values []string
+	}{
+		{"days", cmd.When.Days},
+		{"os", cmd.When.OS},
+		{"hosts", cmd.When.Hosts},
+		{"branch", cmd.When.Branch},
+		{"file", cmd.When.File},
+		{"env", cmd.When.Env},
+	}
+	for _, condition := range when {
+		if len(condition.values) > 0 {
+			add("when %s: %s", condition.name, strings.Join(condition.values, ", "))
+		}
+	}
+	if cmd.When.Time != "" {
+		add("when time: %s", cmd.When.Time)
+	}
+	if cmd.When.Check != "" {
+		add("when check: %s", cmd.When.Check)
+	}
+
+	if cmd.OnFailure != "" {
+		add("on failure: %s", cmd.OnFailure)
+	}
+	if cmd.Undo != "" {
+		add("undo: %s", cmd.Undo)
+	}
+	return details
+}
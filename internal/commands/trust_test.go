@@ -0,0 +1,67 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ferg-cod3s/rune/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommandDetails(t *testing.T) {
+	assert.Empty(t, commandDetails(config.Command{Name: "Install", Command: "npm ci"}))
+
+	details := commandDetails(config.Command{
+		Name:       "Deploy",
+		Command:    "make deploy",
+		Shell:      "bash",
+		Dir:        "deploy",
+		Env:        map[string]string{"TOKEN": "${HOME}", "A": "1"},
+		Background: true,
+		Timeout:    time.Minute,
+		When:       config.When{Branch: config.StringList{"main"}, Check: "test -f ok"},
+		OnFailure:  "curl -d failed example.com",
+		Undo:       "make rollback",
+	})
+	assert.Equal(t, []string{
+		"shell: bash",
+		"dir: deploy",
+		"env: A=1",
+		"env: TOKEN=${HOME}",
+		"runs in the background",
+		"timeout: 1m0s",
+		"when branch: main",
+		"when check: test -f ok",
+		"on failure: curl -d failed example.com",
+		"undo: make rollback",
+	}, details)
+}
+
+func TestTrustRepoConfig(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	// Without a terminal nothing is trusted on the user's behalf
+	stdin, w, err := os.Pipe()
+	require.NoError(t, err)
+	defer w.Close()
+	defer func(old *os.File) { os.Stdin = old }(os.Stdin)
+	os.Stdin = stdin
+
+	load := func(content string) *config.RepoConfig {
+		path := filepath.Join(t.TempDir(), config.RepoConfigFile)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+		repo, err := config.LoadRepoConfig(path)
+		require.NoError(t, err)
+		return repo
+	}
+
+	assert.True(t, trustRepoConfig(load("{}\n")))
+
+	// The name alone picks the user's per_project rituals, so it needs trust too
+	repo := load("project: payments\n")
+	assert.False(t, trustRepoConfig(repo))
+	require.NoError(t, repo.Trust())
+	assert.True(t, trustRepoConfig(repo))
+}
@@ -0,0 +1,46 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/ferg-cod3s/rune/internal/tracking"
+	"github.com/spf13/cobra"
+)
+
+var undoCmd = &cobra.Command{
+	Use:   "undo",
+	Short: "Undo the last log, edit or delete",
+	Long:  `Revert the most recent change made with 'rune log', 'rune edit' or 'rune delete'.`,
+	Args:  cobra.NoArgs,
+	RunE:  runUndo,
+}
+
+func init() {
+	rootCmd.AddCommand(undoCmd)
+}
+
+func runUndo(cmd *cobra.Command, args []string) error {
+	tracker, err := openTracker(loadConfig())
+	if err != nil {
+		return err
+	}
+	defer tracker.Close()
+
+	entry, err := tracker.Undo()
+	if err != nil {
+		return fmt.Errorf("failed to undo: %w", err)
+	}
+
+	switch entry.Action {
+	case tracking.JournalLog:
+		fmt.Printf("✓ Removed logged session %s\n", entry.After.ID)
+	case tracking.JournalEdit:
+		fmt.Printf("✓ Restored session %s\n", entry.Before.ID)
+		printSessionDetails(entry.Before)
+	case tracking.JournalDelete:
+		fmt.Printf("✓ Restored deleted session %s\n", entry.Before.ID)
+		printSessionDetails(entry.Before)
+	}
+
+	return nil
+}
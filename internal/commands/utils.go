@@ -3,8 +3,190 @@ package commands
 import (
 	"fmt"
 	"time"
+
+	"github.com/ferg-cod3s/rune/internal/calendar"
+	"github.com/ferg-cod3s/rune/internal/config"
+	"github.com/ferg-cod3s/rune/internal/daemon"
+	"github.com/ferg-cod3s/rune/internal/tracking"
 )
 
+// sessionTracker is the set of tracking operations used by commands. It is
+// implemented by both *tracking.Tracker and *daemon.Client, so commands
+// behave the same whether or not the daemon owns the session database.
+type sessionTracker interface {
+	Start(project string) (*tracking.Session, error)
+	StartWithOptions(project string, opts tracking.StartOptions) (*tracking.Session, error)
+	Stop() (*tracking.Session, error)
+	Pause() (*tracking.Session, error)
+	PauseWithReason(reason tracking.PauseReason) (*tracking.Session, error)
+	Resume() (*tracking.Session, error)
+	GetCurrentSession() (*tracking.Session, error)
+	GetSessionDuration() (time.Duration, error)
+	GetDailyTotal() (time.Duration, error)
+	GetWeeklyTotal() (time.Duration, error)
+	GetSessionHistory(limit int) ([]*tracking.Session, error)
+	Sessions(from, to time.Time, filter tracking.SessionFilter) ([]*tracking.Session, error)
+	GetProjectStats() (map[string]time.Duration, error)
+	GetSession(id string) (*tracking.Session, error)
+	LogSession(project string, start, end time.Time, note string, tags []string) (*tracking.Session, error)
+	EditSession(id string, edit tracking.SessionEdit) (*tracking.Session, error)
+	DeleteSession(id string) (*tracking.Session, error)
+	Undo() (*tracking.JournalEntry, error)
+	ImportSessions(sessions []*tracking.Session, dryRun bool) (*tracking.ImportResult, error)
+	RecordRitualRun(run *tracking.RitualRun) error
+	RitualRuns(limit int, failedOnly bool) ([]*tracking.RitualRun, error)
+	GetRitualRun(id string) (*tracking.RitualRun, error)
+	AddTags(tags ...string) (*tracking.Session, error)
+	RemoveTags(tags ...string) (*tracking.Session, error)
+	SessionsWithTag(tag string) ([]*tracking.Session, error)
+	GetTagStats() ([]tracking.TagStats, error)
+	IsIdle() (bool, error)
+	GetIdleTime() (time.Duration, error)
+	Close() error
+}
+
+// loadConfig returns the user's config, or nil when there is none and the
+// defaults apply. A config that exists but cannot be loaded is reported, as
+// its hooks and calendar and daemon settings are not used.
+func loadConfig() *config.Config {
+	cfg, err := config.Load()
+	if err != nil {
+		if exists, _ := config.Exists(); exists {
+			fmt.Printf("⚠ Could not load config, using defaults: %v\n", err)
+		}
+		return nil
+	}
+	return cfg
+}
+
+// openTracker connects to the running daemon, falling back to opening the
+// session database directly when no daemon is running. The config supplies
+// the idle threshold and calendar; a nil config uses the defaults.
+func openTracker(cfg *config.Config) (sessionTracker, error) {
+	if client, err := daemon.Dial(); err == nil {
+		return client, nil
+	}
+
+	var tracker *tracking.Tracker
+	var err error
+	if cfg != nil {
+		tracker, err = tracking.NewTrackerWithIdleThreshold(cfg.Settings.IdleThreshold)
+	} else {
+		tracker, err = tracking.NewTracker()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize tracker: %w", err)
+	}
+	tracker.SetCalendar(calendarFor(cfg))
+	return tracker, nil
+}
+
+// calendarFor returns the calendar from the timezone, week_start and
+// day_rollover_hour settings, or the default calendar without a usable config
+func calendarFor(cfg *config.Config) calendar.Calendar {
+	if cfg == nil {
+		return calendar.Default()
+	}
+	cal, err := cfg.Calendar()
+	if err != nil {
+		return calendar.Default()
+	}
+	return cal
+}
+
+// newProjectDetector creates a project detector that evaluates the
+// configured projects[].detect rules, then the project named by the
+// repository's .rune.yaml, before the built-in heuristics
+func newProjectDetector(cfg *config.Config, repo *config.RepoConfig) *tracking.ProjectDetector {
+	var rules []tracking.DetectRule
+	if cfg != nil {
+		for _, project := range cfg.Projects {
+			for _, pattern := range project.Detect {
+				rules = append(rules, tracking.DetectRule{Project: project.Name, Pattern: pattern})
+			}
+		}
+	}
+
+	detector := tracking.NewProjectDetectorWithRules(rules)
+	if repo != nil && repo.Project != "" {
+		detector.Declare(repo.Project, "project in "+repo.Path)
+	}
+	return detector
+}
+
+// detectProject returns the project for the working directory. Names from
+// configured rules and .rune.yaml are used verbatim so they line up with
+// per_project keys; heuristic names are sanitized.
+func detectProject(cfg *config.Config, repo *config.RepoConfig) string {
+	detector := newProjectDetector(cfg, repo)
+	detection := detector.Explain()
+	if detection.Rule != nil || detection.Declared {
+		return detection.Project
+	}
+	return detector.SanitizeProjectName(detection.Project)
+}
+
+// resolveProject returns the project named in args, or detected for the
+// working directory, along with the repository's .rune.yaml when it is
+// trusted and belongs to that project. Detection runs at most once.
+func resolveProject(cfg *config.Config, args []string) (string, *config.RepoConfig) {
+	repo := loadRepoConfig()
+	if repo != nil && !trustRepoConfig(repo) {
+		repo = nil
+	}
+	var project string
+	if len(args) > 0 {
+		project = args[0]
+	}
+	if project == "" || repo != nil {
+		detected := detectProject(cfg, repo)
+		if project == "" {
+			project = detected
+		}
+		// A .rune.yaml only applies to sessions of its own repository's project
+		if detected != project {
+			repo = nil
+		}
+	}
+	return project, repo
+}
+
+// parseTimeFlag parses a time given as "15:04" (on the given day),
+// "2006-01-02 15:04" or RFC 3339, in the timezone of the given day
+func parseTimeFlag(value string, day time.Time) (time.Time, error) {
+	if t, err := time.Parse("15:04", value); err == nil {
+		return time.Date(day.Year(), day.Month(), day.Day(), t.Hour(), t.Minute(), 0, 0, day.Location()), nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02 15:04", value, day.Location()); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q (use HH:MM, \"YYYY-MM-DD HH:MM\" or RFC 3339)", value)
+}
+
+// formatPauseSummary describes a session's start time in the calendar's
+// timezone and its pauses, e.g. "started 09:02, paused 3 times for 41m total"
+func formatPauseSummary(session *tracking.Session, cal calendar.Calendar) string {
+	summary := fmt.Sprintf("started %s", session.StartTime.In(cal.Location).Format("15:04"))
+	pauses := session.PauseCount()
+	switch pauses {
+	case 0:
+		return summary
+	case 1:
+		summary += ", paused once"
+	default:
+		summary += fmt.Sprintf(", paused %d times", pauses)
+	}
+
+	end := time.Now()
+	if session.EndTime != nil {
+		end = *session.EndTime
+	}
+	return summary + fmt.Sprintf(" for %s total", formatDuration(session.PausedDuration(end)))
+}
+
 // formatDuration formats a duration as "Xh Ym"
 func formatDuration(d time.Duration) string {
 	hours := int(d.Hours())
@@ -4,8 +4,12 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
 	"time"
 
+	"github.com/ferg-cod3s/rune/internal/calendar"
 	"github.com/spf13/viper"
 )
 
@@ -17,6 +21,9 @@ type Config struct {
 	Projects     []Project    `yaml:"projects" mapstructure:"projects"`
 	Rituals      Rituals      `yaml:"rituals" mapstructure:"rituals"`
 	Integrations Integrations `yaml:"integrations" mapstructure:"integrations"`
+
+	// resolved is the document the config was decoded from, used to locate problems
+	resolved *Resolved
 }
 
 // Settings contains global application settings
@@ -25,6 +32,12 @@ type Settings struct {
 	BreakInterval time.Duration        `yaml:"break_interval" mapstructure:"break_interval"`
 	IdleThreshold time.Duration        `yaml:"idle_threshold" mapstructure:"idle_threshold"`
 	Notifications NotificationSettings `yaml:"notifications" mapstructure:"notifications"`
+	// Timezone is an IANA zone name such as "Europe/Berlin"; empty means local time
+	Timezone string `yaml:"timezone" mapstructure:"timezone"`
+	// WeekStart is the weekday reports treat as the first day of the week
+	WeekStart string `yaml:"week_start" mapstructure:"week_start"`
+	// DayRolloverHour is the hour a new day starts, so late-night work counts towards the previous day
+	DayRolloverHour int `yaml:"day_rollover_hour" mapstructure:"day_rollover_hour"`
 }
 
 // NotificationSettings contains notification preferences
@@ -43,26 +56,115 @@ type Project struct {
 	Detect []string `yaml:"detect" mapstructure:"detect"`
 }
 
-// Rituals contains start and stop ritual configurations
+// Rituals contains start, stop and custom ritual configurations
 type Rituals struct {
 	Start RitualSet `yaml:"start" mapstructure:"start"`
 	Stop  RitualSet `yaml:"stop" mapstructure:"stop"`
+	// Custom rituals are run by name with 'rune ritual run <name>' or from hooks
+	Custom map[string]RitualSet `yaml:"custom" mapstructure:"custom"`
+	Hooks  RitualHooks          `yaml:"hooks" mapstructure:"hooks"`
+	// Parallelism is how many commands whose needs are met may run at once; zero means one
+	Parallelism int `yaml:"parallelism" mapstructure:"parallelism"`
+}
+
+// RitualHooks names the rituals run automatically when a session changes state
+type RitualHooks struct {
+	Pause      string `yaml:"pause" mapstructure:"pause"`
+	Resume     string `yaml:"resume" mapstructure:"resume"`
+	BreakStart string `yaml:"break_start" mapstructure:"break_start"`
+	BreakEnd   string `yaml:"break_end" mapstructure:"break_end"`
+}
+
+// Ritual returns the named ritual: start, stop or a custom ritual
+func (r Rituals) Ritual(name string) (RitualSet, bool) {
+	switch name {
+	case "start":
+		return r.Start, true
+	case "stop":
+		return r.Stop, true
+	}
+	set, ok := r.Custom[name]
+	return set, ok
+}
+
+// RitualNames returns start, stop and the custom ritual names in alphabetical order
+func (r Rituals) RitualNames() []string {
+	custom := make([]string, 0, len(r.Custom))
+	for name := range r.Custom {
+		custom = append(custom, name)
+	}
+	sort.Strings(custom)
+	return append([]string{"start", "stop"}, custom...)
 }
 
 // RitualSet contains global and per-project rituals
 type RitualSet struct {
 	Global     []Command            `yaml:"global" mapstructure:"global"`
 	PerProject map[string][]Command `yaml:"per_project" mapstructure:"per_project"`
+	// Finally commands run in order after the ritual, whether or not it failed
+	Finally []Command `yaml:"finally" mapstructure:"finally"`
+	// KillBackground makes the stop ritual stop the background processes the start ritual launched
+	KillBackground bool `yaml:"kill_background" mapstructure:"kill_background"`
 }
 
 // Command represents a ritual command
 type Command struct {
+	// ID names the command so that others can list it in their needs
+	ID         string `yaml:"id" mapstructure:"id"`
 	Name       string `yaml:"name" mapstructure:"name"`
 	Command    string `yaml:"command" mapstructure:"command"`
 	Optional   bool   `yaml:"optional" mapstructure:"optional"`
 	Background bool   `yaml:"background" mapstructure:"background"`
+	// Shell runs the command through sh, bash, zsh or pwsh, or directly with
+	// "none"; empty means the user's shell
+	Shell string `yaml:"shell" mapstructure:"shell"`
+	// Dir is the working directory, with ~ and ${VAR} expanded; empty means the current directory
+	Dir string `yaml:"dir" mapstructure:"dir"`
+	// Env adds environment variables; values may reference the parent environment as ${VAR}
+	Env map[string]string `yaml:"env" mapstructure:"env"`
+	// Timeout limits each attempt of a foreground command; zero means DefaultCommandTimeout
+	Timeout time.Duration `yaml:"timeout" mapstructure:"timeout"`
+	// Retries is how many times a failed command is run again
+	Retries int `yaml:"retries" mapstructure:"retries"`
+	// RetryDelay is the wait before the first retry, doubling for each later one; zero means DefaultRetryDelay
+	RetryDelay time.Duration `yaml:"retry_delay" mapstructure:"retry_delay"`
+	// When skips the command unless all of its conditions hold
+	When When `yaml:"when" mapstructure:"when"`
+	// Needs are the IDs of commands that must finish before this one starts
+	Needs StringList `yaml:"needs" mapstructure:"needs"`
+	// OnFailure runs when the command fails after its last retry
+	OnFailure string `yaml:"on_failure" mapstructure:"on_failure"`
+	// Undo reverses the command when a required command of the same ritual fails
+	Undo string `yaml:"undo" mapstructure:"undo"`
+	// Confirm asks before running the command and skips it if the answer is no
+	Confirm bool `yaml:"confirm" mapstructure:"confirm"`
+	// Prompt asks for a value before the command runs; the command may then be empty
+	Prompt *Prompt `yaml:"prompt" mapstructure:"prompt"`
+}
+
+// Prompt asks for a value that is passed as an environment variable to its
+// command and to every command that runs after it
+type Prompt struct {
+	Var     string `yaml:"var" mapstructure:"var"`
+	Message string `yaml:"message" mapstructure:"message"`
+	// Default is used when the answer is empty or nobody can be asked
+	Default string `yaml:"default" mapstructure:"default"`
 }
 
+// Ritual command defaults
+const (
+	DefaultCommandTimeout = 30 * time.Second
+	DefaultRetryDelay     = time.Second
+	// MaxCommandRetries bounds retries so a broken command cannot stall a ritual indefinitely
+	MaxCommandRetries = 10
+)
+
+// envName matches valid environment variable names
+var envName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// RitualShells are the accepted values of a command's shell option
+var RitualShells = []string{"sh", "bash", "zsh", "pwsh", "none"}
+
 // Integrations contains external service integrations
 type Integrations struct {
 	Git       GitIntegration       `yaml:"git" mapstructure:"git"`
@@ -98,50 +200,282 @@ type TelemetryIntegration struct {
 
 // Load loads the configuration from the default location or specified file
 func Load() (*Config, error) {
+	cfg, _, err := Check()
+	return cfg, err
+}
+
+// Check loads the configuration like Load and also returns its warnings,
+// such as unknown keys, which do not stop it from loading. The warnings
+// are returned even when the configuration is invalid.
+func Check() (*Config, []Issue, error) {
 	var cfg Config
 
 	if err := viper.Unmarshal(&cfg); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
+		return nil, nil, fmt.Errorf("failed to unmarshal config: %w", err)
+	}
+
+	if path := viper.ConfigFileUsed(); path != "" {
+		resolved, err := Resolve(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := resolved.decode(&cfg); err != nil {
+			return nil, nil, err
+		}
 	}
 
+	warnings := cfg.Warnings()
 	if err := cfg.Validate(); err != nil {
-		return nil, fmt.Errorf("config validation failed: %w", err)
+		return nil, warnings, fmt.Errorf("config validation failed: %w", err)
 	}
 
-	return &cfg, nil
+	return &cfg, warnings, nil
+}
+
+// loadRituals decodes the rituals section of a config file merged with its includes
+func loadRituals(path string) (Rituals, error) {
+	resolved, err := Resolve(path)
+	if err != nil {
+		return Rituals{}, err
+	}
+	var cfg Config
+	if err := resolved.decode(&cfg); err != nil {
+		return Rituals{}, err
+	}
+	return cfg.Rituals, nil
 }
 
-// Validate validates the configuration
+// Validate validates the configuration, reporting every problem found
+// rather than only the first. When the config was loaded from a file, the
+// problems are located by file, line and column.
 func (c *Config) Validate() error {
-	if c.Version != 1 {
-		return fmt.Errorf("unsupported config version: %d (expected: 1)", c.Version)
+	return c.check().err(c.resolved)
+}
+
+// Warnings returns the problems that do not stop the configuration from
+// loading, located like Validate's: keys it does not know, such as a
+// misspelled setting, and per_project entries for undeclared projects
+func (c *Config) Warnings() []Issue {
+	return locate(c.check().warnings, c.resolved)
+}
+
+// check collects the configuration's problems and warnings
+func (c *Config) check() *validator {
+	v := &validator{}
+	if c.resolved != nil {
+		v.warnings = append(v.warnings, c.resolved.unknown...)
+	}
+
+	if c.Version != CurrentVersion {
+		v.addf("version", "unsupported config version: %d (expected: %d)", c.Version, CurrentVersion)
 	}
 
 	if c.Settings.WorkHours <= 0 || c.Settings.WorkHours > 24 {
-		return fmt.Errorf("work_hours must be between 0 and 24, got: %f", c.Settings.WorkHours)
+		v.addf("settings.work_hours", "work_hours must be between 0 and 24, got: %f", c.Settings.WorkHours)
 	}
 
 	if c.Settings.BreakInterval <= 0 {
-		return fmt.Errorf("break_interval must be positive, got: %v", c.Settings.BreakInterval)
+		v.addf("settings.break_interval", "break_interval must be positive, got: %v", c.Settings.BreakInterval)
 	}
 
 	if c.Settings.IdleThreshold <= 0 {
-		return fmt.Errorf("idle_threshold must be positive, got: %v", c.Settings.IdleThreshold)
+		v.addf("settings.idle_threshold", "idle_threshold must be positive, got: %v", c.Settings.IdleThreshold)
+	}
+
+	// Each calendar setting is checked on its own so that each problem is located
+	if _, err := calendar.New(c.Settings.Timezone, "", 0); err != nil {
+		v.addf("settings.timezone", "%v", err)
+	}
+	if _, err := calendar.New("", c.Settings.WeekStart, 0); err != nil {
+		v.addf("settings.week_start", "%v", err)
+	}
+	if _, err := calendar.New("", "", c.Settings.DayRolloverHour); err != nil {
+		v.addf("settings.day_rollover_hour", "%v", err)
 	}
 
 	// Validate projects
+	projects := make(map[string]bool, len(c.Projects))
 	for i, project := range c.Projects {
+		path := fmt.Sprintf("projects[%d]", i)
+		projects[project.Name] = true
 		if project.Name == "" {
-			return fmt.Errorf("project[%d]: name cannot be empty", i)
+			v.addf(path, "project[%d]: name cannot be empty", i)
 		}
 		if len(project.Detect) == 0 {
-			return fmt.Errorf("project[%d]: detect patterns cannot be empty", i)
+			v.addf(path, "project[%d]: detect patterns cannot be empty", i)
+		}
+		for j, pattern := range project.Detect {
+			if err := validateDetectPattern(pattern); err != nil {
+				v.addf(fmt.Sprintf("%s.detect[%d]", path, j), "project[%d] (%s): %v", i, project.Name, err)
+			}
 		}
 	}
 
+	// Ritual templates are checked against sample values of their variables
+	sample := TemplateData{Project: "project", SessionID: "session", StartTime: time.Now(), Today: time.Now().Format("2006-01-02"), Config: c}
+	c.Rituals.validate(sample, projects, v)
+
+	return v
+}
+
+// Calendar returns the calendar described by the timezone, week_start and day_rollover_hour settings
+func (c *Config) Calendar() (calendar.Calendar, error) {
+	return calendar.New(c.Settings.Timezone, c.Settings.WeekStart, c.Settings.DayRolloverHour)
+}
+
+// detectPatternPrefix matches a "kind:" prefix on a detect pattern
+var detectPatternPrefix = regexp.MustCompile(`^([a-z]+):`)
+
+// validateDetectPattern checks that a detect pattern uses a known rule kind.
+// Version 1 read patterns without a prefix as file globs; migration adds file:.
+func validateDetectPattern(pattern string) error {
+	if strings.TrimSpace(pattern) == "" {
+		return fmt.Errorf("detect pattern cannot be empty")
+	}
+
+	matches := detectPatternPrefix.FindStringSubmatch(pattern)
+	if matches == nil {
+		return fmt.Errorf("detect pattern %q has no kind (use dir:, git:, branch:, env: or file:)", pattern)
+	}
+
+	value := strings.TrimPrefix(pattern, matches[0])
+	switch matches[1] {
+	case "dir", "branch", "env", "file":
+		if value == "" {
+			return fmt.Errorf("detect pattern %q has an empty value", pattern)
+		}
+	case "git":
+		if _, err := regexp.Compile(value); err != nil {
+			return fmt.Errorf("detect pattern %q has an invalid regex: %w", pattern, err)
+		}
+	default:
+		return fmt.Errorf("detect pattern %q has unknown kind %q (expected dir, git, branch, env or file)", pattern, matches[1])
+	}
 	return nil
 }
 
+// validate checks every ritual command and hook. Projects named under
+// per_project but not declared in projects are warned about.
+func (r Rituals) validate(sample TemplateData, projects map[string]bool, v *validator) {
+	if r.Parallelism < 0 {
+		v.addf("rituals.parallelism", "rituals.parallelism must not be negative, got: %d", r.Parallelism)
+	}
+	for _, name := range r.RitualNames() {
+		set, _ := r.Ritual(name)
+		key := name
+		if _, custom := r.Custom[name]; custom {
+			key = "custom." + name
+			if name == "start" || name == "stop" || !commandID.MatchString(name) {
+				v.addf("rituals."+key, "rituals.custom: invalid ritual name %q (use letters, digits, '.', '_' and '-', other than start and stop)", name)
+				continue
+			}
+		}
+		if set.KillBackground && name != "stop" {
+			v.addf("rituals."+key+".kill_background", "rituals.%s.kill_background is only supported on the stop ritual", key)
+		}
+		set.validate("rituals."+key, sample, projects, v)
+	}
+	r.Hooks.validate(r, v)
+}
+
+// validate checks that every hook names a configured ritual
+func (h RitualHooks) validate(r Rituals, v *validator) {
+	hooks := []struct{ key, ritual string }{
+		{"pause", h.Pause},
+		{"resume", h.Resume},
+		{"break_start", h.BreakStart},
+		{"break_end", h.BreakEnd},
+	}
+	for _, hook := range hooks {
+		if hook.ritual == "" {
+			continue
+		}
+		if _, ok := r.Ritual(hook.ritual); !ok {
+			v.addf("rituals.hooks."+hook.key, "rituals.hooks.%s: unknown ritual %q", hook.key, hook.ritual)
+		}
+	}
+}
+
+// validate checks the global and per-project commands of the ritual at path
+func (s RitualSet) validate(path string, sample TemplateData, projects map[string]bool, v *validator) {
+	for i, cmd := range s.Global {
+		cmd.validate(fmt.Sprintf("%s.global[%d]", path, i), sample, v)
+	}
+	if err := CheckNeeds(s.Global); err != nil {
+		v.add(path+".global", err)
+	}
+
+	names := make([]string, 0, len(s.PerProject))
+	for project := range s.PerProject {
+		names = append(names, project)
+	}
+	sort.Strings(names)
+	for _, project := range names {
+		projectPath := path + ".per_project." + project
+		if projects != nil && !projects[project] {
+			v.warnf(projectPath, "%s: project %q is not declared under projects", projectPath, project)
+		}
+		for i, cmd := range s.PerProject[project] {
+			cmd.validate(fmt.Sprintf("%s[%d]", projectPath, i), sample, v)
+		}
+		// Project commands share one plan with the global ones, so they may need them
+		commands := append(append([]Command{}, s.Global...), s.PerProject[project]...)
+		if err := CheckNeeds(commands); err != nil {
+			v.add(projectPath, err)
+		}
+	}
+
+	for i, cmd := range s.Finally {
+		cmdPath := fmt.Sprintf("%s.finally[%d]", path, i)
+		cmd.validate(cmdPath, sample, v)
+		if len(cmd.Needs) > 0 || cmd.Undo != "" {
+			v.add(cmdPath, fmt.Errorf("command %q runs in order at the end and cannot set needs or undo", cmd.Name))
+		}
+	}
+}
+
+// validate checks a single ritual command at path, expanding its templates with sample data
+func (c Command) validate(path string, sample TemplateData, v *validator) {
+	if strings.TrimSpace(c.Command) == "" && c.Prompt == nil {
+		v.add(path, fmt.Errorf("command %q is empty", c.Name))
+	}
+	if c.Prompt != nil && !envName.MatchString(c.Prompt.Var) {
+		v.add(path, fmt.Errorf("command %q prompt has invalid var %q", c.Name, c.Prompt.Var))
+	}
+	if c.Shell != "" {
+		valid := false
+		for _, shell := range RitualShells {
+			valid = valid || c.Shell == shell
+		}
+		if !valid {
+			v.add(path, fmt.Errorf("command %q has unknown shell %q (expected %s)", c.Name, c.Shell, strings.Join(RitualShells, ", ")))
+		}
+	}
+	for name := range c.Env {
+		if !envName.MatchString(name) {
+			v.add(path, fmt.Errorf("command %q has invalid environment variable name %q", c.Name, name))
+		}
+	}
+	if c.Timeout < 0 {
+		v.add(path, fmt.Errorf("command %q timeout must not be negative, got: %v", c.Name, c.Timeout))
+	}
+	if c.Retries < 0 || c.Retries > MaxCommandRetries {
+		v.add(path, fmt.Errorf("command %q retries must be between 0 and %d, got: %d", c.Name, MaxCommandRetries, c.Retries))
+	}
+	if c.RetryDelay < 0 {
+		v.add(path, fmt.Errorf("command %q retry_delay must not be negative, got: %v", c.Name, c.RetryDelay))
+	}
+	if c.Background && (c.Timeout != 0 || c.Retries != 0) {
+		v.add(path, fmt.Errorf("command %q runs in the background and cannot set timeout or retries", c.Name))
+	}
+	if err := c.When.validate(); err != nil {
+		v.add(path, fmt.Errorf("command %q %w", c.Name, err))
+	}
+	if _, err := ExpandCommand(c, sample); err != nil {
+		v.add(path, err)
+	}
+}
+
 // GetConfigPath returns the path to the configuration file
 func GetConfigPath() (string, error) {
 	home, err := os.UserHomeDir()
@@ -175,25 +509,22 @@ func LoadConfig() (*Config, error) {
 	return Load()
 }
 
-// SaveConfig saves the configuration to file
-func SaveConfig(cfg *Config) error {
+// SetValue sets one key in the user's existing config file, keeping the
+// rest of the file, its comments included, as written
+func SetValue(key string, value interface{}) error {
 	configPath, err := GetConfigPath()
 	if err != nil {
 		return err
 	}
-
-	// Ensure config directory exists
-	configDir := filepath.Dir(configPath)
-	if err := os.MkdirAll(configDir, 0755); err != nil {
-		return fmt.Errorf("failed to create config directory: %w", err)
+	if _, err := os.Stat(configPath); err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
 	}
-
-	viper.Set("version", cfg.Version)
-	viper.Set("user_id", cfg.UserID)
-	viper.Set("settings", cfg.Settings)
-	viper.Set("projects", cfg.Projects)
-	viper.Set("rituals", cfg.Rituals)
-	viper.Set("integrations", cfg.Integrations)
-
-	return viper.WriteConfigAs(configPath)
+	file, err := OpenFile(configPath)
+	if err != nil {
+		return err
+	}
+	if err := file.Set(key, value); err != nil {
+		return err
+	}
+	return file.Save()
 }
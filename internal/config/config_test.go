@@ -20,7 +20,7 @@ func TestConfig_Validate(t *testing.T) {
 		{
 			name: "valid config",
 			config: Config{
-				Version: 1,
+				Version: CurrentVersion,
 				Settings: Settings{
 					WorkHours:     8.0,
 					BreakInterval: 50 * time.Minute,
@@ -32,10 +32,25 @@ func TestConfig_Validate(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "valid calendar settings",
+			config: Config{
+				Version: CurrentVersion,
+				Settings: Settings{
+					WorkHours:       8.0,
+					BreakInterval:   50 * time.Minute,
+					IdleThreshold:   10 * time.Minute,
+					Timezone:        "America/Chicago",
+					WeekStart:       "monday",
+					DayRolloverHour: 4,
+				},
+			},
+			wantErr: false,
+		},
 		{
 			name: "invalid version",
 			config: Config{
-				Version: 2,
+				Version: CurrentVersion + 1,
 				Settings: Settings{
 					WorkHours:     8.0,
 					BreakInterval: 50 * time.Minute,
@@ -48,7 +63,7 @@ func TestConfig_Validate(t *testing.T) {
 		{
 			name: "invalid work hours - zero",
 			config: Config{
-				Version: 1,
+				Version: CurrentVersion,
 				Settings: Settings{
 					WorkHours:     0,
 					BreakInterval: 50 * time.Minute,
@@ -61,7 +76,7 @@ func TestConfig_Validate(t *testing.T) {
 		{
 			name: "invalid work hours - too high",
 			config: Config{
-				Version: 1,
+				Version: CurrentVersion,
 				Settings: Settings{
 					WorkHours:     25,
 					BreakInterval: 50 * time.Minute,
@@ -74,7 +89,7 @@ func TestConfig_Validate(t *testing.T) {
 		{
 			name: "invalid break interval",
 			config: Config{
-				Version: 1,
+				Version: CurrentVersion,
 				Settings: Settings{
 					WorkHours:     8.0,
 					BreakInterval: -1 * time.Minute,
@@ -87,7 +102,7 @@ func TestConfig_Validate(t *testing.T) {
 		{
 			name: "invalid idle threshold",
 			config: Config{
-				Version: 1,
+				Version: CurrentVersion,
 				Settings: Settings{
 					WorkHours:     8.0,
 					BreakInterval: 50 * time.Minute,
@@ -100,7 +115,7 @@ func TestConfig_Validate(t *testing.T) {
 		{
 			name: "project with empty name",
 			config: Config{
-				Version: 1,
+				Version: CurrentVersion,
 				Settings: Settings{
 					WorkHours:     8.0,
 					BreakInterval: 50 * time.Minute,
@@ -116,7 +131,7 @@ func TestConfig_Validate(t *testing.T) {
 		{
 			name: "project with empty detect patterns",
 			config: Config{
-				Version: 1,
+				Version: CurrentVersion,
 				Settings: Settings{
 					WorkHours:     8.0,
 					BreakInterval: 50 * time.Minute,
@@ -129,6 +144,518 @@ func TestConfig_Validate(t *testing.T) {
 			wantErr: true,
 			errMsg:  "detect patterns cannot be empty",
 		},
+		{
+			name: "invalid timezone",
+			config: Config{
+				Version: CurrentVersion,
+				Settings: Settings{
+					WorkHours:     8.0,
+					BreakInterval: 50 * time.Minute,
+					IdleThreshold: 10 * time.Minute,
+					Timezone:      "Europe/Atlantis",
+				},
+			},
+			wantErr: true,
+			errMsg:  "invalid timezone",
+		},
+		{
+			name: "invalid week start",
+			config: Config{
+				Version: CurrentVersion,
+				Settings: Settings{
+					WorkHours:     8.0,
+					BreakInterval: 50 * time.Minute,
+					IdleThreshold: 10 * time.Minute,
+					WeekStart:     "weekend",
+				},
+			},
+			wantErr: true,
+			errMsg:  "invalid week start",
+		},
+		{
+			name: "day rollover hour out of range",
+			config: Config{
+				Version: CurrentVersion,
+				Settings: Settings{
+					WorkHours:       8.0,
+					BreakInterval:   50 * time.Minute,
+					IdleThreshold:   10 * time.Minute,
+					DayRolloverHour: 25,
+				},
+			},
+			wantErr: true,
+			errMsg:  "rollover hour must be between 0 and 23",
+		},
+		{
+			name: "project with unknown detect kind",
+			config: Config{
+				Version: CurrentVersion,
+				Settings: Settings{
+					WorkHours:     8.0,
+					BreakInterval: 50 * time.Minute,
+					IdleThreshold: 10 * time.Minute,
+				},
+				Projects: []Project{
+					{Name: "test", Detect: []string{"dir:~/code/test", "path:~/code"}},
+				},
+			},
+			wantErr: true,
+			errMsg:  `unknown kind "path"`,
+		},
+		{
+			name: "project with invalid git regex",
+			config: Config{
+				Version: CurrentVersion,
+				Settings: Settings{
+					WorkHours:     8.0,
+					BreakInterval: 50 * time.Minute,
+					IdleThreshold: 10 * time.Minute,
+				},
+				Projects: []Project{
+					{Name: "test", Detect: []string{"git:test("}},
+				},
+			},
+			wantErr: true,
+			errMsg:  "invalid regex",
+		},
+		{
+			name: "ritual commands with shells",
+			config: Config{
+				Version: CurrentVersion,
+				Settings: Settings{
+					WorkHours:     8.0,
+					BreakInterval: 50 * time.Minute,
+					IdleThreshold: 10 * time.Minute,
+				},
+				Rituals: Rituals{
+					Start: RitualSet{Global: []Command{
+						{Name: "Pull", Command: "git pull && git status", Shell: "bash"},
+						{Name: "Status", Command: "git status", Shell: "none"},
+					}},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "ritual command with unknown shell",
+			config: Config{
+				Version: CurrentVersion,
+				Settings: Settings{
+					WorkHours:     8.0,
+					BreakInterval: 50 * time.Minute,
+					IdleThreshold: 10 * time.Minute,
+				},
+				Rituals: Rituals{
+					Stop: RitualSet{PerProject: map[string][]Command{
+						"api": {{Name: "Commit", Command: "git commit", Shell: "fish"}},
+					}},
+				},
+			},
+			wantErr: true,
+			errMsg:  `rituals.stop.per_project.api[0]: command "Commit" has unknown shell "fish"`,
+		},
+		{
+			name: "ritual command with dir, env, timeout and retries",
+			config: Config{
+				Version: CurrentVersion,
+				Settings: Settings{
+					WorkHours:     8.0,
+					BreakInterval: 50 * time.Minute,
+					IdleThreshold: 10 * time.Minute,
+				},
+				Rituals: Rituals{Start: RitualSet{Global: []Command{{
+					Name:       "Pull",
+					Command:    "docker-compose pull",
+					Dir:        "~/code/app",
+					Env:        map[string]string{"COMPOSE_FILE": "${HOME}/compose.yaml"},
+					Timeout:    10 * time.Minute,
+					Retries:    3,
+					RetryDelay: 2 * time.Second,
+				}}}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "ritual command with invalid env name",
+			config: Config{
+				Version: CurrentVersion,
+				Settings: Settings{
+					WorkHours:     8.0,
+					BreakInterval: 50 * time.Minute,
+					IdleThreshold: 10 * time.Minute,
+				},
+				Rituals: Rituals{Start: RitualSet{Global: []Command{{Name: "Pull", Command: "git pull", Env: map[string]string{"BAD-NAME": "1"}}}}},
+			},
+			wantErr: true,
+			errMsg:  `invalid environment variable name "BAD-NAME"`,
+		},
+		{
+			name: "ritual command with negative timeout",
+			config: Config{
+				Version: CurrentVersion,
+				Settings: Settings{
+					WorkHours:     8.0,
+					BreakInterval: 50 * time.Minute,
+					IdleThreshold: 10 * time.Minute,
+				},
+				Rituals: Rituals{Start: RitualSet{Global: []Command{{Name: "Pull", Command: "git pull", Timeout: -time.Second}}}},
+			},
+			wantErr: true,
+			errMsg:  `timeout must not be negative`,
+		},
+		{
+			name: "ritual command with too many retries",
+			config: Config{
+				Version: CurrentVersion,
+				Settings: Settings{
+					WorkHours:     8.0,
+					BreakInterval: 50 * time.Minute,
+					IdleThreshold: 10 * time.Minute,
+				},
+				Rituals: Rituals{Start: RitualSet{Global: []Command{{Name: "Pull", Command: "git pull", Retries: 11}}}},
+			},
+			wantErr: true,
+			errMsg:  `retries must be between 0 and 10`,
+		},
+		{
+			name: "background ritual command with retries",
+			config: Config{
+				Version: CurrentVersion,
+				Settings: Settings{
+					WorkHours:     8.0,
+					BreakInterval: 50 * time.Minute,
+					IdleThreshold: 10 * time.Minute,
+				},
+				Rituals: Rituals{Start: RitualSet{Global: []Command{{Name: "Serve", Command: "bun run dev", Background: true, Retries: 1}}}},
+			},
+			wantErr: true,
+			errMsg:  `cannot set timeout or retries`,
+		},
+		{
+			name: "ritual command with when conditions",
+			config: Config{
+				Version: CurrentVersion,
+				Settings: Settings{
+					WorkHours:     8.0,
+					BreakInterval: 50 * time.Minute,
+					IdleThreshold: 10 * time.Minute,
+				},
+				Rituals: Rituals{Start: RitualSet{Global: []Command{{Name: "Standup", Command: "open https://meet", When: When{
+					Days:   StringList{"weekdays", "sat"},
+					Time:   "22:00-02:00",
+					Hosts:  StringList{"work-*"},
+					OS:     StringList{"darwin"},
+					Branch: StringList{"feature/*"},
+					File:   StringList{"docker-compose.yml"},
+					Env:    StringList{"CI", "DEPLOY_ENV=prod*"},
+					Check:  "docker info",
+				}}}}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "ritual command with invalid when day",
+			config: Config{
+				Version: CurrentVersion,
+				Settings: Settings{
+					WorkHours:     8.0,
+					BreakInterval: 50 * time.Minute,
+					IdleThreshold: 10 * time.Minute,
+				},
+				Rituals: Rituals{Start: RitualSet{Global: []Command{{Name: "Standup", Command: "true", When: When{Days: StringList{"someday"}}}}}},
+			},
+			wantErr: true,
+			errMsg:  `rituals.start.global[0]: command "Standup" when.days: invalid day "someday"`,
+		},
+		{
+			name: "ritual command with invalid when time window",
+			config: Config{
+				Version: CurrentVersion,
+				Settings: Settings{
+					WorkHours:     8.0,
+					BreakInterval: 50 * time.Minute,
+					IdleThreshold: 10 * time.Minute,
+				},
+				Rituals: Rituals{Start: RitualSet{Global: []Command{{Name: "Standup", Command: "true", When: When{Time: "9am-5pm"}}}}},
+			},
+			wantErr: true,
+			errMsg:  `when.time: invalid time window "9am-5pm"`,
+		},
+		{
+			name: "ritual command with invalid when branch pattern",
+			config: Config{
+				Version: CurrentVersion,
+				Settings: Settings{
+					WorkHours:     8.0,
+					BreakInterval: 50 * time.Minute,
+					IdleThreshold: 10 * time.Minute,
+				},
+				Rituals: Rituals{Start: RitualSet{Global: []Command{{Name: "Deploy", Command: "true", When: When{Branch: StringList{"release/["}}}}}},
+			},
+			wantErr: true,
+			errMsg:  `when.branch: invalid pattern "release/["`,
+		},
+		{
+			name: "ritual commands with needs across global and project",
+			config: Config{
+				Version: CurrentVersion,
+				Settings: Settings{
+					WorkHours:     8.0,
+					BreakInterval: 50 * time.Minute,
+					IdleThreshold: 10 * time.Minute,
+				},
+				Projects: []Project{{Name: "api", Detect: []string{"file:go.mod"}}},
+				Rituals: Rituals{Parallelism: 4, Start: RitualSet{
+					Global: []Command{{ID: "docker", Name: "Docker", Command: "docker-compose up -d"}},
+					PerProject: map[string][]Command{
+						"api": {{Name: "Migrate", Command: "make migrate", Needs: StringList{"docker"}}},
+					},
+				}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "ritual command needs unknown id",
+			config: Config{
+				Version: CurrentVersion,
+				Settings: Settings{
+					WorkHours:     8.0,
+					BreakInterval: 50 * time.Minute,
+					IdleThreshold: 10 * time.Minute,
+				},
+				Rituals: Rituals{Stop: RitualSet{PerProject: map[string][]Command{
+					"api": {{Name: "Commit", Command: "git commit", Needs: StringList{"tests"}}},
+				}}},
+			},
+			wantErr: true,
+			errMsg:  `rituals.stop.per_project.api: command "Commit" needs unknown id "tests"`,
+		},
+		{
+			name: "ritual commands with duplicate ids",
+			config: Config{
+				Version: CurrentVersion,
+				Settings: Settings{
+					WorkHours:     8.0,
+					BreakInterval: 50 * time.Minute,
+					IdleThreshold: 10 * time.Minute,
+				},
+				Rituals: Rituals{Start: RitualSet{
+					Global:     []Command{{ID: "pull", Name: "Pull", Command: "git pull"}},
+					PerProject: map[string][]Command{"api": {{ID: "pull", Name: "Pull api", Command: "git pull"}}},
+				}},
+			},
+			wantErr: true,
+			errMsg:  `rituals.start.per_project.api: duplicate command id "pull"`,
+		},
+		{
+			name: "ritual commands with dependency cycle",
+			config: Config{
+				Version: CurrentVersion,
+				Settings: Settings{
+					WorkHours:     8.0,
+					BreakInterval: 50 * time.Minute,
+					IdleThreshold: 10 * time.Minute,
+				},
+				Rituals: Rituals{Start: RitualSet{Global: []Command{
+					{ID: "a", Name: "A", Command: "true", Needs: StringList{"c"}},
+					{ID: "b", Name: "B", Command: "true", Needs: StringList{"a"}},
+					{ID: "c", Name: "C", Command: "true", Needs: StringList{"b"}},
+				}}},
+			},
+			wantErr: true,
+			errMsg:  `rituals.start.global: dependency cycle: a -> c -> b -> a`,
+		},
+		{
+			name: "negative ritual parallelism",
+			config: Config{
+				Version: CurrentVersion,
+				Settings: Settings{
+					WorkHours:     8.0,
+					BreakInterval: 50 * time.Minute,
+					IdleThreshold: 10 * time.Minute,
+				},
+				Rituals: Rituals{Parallelism: -1},
+			},
+			wantErr: true,
+			errMsg:  `rituals.parallelism must not be negative`,
+		},
+		{
+			name: "ritual with undo, on_failure and finally",
+			config: Config{
+				Version: CurrentVersion,
+				Settings: Settings{
+					WorkHours:     8.0,
+					BreakInterval: 50 * time.Minute,
+					IdleThreshold: 10 * time.Minute,
+				},
+				Rituals: Rituals{Start: RitualSet{
+					Global:  []Command{{Name: "Docker", Command: "docker-compose up -d", Undo: "docker-compose down", OnFailure: "docker-compose logs"}},
+					Finally: []Command{{Name: "Notify", Command: "notify-send done"}},
+				}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "finally command with needs",
+			config: Config{
+				Version: CurrentVersion,
+				Settings: Settings{
+					WorkHours:     8.0,
+					BreakInterval: 50 * time.Minute,
+					IdleThreshold: 10 * time.Minute,
+				},
+				Rituals: Rituals{Stop: RitualSet{
+					Global:  []Command{{ID: "commit", Name: "Commit", Command: "git commit"}},
+					Finally: []Command{{Name: "Push", Command: "git push", Needs: StringList{"commit"}}},
+				}},
+			},
+			wantErr: true,
+			errMsg:  `rituals.stop.finally[0]: command "Push" runs in order at the end and cannot set needs or undo`,
+		},
+		{
+			name: "kill_background on start ritual",
+			config: Config{
+				Version: CurrentVersion,
+				Settings: Settings{
+					WorkHours:     8.0,
+					BreakInterval: 50 * time.Minute,
+					IdleThreshold: 10 * time.Minute,
+				},
+				Rituals: Rituals{Start: RitualSet{KillBackground: true}},
+			},
+			wantErr: true,
+			errMsg:  `rituals.start.kill_background is only supported on the stop ritual`,
+		},
+		{
+			name: "custom ritual with hooks",
+			config: Config{
+				Version: CurrentVersion,
+				Settings: Settings{
+					WorkHours:     8.0,
+					BreakInterval: 50 * time.Minute,
+					IdleThreshold: 10 * time.Minute,
+				},
+				Rituals: Rituals{
+					Custom: map[string]RitualSet{"lunch": {Global: []Command{{Name: "Lock", Command: "loginctl lock-session"}}}},
+					Hooks:  RitualHooks{BreakStart: "lunch", Resume: "start"},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "invalid custom ritual command",
+			config: Config{
+				Version: CurrentVersion,
+				Settings: Settings{
+					WorkHours:     8.0,
+					BreakInterval: 50 * time.Minute,
+					IdleThreshold: 10 * time.Minute,
+				},
+				Rituals: Rituals{Custom: map[string]RitualSet{"lunch": {Global: []Command{{Name: "Nothing", Command: ""}}}}},
+			},
+			wantErr: true,
+			errMsg:  `rituals.custom.lunch.global[0]: command "Nothing" is empty`,
+		},
+		{
+			name: "custom ritual named stop",
+			config: Config{
+				Version: CurrentVersion,
+				Settings: Settings{
+					WorkHours:     8.0,
+					BreakInterval: 50 * time.Minute,
+					IdleThreshold: 10 * time.Minute,
+				},
+				Rituals: Rituals{Custom: map[string]RitualSet{"stop": {}}},
+			},
+			wantErr: true,
+			errMsg:  `rituals.custom: invalid ritual name "stop"`,
+		},
+		{
+			name: "hook with unknown ritual",
+			config: Config{
+				Version: CurrentVersion,
+				Settings: Settings{
+					WorkHours:     8.0,
+					BreakInterval: 50 * time.Minute,
+					IdleThreshold: 10 * time.Minute,
+				},
+				Rituals: Rituals{Hooks: RitualHooks{Pause: "lunch"}},
+			},
+			wantErr: true,
+			errMsg:  `rituals.hooks.pause: unknown ritual "lunch"`,
+		},
+		{
+			name: "prompt without command",
+			config: Config{
+				Version: CurrentVersion,
+				Settings: Settings{
+					WorkHours:     8.0,
+					BreakInterval: 50 * time.Minute,
+					IdleThreshold: 10 * time.Minute,
+				},
+				Rituals: Rituals{Stop: RitualSet{Global: []Command{
+					{Name: "Note", Prompt: &Prompt{Var: "NOTE", Message: "Standup note"}},
+					{Name: "Commit", Command: "git commit -m \"$NOTE\"", Confirm: true},
+				}}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "prompt with invalid var",
+			config: Config{
+				Version: CurrentVersion,
+				Settings: Settings{
+					WorkHours:     8.0,
+					BreakInterval: 50 * time.Minute,
+					IdleThreshold: 10 * time.Minute,
+				},
+				Rituals: Rituals{Stop: RitualSet{Global: []Command{{Name: "Note", Prompt: &Prompt{Var: "my note"}}}}},
+			},
+			wantErr: true,
+			errMsg:  `rituals.stop.global[0]: command "Note" prompt has invalid var "my note"`,
+		},
+		{
+			name: "ritual command with unknown template variable",
+			config: Config{
+				Version: CurrentVersion,
+				Settings: Settings{
+					WorkHours:     8.0,
+					BreakInterval: 50 * time.Minute,
+					IdleThreshold: 10 * time.Minute,
+				},
+				Rituals: Rituals{Stop: RitualSet{Global: []Command{{Name: "Commit", Command: "git commit -m 'WIP {{.Projet}}'"}}}},
+			},
+			wantErr: true,
+			errMsg:  `rituals.stop.global[0]: command "Commit" has an invalid template in command`,
+		},
+		{
+			name: "ritual env with unclosed template",
+			config: Config{
+				Version: CurrentVersion,
+				Settings: Settings{
+					WorkHours:     8.0,
+					BreakInterval: 50 * time.Minute,
+					IdleThreshold: 10 * time.Minute,
+				},
+				Rituals: Rituals{Start: RitualSet{Global: []Command{{Name: "Serve", Command: "serve", Env: map[string]string{"BRANCH": "{{.GitBranch"}}}}},
+			},
+			wantErr: true,
+			errMsg:  `rituals.start.global[0]: command "Serve" has an invalid template in env BRANCH`,
+		},
+		{
+			name: "empty ritual command",
+			config: Config{
+				Version: CurrentVersion,
+				Settings: Settings{
+					WorkHours:     8.0,
+					BreakInterval: 50 * time.Minute,
+					IdleThreshold: 10 * time.Minute,
+				},
+				Rituals: Rituals{Start: RitualSet{Global: []Command{{Name: "Nothing", Command: " "}}}},
+			},
+			wantErr: true,
+			errMsg:  `rituals.start.global[0]: command "Nothing" is empty`,
+		},
 	}
 
 	for _, tt := range tests {
@@ -181,3 +708,141 @@ func TestExists(t *testing.T) {
 	require.NoError(t, err)
 	assert.True(t, exists)
 }
+
+func TestLoadRituals_PreservesKeyCase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+rituals:
+  start:
+    global:
+      - name: "Pull images"
+        command: "docker-compose pull"
+        timeout: 10m
+        env:
+          DOCKER_HOST: "unix:///var/run/docker.sock"
+          http_proxy: "http://proxy:3128"
+    per_project:
+      MainApp:
+        - name: "Serve"
+          command: "bun run dev"
+`), 0644))
+
+	rituals, err := loadRituals(path)
+	require.NoError(t, err)
+	require.Len(t, rituals.Start.Global, 1)
+	assert.Equal(t, map[string]string{"DOCKER_HOST": "unix:///var/run/docker.sock", "http_proxy": "http://proxy:3128"}, rituals.Start.Global[0].Env)
+	assert.Equal(t, 10*time.Minute, rituals.Start.Global[0].Timeout)
+	assert.Contains(t, rituals.Start.PerProject, "MainApp")
+}
+
+func TestLoadRituals_WhenAcceptsScalarsAndLists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+rituals:
+  start:
+    global:
+      - name: "Standup"
+        command: "open https://meet"
+        when:
+          days: [mon, wed]
+          time: "09:00-10:00"
+          os: darwin
+          env: CI
+`), 0644))
+
+	rituals, err := loadRituals(path)
+	require.NoError(t, err)
+	require.Len(t, rituals.Start.Global, 1)
+	when := rituals.Start.Global[0].When
+	assert.Equal(t, StringList{"mon", "wed"}, when.Days)
+	assert.Equal(t, "09:00-10:00", when.Time)
+	assert.Equal(t, StringList{"darwin"}, when.OS)
+	assert.Equal(t, StringList{"CI"}, when.Env)
+}
+
+func TestLoadRituals_CustomAndHooks(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+rituals:
+  custom:
+    Deploy-Day:
+      global:
+        - name: "Check CI"
+          command: "gh run list"
+  hooks:
+    break_start: Deploy-Day
+`), 0644))
+
+	rituals, err := loadRituals(path)
+	require.NoError(t, err)
+	set, ok := rituals.Ritual("Deploy-Day")
+	require.True(t, ok)
+	require.Len(t, set.Global, 1)
+	assert.Equal(t, "Deploy-Day", rituals.Hooks.BreakStart)
+	assert.Equal(t, []string{"start", "stop", "Deploy-Day"}, rituals.RitualNames())
+	v := &validator{}
+	rituals.validate(TemplateData{}, nil, v)
+	assert.NoError(t, v.err(nil))
+}
+
+func TestExpandCommand(t *testing.T) {
+	data := TemplateData{
+		Project:   "api",
+		Duration:  TemplateDuration(2*time.Hour + 14*time.Minute + 40*time.Second),
+		Today:     "2024-06-14",
+		GitBranch: "main",
+		Config:    &Config{Settings: Settings{Timezone: "Europe/Berlin"}},
+	}
+	cmd, err := ExpandCommand(Command{
+		Name:    "Commit",
+		Command: `git commit -m "WIP {{.Project}} {{.Duration}}"`,
+		Dir:     "~/notes/{{.Today}}",
+		Env:     map[string]string{"TZ": "{{.Config.Settings.Timezone}}", "PLAIN": "${HOME}"},
+		Undo:    "git checkout {{.GitBranch}}",
+	}, data)
+	require.NoError(t, err)
+	assert.Equal(t, `git commit -m "WIP api 2h15m"`, cmd.Command)
+	assert.Equal(t, "~/notes/2024-06-14", cmd.Dir)
+	assert.Equal(t, map[string]string{"TZ": "Europe/Berlin", "PLAIN": "${HOME}"}, cmd.Env)
+	assert.Equal(t, "git checkout main", cmd.Undo)
+
+	assert.Equal(t, "0m", TemplateDuration(20*time.Second).String())
+	assert.Equal(t, "45m", TemplateDuration(45*time.Minute).String())
+}
+
+func TestExpandCommand_Quote(t *testing.T) {
+	data := TemplateData{Project: "api", GitBranch: "it's/$(rm -rf ~)"}
+
+	cmd, err := ExpandCommand(Command{Name: "Checkout", Shell: "sh", Command: "git checkout {{q .GitBranch}}", Undo: "echo {{shellquote .Project}}"}, data)
+	require.NoError(t, err)
+	assert.Equal(t, `git checkout 'it'\''s/$(rm -rf ~)'`, cmd.Command)
+	assert.Equal(t, "echo 'api'", cmd.Undo)
+
+	cmd, err = ExpandCommand(Command{Name: "Checkout", Shell: "pwsh", Command: "git checkout {{q .GitBranch}}"}, data)
+	require.NoError(t, err)
+	assert.Equal(t, `git checkout 'it''s/$(rm -rf ~)'`, cmd.Command)
+	// Unquoted, an untrusted value has shell syntax replaced but still compares as itself
+	cmd, err = ExpandCommand(Command{Name: "Push", Shell: "sh", Command: `git push origin {{.GitBranch}}{{if eq .GitBranch "it's/$(rm -rf ~)"}} # same{{end}}`}, data)
+	require.NoError(t, err)
+	assert.Equal(t, "git push origin it-s/--rm--rf--- # same", cmd.Command)
+}
+
+func TestTimeWindow_Contains(t *testing.T) {
+	at := func(hour, minute int) time.Time { return time.Date(2024, 6, 15, hour, minute, 0, 0, time.UTC) }
+
+	window, err := ParseTimeWindow("09:00-17:30")
+	require.NoError(t, err)
+	assert.False(t, window.Contains(at(8, 59)))
+	assert.True(t, window.Contains(at(9, 0)))
+	assert.True(t, window.Contains(at(17, 29)))
+	assert.False(t, window.Contains(at(17, 30)))
+
+	overnight, err := ParseTimeWindow("22:00-02:00")
+	require.NoError(t, err)
+	assert.True(t, overnight.Contains(at(23, 0)))
+	assert.True(t, overnight.Contains(at(1, 59)))
+	assert.False(t, overnight.Contains(at(12, 0)))
+
+	_, err = ParseTimeWindow("09:00-09:00")
+	assert.ErrorContains(t, err, "is empty")
+}
@@ -0,0 +1,122 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContext is how many unchanged lines a diff shows around each change
+const diffContext = 3
+
+// diffLine is one line of a diff: ' ' unchanged, '-' removed or '+' added
+type diffLine struct {
+	op   byte
+	text string
+	// a and b are the 1-based line numbers in the old and new text
+	a, b int
+}
+
+// UnifiedDiff returns a unified diff from one version of a file to another,
+// or "" when they are the same
+func UnifiedDiff(fromName, toName string, from, to []byte) string {
+	a := splitLines(string(from))
+	b := splitLines(string(to))
+	lines := diffLines(a, b)
+
+	var out strings.Builder
+	for start := 0; start < len(lines); {
+		// Find the next change and the run of changes close enough to share a hunk
+		first := start
+		for first < len(lines) && lines[first].op == ' ' {
+			first++
+		}
+		if first == len(lines) {
+			break
+		}
+		last := first
+		for i := first; i < len(lines) && i-last <= 2*diffContext; i++ {
+			if lines[i].op != ' ' {
+				last = i
+			}
+		}
+		hunkStart := max(first-diffContext, 0)
+		hunkEnd := min(last+diffContext+1, len(lines))
+
+		if out.Len() == 0 {
+			fmt.Fprintf(&out, "--- %s\n+++ %s\n", fromName, toName)
+		}
+		hunk := lines[hunkStart:hunkEnd]
+		aStart, aCount, bStart, bCount := hunkRange(hunk)
+		fmt.Fprintf(&out, "@@ -%d,%d +%d,%d @@\n", aStart, aCount, bStart, bCount)
+		for _, line := range hunk {
+			fmt.Fprintf(&out, "%c%s\n", line.op, line.text)
+		}
+		start = hunkEnd
+	}
+	return out.String()
+}
+
+// hunkRange returns where a hunk starts in each file and how many of its lines each file has
+func hunkRange(hunk []diffLine) (aStart, aCount, bStart, bCount int) {
+	// A side with no lines in the hunk is numbered by the line before it
+	aStart, bStart = hunk[0].a, hunk[0].b
+	for _, line := range hunk {
+		if line.op != '+' {
+			if aCount == 0 {
+				aStart = line.a
+			}
+			aCount++
+		}
+		if line.op != '-' {
+			if bCount == 0 {
+				bStart = line.b
+			}
+			bCount++
+		}
+	}
+	return aStart, aCount, bStart, bCount
+}
+
+// diffLines aligns two texts line by line along their longest common subsequence
+func diffLines(a, b []string) []diffLine {
+	// common[i][j] is the length of the longest common subsequence of a[i:] and b[j:]
+	common := make([][]int, len(a)+1)
+	for i := range common {
+		common[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				common[i][j] = common[i+1][j+1] + 1
+			} else {
+				common[i][j] = max(common[i+1][j], common[i][j+1])
+			}
+		}
+	}
+
+	var lines []diffLine
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			lines = append(lines, diffLine{op: ' ', text: a[i], a: i + 1, b: j + 1})
+			i++
+			j++
+		case i < len(a) && (j == len(b) || common[i+1][j] >= common[i][j+1]):
+			lines = append(lines, diffLine{op: '-', text: a[i], a: i + 1, b: j})
+			i++
+		default:
+			lines = append(lines, diffLine{op: '+', text: b[j], a: i, b: j + 1})
+			j++
+		}
+	}
+	return lines
+}
+
+// splitLines splits text into lines without their line endings
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
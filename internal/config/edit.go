@@ -0,0 +1,443 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// File is a config file opened for editing. Changes are made on its YAML
+// node tree, so comments and key order survive, and saving keeps the
+// file's blank lines and indentation wherever they still apply.
+type File struct {
+	path     string
+	original []byte
+	doc      *yaml.Node
+}
+
+// OpenFile reads a config file for editing; a missing file starts empty
+func OpenFile(path string) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", displayPath(path), err)
+	}
+	if doc.Kind == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode}
+	}
+	if len(doc.Content) == 0 {
+		doc.Content = []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}
+	}
+	if doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("%s: config must be a mapping", displayPath(path))
+	}
+	return &File{path: path, original: data, doc: &doc}, nil
+}
+
+// keyStep is one step of a key path: a mapping key or a sequence index
+type keyStep struct {
+	key   string
+	index int
+}
+
+// formatKey writes key steps back as a key for messages
+func formatKey(steps []keyStep) string {
+	var b strings.Builder
+	for _, step := range steps {
+		switch {
+		case step.index >= 0:
+			fmt.Fprintf(&b, "[%d]", step.index)
+		case strings.ContainsAny(step.key, ".[]"):
+			fmt.Fprintf(&b, "[%q]", step.key)
+		default:
+			if b.Len() > 0 {
+				b.WriteByte('.')
+			}
+			b.WriteString(step.key)
+		}
+	}
+	return b.String()
+}
+
+// parseKey splits a key such as `rituals.start.global[0].command` into
+// steps. Keys containing dots are written in brackets:
+// `rituals.start.per_project["docs.site"]`.
+func parseKey(key string) ([]keyStep, error) {
+	var steps []keyStep
+	rest := key
+	for rest != "" {
+		switch {
+		case strings.HasPrefix(rest, `["`):
+			end := strings.Index(rest, `"]`)
+			if end < 0 {
+				return nil, fmt.Errorf("invalid key %q: unterminated [\"", key)
+			}
+			steps = append(steps, keyStep{key: rest[2:end], index: -1})
+			rest = rest[end+2:]
+		case strings.HasPrefix(rest, "["):
+			end := strings.Index(rest, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("invalid key %q: unterminated [", key)
+			}
+			index, err := strconv.Atoi(rest[1:end])
+			if err != nil || index < 0 {
+				return nil, fmt.Errorf("invalid key %q: bad index %q", key, rest[1:end])
+			}
+			steps = append(steps, keyStep{index: index})
+			rest = rest[end+1:]
+		default:
+			rest = strings.TrimPrefix(rest, ".")
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("invalid key %q: empty segment", key)
+			}
+			steps = append(steps, keyStep{key: rest[:end], index: -1})
+			rest = rest[end:]
+		}
+	}
+	if len(steps) == 0 {
+		return nil, fmt.Errorf("key cannot be empty")
+	}
+	return steps, nil
+}
+
+// lookup follows key steps from node, returning nil when a step is missing
+func lookup(node *yaml.Node, steps []keyStep) *yaml.Node {
+	for _, step := range steps {
+		switch {
+		case step.index >= 0:
+			if node.Kind != yaml.SequenceNode || step.index >= len(node.Content) {
+				return nil
+			}
+			node = node.Content[step.index]
+		case node.Kind == yaml.MappingNode:
+			i := mappingIndex(node, step.key)
+			if i < 0 {
+				return nil
+			}
+			node = node.Content[i+1]
+		default:
+			return nil
+		}
+	}
+	return node
+}
+
+// Get returns the value at key, or nil when it is not set
+func (f *File) Get(key string) (*yaml.Node, error) {
+	steps, err := parseKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return lookup(f.doc.Content[0], steps), nil
+}
+
+// Get returns the merged value at key, or nil when it is not set
+func (r *Resolved) Get(key string) (*yaml.Node, error) {
+	steps, err := parseKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return lookup(r.Document, steps), nil
+}
+
+// Set stores value at key, creating the mappings on the way. A sequence
+// index may name an existing element or the position just past the end.
+// A scalar that is replaced keeps its comments and quoting; new lists and
+// mappings are written in block style.
+func (f *File) Set(key string, value interface{}) error {
+	steps, err := parseKey(key)
+	if err != nil {
+		return err
+	}
+	var node yaml.Node
+	if err := node.Encode(value); err != nil {
+		return fmt.Errorf("failed to encode value for %s: %w", key, err)
+	}
+	blockStyle(&node)
+
+	parent := f.doc.Content[0]
+	for i, step := range steps {
+		last := i == len(steps)-1
+		next := &node
+		if !last {
+			// Missing parents become mappings or sequences, as the next step asks
+			next = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			if steps[i+1].index >= 0 {
+				next = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+			}
+		}
+
+		switch {
+		case step.index >= 0:
+			if parent.Kind != yaml.SequenceNode {
+				return fmt.Errorf("%s is not a list", formatKey(steps[:i]))
+			}
+			switch {
+			case step.index < len(parent.Content):
+				if last {
+					parent.Content[step.index] = replaceNode(parent.Content[step.index], next)
+				}
+			case step.index == len(parent.Content):
+				parent.Content = append(parent.Content, next)
+			default:
+				return fmt.Errorf("%s has %d items; cannot set %s", formatKey(steps[:i]), len(parent.Content), formatKey(steps[:i+1]))
+			}
+			parent = parent.Content[step.index]
+		case parent.Kind == yaml.MappingNode:
+			j := mappingIndex(parent, step.key)
+			switch {
+			case j < 0:
+				keyNode := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: step.key}
+				parent.Content = append(parent.Content, keyNode, next)
+				j = len(parent.Content) - 2
+			case last:
+				parent.Content[j+1] = replaceNode(parent.Content[j+1], next)
+			}
+			parent = parent.Content[j+1]
+		default:
+			return fmt.Errorf("%s is not a mapping", formatKey(steps[:i]))
+		}
+	}
+	return nil
+}
+
+// blockStyle writes a value's mappings and lists one item per line, as config files are written
+func blockStyle(node *yaml.Node) {
+	node.Style &^= yaml.FlowStyle
+	for _, child := range node.Content {
+		blockStyle(child)
+	}
+}
+
+// replaceNode returns the new value for a key, carrying over the comments
+// of the old one and the quoting of an old string
+func replaceNode(old, value *yaml.Node) *yaml.Node {
+	value.HeadComment, value.LineComment, value.FootComment = old.HeadComment, old.LineComment, old.FootComment
+	if old.Kind == yaml.ScalarNode && value.Kind == yaml.ScalarNode && old.Tag == "!!str" && value.Tag == "!!str" && value.Style == 0 {
+		value.Style = old.Style
+	}
+	return value
+}
+
+// Unset removes the key, or the list item, at key
+func (f *File) Unset(key string) error {
+	steps, err := parseKey(key)
+	if err != nil {
+		return err
+	}
+	parent := lookup(f.doc.Content[0], steps[:len(steps)-1])
+	step := steps[len(steps)-1]
+	switch {
+	case parent == nil:
+	case step.index >= 0 && parent.Kind == yaml.SequenceNode && step.index < len(parent.Content):
+		parent.Content = append(parent.Content[:step.index], parent.Content[step.index+1:]...)
+		return nil
+	case step.index < 0 && parent.Kind == yaml.MappingNode:
+		if i := mappingIndex(parent, step.key); i >= 0 {
+			parent.Content = append(parent.Content[:i], parent.Content[i+2:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("%s is not set", key)
+}
+
+// Bytes renders the edited file
+func (f *File) Bytes() ([]byte, error) {
+	var b bytes.Buffer
+	encoder := yaml.NewEncoder(&b)
+	encoder.SetIndent(detectIndent(f.original))
+	if err := encoder.Encode(f.doc); err != nil {
+		return nil, fmt.Errorf("failed to encode config: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode config: %w", err)
+	}
+	encoded := b.Bytes()
+
+	// The encoder drops blank lines and respaces comments; take those lines
+	// from the original where they still fit, as long as the result means
+	// exactly what the encoder wrote
+	restored := restoreFormatting(f.original, encoded)
+	if sameYAML(restored, encoded) {
+		return restored, nil
+	}
+	return encoded, nil
+}
+
+// Validate checks the edited config as rune would load it, with its includes
+func (f *File) Validate() error {
+	data, err := f.Bytes()
+	if err != nil {
+		return err
+	}
+	// Write next to the original so that relative includes resolve the same way
+	tmp, err := os.CreateTemp(filepath.Dir(f.path), ".config-*.yaml")
+	if err != nil {
+		return fmt.Errorf("failed to check config: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to check config: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to check config: %w", err)
+	}
+
+	resolved, err := Resolve(tmp.Name())
+	if err != nil {
+		return err
+	}
+	var cfg Config
+	if err := resolved.decode(&cfg); err != nil {
+		return err
+	}
+	err = cfg.Validate()
+	if validation, ok := err.(*ValidationError); ok {
+		for i := range validation.Issues {
+			if validation.Issues[i].File == tmp.Name() {
+				validation.Issues[i].File = f.path
+			}
+		}
+	}
+	return err
+}
+
+// Save writes the edited file
+func (f *File) Save() error {
+	data, err := f.Bytes()
+	if err != nil {
+		return err
+	}
+	if err := WriteConfigFile(f.path, data); err != nil {
+		return err
+	}
+	f.original = data
+	return nil
+}
+
+// WriteConfigFile replaces a config file in one step, through a temporary
+// file renamed over it, keeping its permissions
+func WriteConfigFile(path string, data []byte) error {
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".config-*.yaml")
+	if err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	return nil
+}
+
+// detectIndent returns the indentation the file uses for nested mappings, defaulting to two spaces
+func detectIndent(data []byte) int {
+	for _, line := range strings.Split(string(data), "\n") {
+		trimmed := strings.TrimLeft(line, " ")
+		indent := len(line) - len(trimmed)
+		if indent > 0 && trimmed != "" && !strings.HasPrefix(trimmed, "#") && !strings.HasPrefix(trimmed, "- ") {
+			return indent
+		}
+	}
+	return 2
+}
+
+// restoreFormatting aligns the encoded file with the original and keeps the
+// original's blank lines, and its version of lines that differ only in
+// spacing after their indentation. A changed line takes the place of the
+// line it replaces and keeps the spacing before that line's comment.
+func restoreFormatting(original, encoded []byte) []byte {
+	a := splitLines(string(original))
+	b := splitLines(string(encoded))
+	normalized := func(lines []string) []string {
+		out := make([]string, len(lines))
+		for i, line := range lines {
+			trimmed := strings.TrimLeft(line, " ")
+			out[i] = line[:len(line)-len(trimmed)] + strings.Join(strings.Fields(trimmed), " ")
+		}
+		return out
+	}
+
+	var out, removed, added []string
+	flush := func() {
+		for _, line := range removed {
+			switch {
+			case strings.TrimSpace(line) == "":
+				out = append(out, "")
+			case len(added) > 0:
+				out = append(out, keepCommentGap(line, added[0]))
+				added = added[1:]
+			}
+		}
+		out = append(out, added...)
+		removed, added = nil, nil
+	}
+	for _, line := range diffLines(normalized(a), normalized(b)) {
+		switch line.op {
+		case ' ':
+			flush()
+			out = append(out, a[line.a-1])
+		case '-':
+			removed = append(removed, a[line.a-1])
+		case '+':
+			added = append(added, b[line.b-1])
+		}
+	}
+	flush()
+	if len(out) == 0 {
+		return encoded
+	}
+	return []byte(strings.Join(out, "\n") + "\n")
+}
+
+// keepCommentGap respaces the comment of a changed line as the old line had it
+func keepCommentGap(old, changed string) string {
+	i, j := strings.Index(old, " #"), strings.Index(changed, " #")
+	if i < 0 || j < 0 || strings.TrimLeft(old[i:], " ") != strings.TrimLeft(changed[j:], " ") {
+		return changed
+	}
+	code := strings.TrimRight(old[:i+1], " ")
+	gap := old[len(code) : i+1]
+	return strings.TrimRight(changed[:j+1], " ") + gap + strings.TrimLeft(changed[j:], " ")
+}
+
+// sameYAML reports whether two documents hold the same data
+func sameYAML(a, b []byte) bool {
+	var x, y interface{}
+	if yaml.Unmarshal(a, &x) != nil || yaml.Unmarshal(b, &y) != nil {
+		return false
+	}
+	return reflect.DeepEqual(x, y)
+}
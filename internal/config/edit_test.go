@@ -0,0 +1,187 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+var editConfig = fmt.Sprintf(`# Rune config
+version: %d
+
+settings:
+  work_hours: 8.0  # a day
+  break_interval: 50m
+  idle_threshold: "10m"
+
+  # notifications
+  notifications:
+    enabled: true
+
+projects:
+  - name: api
+    detect: ["file:go.mod"]
+
+rituals:
+  start:
+    global:
+      - name: Pull
+        command: git pull
+`, CurrentVersion)
+
+func openEditFile(t *testing.T) (*File, string) {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	path := filepath.Join(dir, ".rune", "config.yaml")
+	writeFile(t, path, editConfig)
+	file, err := OpenFile(path)
+	require.NoError(t, err)
+	return file, path
+}
+
+func TestParseKey(t *testing.T) {
+	steps, err := parseKey(`rituals.start.per_project["docs.site"][2].command`)
+	require.NoError(t, err)
+	assert.Equal(t, []keyStep{
+		{key: "rituals", index: -1},
+		{key: "start", index: -1},
+		{key: "per_project", index: -1},
+		{key: "docs.site", index: -1},
+		{index: 2},
+		{key: "command", index: -1},
+	}, steps)
+	assert.Equal(t, `rituals.start.per_project["docs.site"][2].command`, formatKey(steps))
+
+	for _, key := range []string{"", "settings..work_hours", "a[x]", "a[1", `a["b`} {
+		_, err := parseKey(key)
+		assert.Error(t, err, key)
+	}
+}
+
+func TestFile_SetKeepsFormatting(t *testing.T) {
+	file, path := openEditFile(t)
+
+	require.NoError(t, file.Set("settings.work_hours", 7.5))
+	require.NoError(t, file.Set("settings.idle_threshold", "15m"))
+	require.NoError(t, file.Set("user_id", "anon_1"))
+	require.NoError(t, file.Validate())
+	require.NoError(t, file.Save())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	want := fmt.Sprintf(`# Rune config
+version: %d
+
+settings:
+  work_hours: 7.5  # a day
+  break_interval: 50m
+  idle_threshold: "15m"
+
+  # notifications
+  notifications:
+    enabled: true
+
+projects:
+  - name: api
+    detect: ["file:go.mod"]
+
+rituals:
+  start:
+    global:
+      - name: Pull
+        command: git pull
+user_id: anon_1
+`, CurrentVersion)
+	assert.Equal(t, want, string(data))
+}
+
+func TestFile_SetCreatesParents(t *testing.T) {
+	file, _ := openEditFile(t)
+
+	var value yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte(`[{name: Up, command: docker compose up -d}]`), &value))
+	require.NoError(t, file.Set(`rituals.start.per_project["api"]`, value.Content[0]))
+	require.NoError(t, file.Set("rituals.start.global[1].name", "Test"))
+	require.NoError(t, file.Set("rituals.start.global[1].command", "make test"))
+	require.NoError(t, file.Validate())
+
+	data, err := file.Bytes()
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `      - name: Test
+        command: make test
+    per_project:
+      api:
+        - name: Up
+          command: docker compose up -d
+`)
+
+	assert.EqualError(t, file.Set("rituals.start.global[5]", "x"), "rituals.start.global has 2 items; cannot set rituals.start.global[5]")
+	assert.EqualError(t, file.Set("settings.work_hours.x", 1), "settings.work_hours is not a mapping")
+	assert.EqualError(t, file.Set("settings[0]", 1), "settings is not a list")
+}
+
+func TestFile_GetAndUnset(t *testing.T) {
+	file, _ := openEditFile(t)
+
+	value, err := file.Get("rituals.start.global[0].command")
+	require.NoError(t, err)
+	require.NotNil(t, value)
+	assert.Equal(t, "git pull", value.Value)
+
+	require.NoError(t, file.Unset("settings.notifications"))
+	require.NoError(t, file.Unset("rituals.start.global[0]"))
+	assert.EqualError(t, file.Unset("settings.nope"), "settings.nope is not set")
+
+	value, err = file.Get("settings.notifications")
+	require.NoError(t, err)
+	assert.Nil(t, value)
+
+	data, err := file.Bytes()
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "notifications")
+	assert.Contains(t, string(data), "work_hours: 8.0  # a day")
+	assert.Contains(t, string(data), "global: []")
+}
+
+func TestFile_ValidateLocatesIssues(t *testing.T) {
+	file, path := openEditFile(t)
+
+	require.NoError(t, file.Set("settings.work_hours", 30))
+	err := file.Validate()
+	var validation *ValidationError
+	require.ErrorAs(t, err, &validation)
+	require.Len(t, validation.Issues, 1)
+	assert.Equal(t, path, validation.Issues[0].File)
+	assert.Equal(t, 5, validation.Issues[0].Line)
+
+	// Nothing is written until the file is saved
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, editConfig, string(data))
+}
+
+func TestSetValue(t *testing.T) {
+	_, path := openEditFile(t)
+	require.NoError(t, os.Chmod(path, 0600))
+
+	require.NoError(t, SetValue("user_id", "anon_2"))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, editConfig+"user_id: anon_2\n", string(data))
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	// Only an existing file is edited
+	require.NoError(t, os.Remove(path))
+	assert.Error(t, SetValue("user_id", "anon_3"))
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+}
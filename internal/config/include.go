@@ -0,0 +1,485 @@
+package config
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultPackFile is the config file read from a git include that names no file
+const DefaultPackFile = ".rune.yaml"
+
+// includedSections are the top-level keys an included file may contribute
+var includedSections = []string{"settings", "projects", "rituals"}
+
+// Include pulls another config file into the config: a local path, or a file
+// in a git repository checked out at a pinned ref
+type Include struct {
+	Path string `yaml:"path"`
+	Git  string `yaml:"git"`
+	Ref  string `yaml:"ref"`
+	// File is the config file within the git repository; empty means DefaultPackFile
+	File string `yaml:"file"`
+}
+
+// UnmarshalYAML accepts a plain path as well as the full form
+func (i *Include) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		i.Path = value.Value
+		return nil
+	}
+	type plain Include
+	return value.Decode((*plain)(i))
+}
+
+// String describes the include as written in the config
+func (i Include) String() string {
+	if i.Git != "" {
+		file := i.File
+		if file == "" {
+			file = DefaultPackFile
+		}
+		return fmt.Sprintf("%s@%s:%s", i.Git, i.Ref, file)
+	}
+	return i.Path
+}
+
+// validate checks that the include names exactly one source
+func (i Include) validate() error {
+	switch {
+	case i.Path != "" && i.Git != "":
+		return fmt.Errorf("include %q sets both path and git", i.String())
+	case i.Path == "" && i.Git == "":
+		return fmt.Errorf("include must set a path or a git repository")
+	case i.Git != "" && i.Ref == "":
+		return fmt.Errorf("include %q must be pinned to a ref", i.Git)
+	case strings.HasPrefix(i.Git, "-") || strings.HasPrefix(i.Ref, "-"):
+		// git would read these as options
+		return fmt.Errorf("include %q: git and ref cannot start with '-'", i.String())
+	case i.Path != "" && (i.Ref != "" || i.File != ""):
+		return fmt.Errorf("include %q: ref and file only apply to git includes", i.Path)
+	}
+	return nil
+}
+
+// Resolved is a config file merged with the files it includes
+type Resolved struct {
+	// Document is the merged config, with a comment on each value naming the file it came from
+	Document *yaml.Node
+	// Sources are the merged files in the order they were applied, the config file last
+	Sources []string
+
+	// files maps each node of Document to the file it was read from
+	files map[*yaml.Node]string
+	// unknown are the keys the config schema does not know, in the order they were read
+	unknown []Issue
+}
+
+// Resolve reads a config file and merges in the files it includes, in order.
+// Included files only contribute settings, projects and rituals. Later files
+// take precedence over earlier ones and the including file over its includes:
+// settings and other maps are merged key by key, projects with the same name
+// are replaced, and ritual command lists are appended to.
+func Resolve(path string) (*Resolved, error) {
+	r := &Resolved{}
+	doc, err := r.load(path, nil)
+	if err != nil {
+		return nil, err
+	}
+	r.Document = doc
+	return r, nil
+}
+
+// decode sets the version, settings, projects and rituals of cfg from the
+// resolved config, which has been migrated to CurrentVersion. Rituals are
+// decoded directly because viper lowercases map keys, which would change
+// environment variable names and the project names under per_project.
+func (r *Resolved) decode(cfg *Config) error {
+	var file struct {
+		Version  int       `yaml:"version"`
+		Settings Settings  `yaml:"settings"`
+		Projects []Project `yaml:"projects"`
+		Rituals  Rituals   `yaml:"rituals"`
+	}
+	if err := r.Document.Decode(&file); err != nil {
+		return fmt.Errorf("failed to parse config: %w", err)
+	}
+
+	// Viper read the file as written; these sections come from the
+	// migrated document merged with its includes
+	cfg.Version = file.Version
+	cfg.Settings = file.Settings
+	cfg.Projects = file.Projects
+	cfg.Rituals = file.Rituals
+	cfg.resolved = r
+	return nil
+}
+
+// YAML renders the resolved config with its source comments
+func (r *Resolved) YAML() ([]byte, error) {
+	var b bytes.Buffer
+	encoder := yaml.NewEncoder(&b)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(r.Document); err != nil {
+		return nil, fmt.Errorf("failed to encode resolved config: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode resolved config: %w", err)
+	}
+	return b.Bytes(), nil
+}
+
+// load reads one config file, merged with its includes
+func (r *Resolved) load(path string, stack []string) (*yaml.Node, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	for i, seen := range stack {
+		if seen == abs {
+			cycle := append(append([]string{}, stack[i:]...), abs)
+			for j := range cycle {
+				cycle[j] = displayPath(cycle[j])
+			}
+			return nil, fmt.Errorf("include cycle: %s", strings.Join(cycle, " -> "))
+		}
+	}
+	stack = append(stack, abs)
+
+	data, err := os.ReadFile(abs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	// Files written for an older schema, such as shared packs, are upgraded in memory
+	migration, err := Migrate(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", displayPath(abs), err)
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(migration.Data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", displayPath(abs), err)
+	}
+	root := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	if len(doc.Content) > 0 && doc.Content[0].Kind == yaml.MappingNode {
+		root = doc.Content[0]
+	}
+	clearComments(root)
+	sourceLines(root, migration)
+	r.recordFile(root, abs)
+	r.checkKeys(root, abs)
+
+	var file struct {
+		Include []Include `yaml:"include"`
+	}
+	if err := root.Decode(&file); err != nil {
+		return nil, fmt.Errorf("%s: invalid include: %w", displayPath(abs), err)
+	}
+
+	merged := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	for _, include := range file.Include {
+		if err := include.validate(); err != nil {
+			return nil, fmt.Errorf("%s: %w", displayPath(abs), err)
+		}
+		includePath, err := include.locate(filepath.Dir(abs))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", displayPath(abs), err)
+		}
+		included, err := r.load(includePath, stack)
+		if err != nil {
+			return nil, err
+		}
+		// The included document is already annotated with its own sources
+		mergeMapping(merged, onlyKeys(included, includedSections), "")
+	}
+
+	own := withoutKey(root, "include")
+	annotate(own, displayPath(abs))
+	mergeMapping(merged, own, "")
+	r.Sources = append(r.Sources, abs)
+	return merged, nil
+}
+
+// locate returns the path of an included file, fetching git includes
+func (i Include) locate(dir string) (string, error) {
+	if i.Git == "" {
+		path, err := expandDir(i.Path)
+		if err != nil {
+			return "", err
+		}
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		return path, nil
+	}
+
+	checkout, err := fetchPack(i.Git, i.Ref)
+	if err != nil {
+		return "", err
+	}
+	file := i.File
+	if file == "" {
+		file = DefaultPackFile
+	}
+	return filepath.Join(checkout, file), nil
+}
+
+// fetchPack checks out a git repository at a ref in ~/.rune/packs, cloning it
+// the first time, fetching only when the ref is not known yet and checking
+// out only when HEAD is elsewhere. The ref must be a tag or a commit, which
+// do not move, so a checkout that has it is up to date; branches are refused.
+func fetchPack(url, ref string) (string, error) {
+	if strings.HasPrefix(url, "-") || strings.HasPrefix(ref, "-") {
+		return "", fmt.Errorf("invalid git include %s@%s", url, ref)
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	sum := sha256.Sum256([]byte(url))
+	dir := filepath.Join(home, ".rune", "packs", hex.EncodeToString(sum[:])[:16])
+
+	if _, err := os.Stat(filepath.Join(dir, ".git")); os.IsNotExist(err) {
+		if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+			return "", fmt.Errorf("failed to create packs directory: %w", err)
+		}
+		if _, err := git("", "clone", "--quiet", "--no-checkout", "--", url, dir); err != nil {
+			return "", fmt.Errorf("failed to clone %s: %w", url, err)
+		}
+	}
+
+	commit, err := pinnedCommit(dir, ref)
+	if err != nil {
+		if _, err := git(dir, "fetch", "--quiet", "--tags", "origin"); err != nil {
+			return "", fmt.Errorf("failed to fetch %s: %w", url, err)
+		}
+		if commit, err = pinnedCommit(dir, ref); err != nil {
+			return "", fmt.Errorf("failed to check out %s at %s: %w", url, ref, err)
+		}
+	}
+	// Every command loads the config, so only touch the checkout when the pin moved
+	if head, err := git(dir, "rev-parse", "--verify", "--quiet", "HEAD"); err == nil && head == commit {
+		return dir, nil
+	}
+	if _, err := git(dir, "checkout", "--quiet", "--detach", commit, "--"); err != nil {
+		return "", fmt.Errorf("failed to check out %s at %s: %w", url, ref, err)
+	}
+	return dir, nil
+}
+
+// commitPattern matches full or abbreviated commit hashes
+var commitPattern = regexp.MustCompile(`^[0-9a-f]{7,40}$`)
+
+// pinnedCommit returns the commit a tag or commit hash names in a checkout
+func pinnedCommit(dir, ref string) (string, error) {
+	if commitPattern.MatchString(ref) {
+		if commit, err := git(dir, "rev-parse", "--verify", "--quiet", ref+"^{commit}"); err == nil {
+			return commit, nil
+		}
+	}
+	if commit, err := git(dir, "rev-parse", "--verify", "--quiet", "refs/tags/"+ref+"^{commit}"); err == nil {
+		return commit, nil
+	}
+	if _, err := git(dir, "rev-parse", "--verify", "--quiet", "refs/remotes/origin/"+ref); err == nil {
+		return "", fmt.Errorf("%s is a branch; pin includes to a tag or commit", ref)
+	}
+	return "", fmt.Errorf("no tag or commit named %s", ref)
+}
+
+// git runs a git command and returns its trimmed output, or its error output on failure
+func git(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return "", fmt.Errorf("%s", message)
+		}
+		return "", err
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// expandDir expands a leading ~ and environment variables in a path
+func expandDir(path string) (string, error) {
+	path = os.ExpandEnv(path)
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to get home directory: %w", err)
+		}
+		path = filepath.Join(home, path[1:])
+	}
+	return path, nil
+}
+
+// displayPath shortens a path in the home directory to start with ~
+func displayPath(path string) string {
+	if home, err := os.UserHomeDir(); err == nil {
+		if rel, err := filepath.Rel(home, path); err == nil && !strings.HasPrefix(rel, "..") {
+			return filepath.Join("~", rel)
+		}
+	}
+	return path
+}
+
+// mergeMapping merges src into dst. Values in src replace those in dst,
+// except that maps are merged, projects are merged by name and lists under
+// rituals are appended to. dstPath is the dotted path of dst.
+func mergeMapping(dst, src *yaml.Node, dstPath string) {
+	for i := 0; i+1 < len(src.Content); i += 2 {
+		key, value := src.Content[i], src.Content[i+1]
+		path := key.Value
+		if dstPath != "" {
+			path = dstPath + "." + key.Value
+		}
+
+		j := mappingIndex(dst, key.Value)
+		if j < 0 {
+			dst.Content = append(dst.Content, key, value)
+			continue
+		}
+		existingKey, existing := dst.Content[j], dst.Content[j+1]
+
+		// Entries keep their own source once values from two files are combined
+		switch {
+		case existing.Kind == yaml.MappingNode && value.Kind == yaml.MappingNode:
+			pushDown(existingKey, existing)
+			pushDown(key, value)
+			mergeMapping(existing, value, path)
+		case path == "projects" && existing.Kind == yaml.SequenceNode && value.Kind == yaml.SequenceNode:
+			pushDown(existingKey, existing)
+			pushDown(key, value)
+			mergeProjects(existing, value)
+		case strings.HasPrefix(path, "rituals.") && existing.Kind == yaml.SequenceNode && value.Kind == yaml.SequenceNode:
+			pushDown(existingKey, existing)
+			pushDown(key, value)
+			existing.Content = append(existing.Content, value.Content...)
+		default:
+			dst.Content[j], dst.Content[j+1] = key, value
+		}
+	}
+}
+
+// mergeProjects replaces projects with the same name and appends new ones
+func mergeProjects(dst, src *yaml.Node) {
+	for _, project := range src.Content {
+		replaced := false
+		if name := mappingValue(project, "name"); name != "" {
+			for i, existing := range dst.Content {
+				if mappingValue(existing, "name") == name {
+					dst.Content[i] = project
+					replaced = true
+					break
+				}
+			}
+		}
+		if !replaced {
+			dst.Content = append(dst.Content, project)
+		}
+	}
+}
+
+// annotate marks the values of a mapping as coming from source. Maps and lists
+// are marked on their key, so a whole section from one file has a single comment.
+func annotate(mapping *yaml.Node, source string) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		key, value := mapping.Content[i], mapping.Content[i+1]
+		if value.Kind == yaml.ScalarNode {
+			value.LineComment = "from " + source
+		} else {
+			key.LineComment = "from " + source
+		}
+	}
+}
+
+// pushDown moves the source comment of a map or list about to be merged with
+// values from another file onto its entries, which keep their origin
+func pushDown(key, value *yaml.Node) {
+	comment := key.LineComment
+	if comment == "" {
+		return
+	}
+	key.LineComment = ""
+	switch value.Kind {
+	case yaml.MappingNode:
+		annotate(value, strings.TrimPrefix(comment, "from "))
+	case yaml.SequenceNode:
+		for _, item := range value.Content {
+			if item.Kind == yaml.ScalarNode {
+				item.LineComment = comment
+			} else {
+				item.HeadComment = comment
+			}
+		}
+	}
+}
+
+// clearComments removes the comments of a parsed file, so that only source comments remain
+func clearComments(node *yaml.Node) {
+	node.HeadComment, node.LineComment, node.FootComment = "", "", ""
+	for _, child := range node.Content {
+		clearComments(child)
+	}
+}
+
+// sourceLines renumbers the nodes of a migrated document with the lines of the original file
+func sourceLines(node *yaml.Node, migration *Migration) {
+	node.Line = migration.SourceLine(node.Line)
+	for _, child := range node.Content {
+		sourceLines(child, migration)
+	}
+}
+
+// mappingIndex returns the index of a key in a mapping node, or -1
+func mappingIndex(mapping *yaml.Node, key string) int {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// mappingValue returns the scalar value of a key in a mapping node
+func mappingValue(mapping *yaml.Node, key string) string {
+	if mapping.Kind != yaml.MappingNode {
+		return ""
+	}
+	if i := mappingIndex(mapping, key); i >= 0 {
+		return mapping.Content[i+1].Value
+	}
+	return ""
+}
+
+// onlyKeys returns a mapping with only the given top-level keys
+func onlyKeys(mapping *yaml.Node, keys []string) *yaml.Node {
+	result := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		for _, key := range keys {
+			if mapping.Content[i].Value == key {
+				result.Content = append(result.Content, mapping.Content[i], mapping.Content[i+1])
+			}
+		}
+	}
+	return result
+}
+
+// withoutKey returns a mapping without the given top-level key
+func withoutKey(mapping *yaml.Node, key string) *yaml.Node {
+	result := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value != key {
+			result.Content = append(result.Content, mapping.Content[i], mapping.Content[i+1])
+		}
+	}
+	return result
+}
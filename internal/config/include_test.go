@@ -0,0 +1,183 @@
+package config
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+}
+
+func TestResolve_LocalIncludes(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	writeFile(t, filepath.Join(dir, "team", "rituals.yaml"), `
+user_id: ignored
+settings:
+  work_hours: 7
+  break_interval: 45m
+projects:
+  - name: api
+    detect: ["team-api"]
+  - name: docs
+    detect: ["mkdocs.yml"]
+rituals:
+  start:
+    global:
+      - name: "Pull"
+        command: "git pull"
+  custom:
+    lunch:
+      global:
+        - name: "Lock"
+          command: "loginctl lock-session"
+`)
+	main := filepath.Join(dir, ".rune", "config.yaml")
+	writeFile(t, main, `
+version: 1
+include:
+  - ~/team/rituals.yaml
+settings:
+  work_hours: 8
+projects:
+  - name: api
+    detect: ["go.mod"]
+rituals:
+  start:
+    global:
+      - name: "Personal"
+        command: "echo hi"
+`)
+
+	resolved, err := Resolve(main)
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "team", "rituals.yaml"), main}, resolved.Sources)
+
+	var cfg Config
+	require.NoError(t, resolved.decode(&cfg))
+	assert.Equal(t, 8.0, cfg.Settings.WorkHours)
+	assert.Equal(t, 45*time.Minute, cfg.Settings.BreakInterval)
+	// Included files written for version 1 are upgraded in memory
+	assert.Equal(t, []Project{{Name: "api", Detect: []string{"file:go.mod"}}, {Name: "docs", Detect: []string{"file:mkdocs.yml"}}}, cfg.Projects)
+	require.Len(t, cfg.Rituals.Start.Global, 2)
+	assert.Equal(t, "Pull", cfg.Rituals.Start.Global[0].Name)
+	assert.Equal(t, "Personal", cfg.Rituals.Start.Global[1].Name)
+	assert.Contains(t, cfg.Rituals.Custom, "lunch")
+
+	content, err := resolved.YAML()
+	require.NoError(t, err)
+	output := string(content)
+	assert.NotContains(t, output, "ignored")
+	assert.Contains(t, output, "work_hours: 8 # from ~/.rune/config.yaml")
+	assert.Contains(t, output, "break_interval: 45m # from ~/team/rituals.yaml")
+	assert.Contains(t, output, "custom: # from ~/team/rituals.yaml")
+	assert.Contains(t, output, "# from ~/team/rituals.yaml\n      - name: \"Pull\"")
+	assert.Contains(t, output, "# from ~/.rune/config.yaml\n      - name: \"Personal\"")
+}
+
+func TestResolve_Errors(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+
+	t.Run("cycle", func(t *testing.T) {
+		writeFile(t, filepath.Join(dir, "a.yaml"), "include: [b.yaml]\n")
+		writeFile(t, filepath.Join(dir, "b.yaml"), "include: [a.yaml]\n")
+		_, err := Resolve(filepath.Join(dir, "a.yaml"))
+		assert.ErrorContains(t, err, "include cycle: ~/a.yaml -> ~/b.yaml -> ~/a.yaml")
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		writeFile(t, filepath.Join(dir, "c.yaml"), "include: [missing.yaml]\n")
+		_, err := Resolve(filepath.Join(dir, "c.yaml"))
+		assert.ErrorContains(t, err, "failed to read config file")
+	})
+
+	t.Run("git include with an option for a ref", func(t *testing.T) {
+		writeFile(t, filepath.Join(dir, "e.yaml"), "include:\n  - git: https://example.com/pack.git\n    ref: --upload-pack=touch /tmp/pwned\n")
+		_, err := Resolve(filepath.Join(dir, "e.yaml"))
+		assert.ErrorContains(t, err, "git and ref cannot start with '-'")
+	})
+
+	t.Run("git include without ref", func(t *testing.T) {
+		writeFile(t, filepath.Join(dir, "d.yaml"), "include:\n  - git: https://example.com/pack.git\n")
+		_, err := Resolve(filepath.Join(dir, "d.yaml"))
+		assert.ErrorContains(t, err, `include "https://example.com/pack.git" must be pinned to a ref`)
+	})
+}
+
+func TestResolve_GitInclude(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+
+	repo := filepath.Join(dir, "pack")
+	run := func(args ...string) {
+		t.Helper()
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repo
+		cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=t", "GIT_AUTHOR_EMAIL=t@example.com", "GIT_COMMITTER_NAME=t", "GIT_COMMITTER_EMAIL=t@example.com")
+		output, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(output))
+	}
+	writeFile(t, filepath.Join(repo, ".rune.yaml"), "rituals:\n  stop:\n    global:\n      - name: v1\n        command: echo v1\n")
+	run("init", "--quiet")
+	run("add", ".")
+	run("commit", "--quiet", "-m", "v1")
+	run("tag", "v1")
+	writeFile(t, filepath.Join(repo, ".rune.yaml"), "rituals:\n  stop:\n    global:\n      - name: v2\n        command: echo v2\n")
+	run("commit", "--quiet", "-am", "v2")
+	run("tag", "v2")
+
+	main := filepath.Join(dir, "config.yaml")
+	load := func(ref string) []Command {
+		t.Helper()
+		writeFile(t, main, "include:\n  - git: "+repo+"\n    ref: "+ref+"\n")
+		rituals, err := loadRituals(main)
+		require.NoError(t, err)
+		return rituals.Stop.Global
+	}
+	assert.Equal(t, "v1", load("v1")[0].Name)
+	assert.Equal(t, "v2", load("v2")[0].Name)
+
+	entries, err := os.ReadDir(filepath.Join(dir, ".rune", "packs"))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "the pack is cloned once")
+
+	writeFile(t, main, "include:\n  - git: "+repo+"\n    ref: v3\n")
+	_, err = loadRituals(main)
+	assert.True(t, err != nil && strings.Contains(err.Error(), "failed to check out"), "%v", err)
+
+	// Commits pin as well as tags do
+	output, err := exec.Command("git", "-C", repo, "rev-parse", "v1").Output()
+	require.NoError(t, err)
+	assert.Equal(t, "v1", load(strings.TrimSpace(string(output))[:12])[0].Name)
+
+	// Branches move, so they cannot pin an include
+	output, err = exec.Command("git", "-C", repo, "branch", "--show-current").Output()
+	require.NoError(t, err)
+	branch := strings.TrimSpace(string(output))
+	writeFile(t, main, "include:\n  - git: "+repo+"\n    ref: "+branch+"\n")
+	_, err = loadRituals(main)
+	assert.ErrorContains(t, err, branch+" is a branch; pin includes to a tag or commit")
+
+	// A checkout already at the pin is used as it is, with no network and
+	// even while another git process holds its lock
+	assert.Equal(t, "v2", load("v2")[0].Name)
+	require.NoError(t, os.RemoveAll(repo))
+	lock := filepath.Join(dir, ".rune", "packs", entries[0].Name(), ".git", "index.lock")
+	require.NoError(t, os.WriteFile(lock, nil, 0644))
+	assert.Equal(t, "v2", load("v2")[0].Name)
+}
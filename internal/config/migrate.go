@@ -0,0 +1,288 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CurrentVersion is the config schema version this build reads and writes.
+// Older files are upgraded by the migrations below, one version at a time.
+const CurrentVersion = 2
+
+// migration upgrades a config document from one schema version to the next
+type migration struct {
+	description string
+	apply       func(doc *document) error
+}
+
+// migrations[i] upgrades version i+1 to version i+2
+var migrations = []migration{
+	{description: "give every project detect pattern an explicit kind", apply: migrateDetectKinds},
+}
+
+// Migration is the result of upgrading a config file to CurrentVersion
+type Migration struct {
+	From int
+	To   int
+	// Applied describes each migration that ran, oldest first
+	Applied []string
+	// Data is the upgraded file; it equals the input when nothing ran
+	Data []byte
+
+	// inserted are the lines of Data, in order, that the migrations added
+	inserted []int
+}
+
+// SourceLine maps a line of the upgraded file to the line of the original
+// it came from, so that problems can be reported where the user wrote them.
+// A line the migrations added maps to the line that followed it.
+func (m *Migration) SourceLine(line int) int {
+	shift := 0
+	for _, inserted := range m.inserted {
+		if inserted > line {
+			break
+		}
+		if inserted < line {
+			shift++
+		}
+	}
+	return line - shift
+}
+
+// Migrate upgrades a config file to CurrentVersion. Files without a version
+// are treated as version 1. Edits are made in place in the source text, so
+// comments and formatting elsewhere in the file are kept.
+func Migrate(data []byte) (*Migration, error) {
+	doc, err := parseDocument(data)
+	if err != nil {
+		return nil, err
+	}
+	result := &Migration{From: CurrentVersion, To: CurrentVersion, Data: data}
+	if doc.root == nil {
+		return result, nil
+	}
+
+	version := 1
+	versionNode := mappingNode(doc.root, "version")
+	if versionNode != nil {
+		version, err = strconv.Atoi(versionNode.Value)
+		if err != nil || version < 1 {
+			return nil, fmt.Errorf("invalid config version %q", versionNode.Value)
+		}
+	}
+	if version > CurrentVersion {
+		return nil, fmt.Errorf("config version %d is newer than this version of rune supports (%d)", version, CurrentVersion)
+	}
+	result.From = version
+	if version == CurrentVersion {
+		return result, nil
+	}
+
+	for v := version; v < CurrentVersion; v++ {
+		m := migrations[v-1]
+		if err := m.apply(doc); err != nil {
+			return nil, fmt.Errorf("migration to version %d (%s) failed: %w", v+1, m.description, err)
+		}
+		result.Applied = append(result.Applied, fmt.Sprintf("%d → %d: %s", v, v+1, m.description))
+	}
+
+	if versionNode != nil {
+		if err := doc.setScalar(versionNode, strconv.Itoa(CurrentVersion)); err != nil {
+			return nil, err
+		}
+	} else {
+		doc.insertLine(doc.root.Content[0].Line, fmt.Sprintf("version: %d", CurrentVersion))
+	}
+
+	result.Data, result.inserted = doc.render()
+	return result, nil
+}
+
+// MigrateFile upgrades the config file at path to CurrentVersion, first
+// copying the original to a backup next to it. It returns the migration,
+// whose backup path is empty when the file was already current.
+func MigrateFile(path string) (*Migration, string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read config file: %w", err)
+	}
+	migration, err := Migrate(data)
+	if err != nil {
+		return nil, "", fmt.Errorf("%s: %w", displayPath(path), err)
+	}
+	if len(migration.Applied) == 0 {
+		return migration, "", nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read config file: %w", err)
+	}
+	backup := fmt.Sprintf("%s.v%d.bak", path, migration.From)
+	if err := os.WriteFile(backup, data, info.Mode().Perm()); err != nil {
+		return nil, "", fmt.Errorf("failed to back up config file: %w", err)
+	}
+	if err := WriteConfigFile(path, migration.Data); err != nil {
+		return nil, "", fmt.Errorf("failed to write migrated config file: %w", err)
+	}
+	return migration, backup, nil
+}
+
+// migrateDetectKinds prefixes bare detect patterns, which version 1 read as
+// file globs, with file: so every rule names its kind
+func migrateDetectKinds(doc *document) error {
+	projects := mappingNode(doc.root, "projects")
+	if projects == nil || projects.Kind != yaml.SequenceNode {
+		return nil
+	}
+	for _, project := range projects.Content {
+		if project.Kind != yaml.MappingNode {
+			continue
+		}
+		detect := mappingNode(project, "detect")
+		if detect == nil || detect.Kind != yaml.SequenceNode {
+			continue
+		}
+		for _, pattern := range detect.Content {
+			if pattern.Kind != yaml.ScalarNode || detectPatternPrefix.MatchString(pattern.Value) {
+				continue
+			}
+			if err := doc.setScalar(pattern, "file:"+pattern.Value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// document is a config file being migrated. Migrations change it through
+// setScalar and insertLine, which edit the source text at the node's
+// position rather than re-encoding the whole file.
+type document struct {
+	root  *yaml.Node
+	lines []string
+	edits []textEdit
+}
+
+// textEdit replaces width characters at a position, or inserts a whole line before it
+type textEdit struct {
+	line, column int
+	width        int
+	text         string
+	insert       bool
+}
+
+// parseDocument parses a config file; root is nil when the file is empty
+func parseDocument(data []byte) (*document, error) {
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return nil, fmt.Errorf("failed to parse config: %w", err)
+	}
+	doc := &document{lines: strings.Split(string(data), "\n")}
+	if len(node.Content) > 0 && node.Content[0].Kind == yaml.MappingNode && len(node.Content[0].Content) > 0 {
+		doc.root = node.Content[0]
+	}
+	return doc, nil
+}
+
+// setScalar changes a scalar's value, keeping its quoting style
+func (d *document) setScalar(node *yaml.Node, value string) error {
+	line := []rune(d.lines[node.Line-1])
+	start := node.Column - 1
+	var width int
+	var text string
+	switch node.Style {
+	case 0:
+		width = len([]rune(node.Value))
+		text = value
+	case yaml.DoubleQuotedStyle:
+		width = quotedWidth(line[start:], '"')
+		text = strconv.Quote(value)
+	case yaml.SingleQuotedStyle:
+		width = quotedWidth(line[start:], '\'')
+		text = "'" + strings.ReplaceAll(value, "'", "''") + "'"
+	default:
+		return fmt.Errorf("line %d: cannot rewrite %q in place", node.Line, node.Value)
+	}
+	if width <= 0 || start+width > len(line) {
+		return fmt.Errorf("line %d: cannot rewrite %q in place", node.Line, node.Value)
+	}
+
+	node.Value = value
+	d.edits = append(d.edits, textEdit{line: node.Line, column: node.Column, width: width, text: text})
+	return nil
+}
+
+// quotedWidth returns the length of the quoted scalar at the start of text, or 0 if it does not end on this line
+func quotedWidth(text []rune, quote rune) int {
+	for i := 1; i < len(text); i++ {
+		switch {
+		case quote == '"' && text[i] == '\\':
+			i++
+		case quote == '\'' && text[i] == '\'' && i+1 < len(text) && text[i+1] == '\'':
+			i++
+		case text[i] == quote:
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// insertLine adds a line before the given 1-based line
+func (d *document) insertLine(line int, text string) {
+	d.edits = append(d.edits, textEdit{line: line, text: text, insert: true})
+}
+
+// render applies the edits to the source text, from the end of the file
+// backwards so earlier positions stay valid. It also returns the lines of
+// the result that were inserted.
+func (d *document) render() ([]byte, []int) {
+	edits := append([]textEdit{}, d.edits...)
+	sort.SliceStable(edits, func(i, j int) bool {
+		if edits[i].line != edits[j].line {
+			return edits[i].line > edits[j].line
+		}
+		if edits[i].insert != edits[j].insert {
+			return !edits[i].insert
+		}
+		return edits[i].column > edits[j].column
+	})
+
+	lines := append([]string{}, d.lines...)
+	for _, edit := range edits {
+		i := edit.line - 1
+		if edit.insert {
+			lines = append(lines[:i], append([]string{edit.text}, lines[i:]...)...)
+			continue
+		}
+		line := []rune(lines[i])
+		start := edit.column - 1
+		lines[i] = string(line[:start]) + edit.text + string(line[start+edit.width:])
+	}
+
+	// Each insertion moves the ones below it down a line
+	var inserted []int
+	for _, edit := range d.edits {
+		if edit.insert {
+			inserted = append(inserted, edit.line)
+		}
+	}
+	sort.Ints(inserted)
+	for i := range inserted {
+		inserted[i] += i
+	}
+	return []byte(strings.Join(lines, "\n")), inserted
+}
+
+// mappingNode returns the value node of a key in a mapping node, or nil
+func mappingNode(mapping *yaml.Node, key string) *yaml.Node {
+	if i := mappingIndex(mapping, key); i >= 0 {
+		return mapping.Content[i+1]
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestMigrate_Examples(t *testing.T) {
+	assert.Equal(t, CurrentVersion, len(migrations)+1, "every version needs a migration from the one before")
+
+	examples, err := filepath.Glob(filepath.Join("..", "..", "examples", "*.yaml"))
+	require.NoError(t, err)
+	require.NotEmpty(t, examples)
+
+	for _, path := range examples {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			data, err := os.ReadFile(path)
+			require.NoError(t, err)
+
+			migration, err := Migrate(data)
+			require.NoError(t, err)
+			assert.Equal(t, 1, migration.From)
+			assert.Equal(t, CurrentVersion, migration.To)
+			assert.Len(t, migration.Applied, CurrentVersion-1)
+
+			// Only changed lines differ, so comments and layout survive
+			before := strings.Split(string(data), "\n")
+			after := strings.Split(string(migration.Data), "\n")
+			require.Len(t, after, len(before))
+			for i := range before {
+				if before[i] != after[i] {
+					assert.Regexp(t, `^(version: |\s+detect: )`, after[i])
+				}
+			}
+
+			var cfg Config
+			require.NoError(t, yaml.Unmarshal(migration.Data, &cfg))
+			assert.NoError(t, cfg.Validate())
+			for _, project := range cfg.Projects {
+				for _, pattern := range project.Detect {
+					assert.Regexp(t, `^[a-z]+:`, pattern)
+				}
+			}
+
+			again, err := Migrate(migration.Data)
+			require.NoError(t, err)
+			assert.Empty(t, again.Applied)
+			assert.Equal(t, migration.Data, again.Data)
+		})
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	t.Run("keeps quoting and explicit kinds", func(t *testing.T) {
+		data := []byte(`version: 1
+projects:
+  - name: api
+    detect:
+      - go.mod
+      - 'it''s.txt'
+      - "docs/*.md"
+      - "git:api"
+    # trailing comment
+`)
+		migration, err := Migrate(data)
+		require.NoError(t, err)
+		assert.Equal(t, `version: 2
+projects:
+  - name: api
+    detect:
+      - file:go.mod
+      - 'file:it''s.txt'
+      - "file:docs/*.md"
+      - "git:api"
+    # trailing comment
+`, string(migration.Data))
+	})
+
+	t.Run("adds a missing version", func(t *testing.T) {
+		migration, err := Migrate([]byte("# settings\nsettings:\n  work_hours: 8\n"))
+		require.NoError(t, err)
+		assert.Equal(t, 1, migration.From)
+		assert.Equal(t, "# settings\nversion: 2\nsettings:\n  work_hours: 8\n", string(migration.Data))
+	})
+
+	t.Run("leaves empty files alone", func(t *testing.T) {
+		migration, err := Migrate([]byte("# nothing yet\n"))
+		require.NoError(t, err)
+		assert.Empty(t, migration.Applied)
+		assert.Equal(t, "# nothing yet\n", string(migration.Data))
+	})
+
+	t.Run("rejects newer and invalid versions", func(t *testing.T) {
+		_, err := Migrate([]byte("version: 99\n"))
+		assert.ErrorContains(t, err, "config version 99 is newer than this version of rune supports")
+		_, err = Migrate([]byte("version: one\n"))
+		assert.ErrorContains(t, err, `invalid config version "one"`)
+	})
+}
+
+func TestMigrateFile(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	path := filepath.Join(dir, "config.yaml")
+	original := "version: 1\nprojects:\n  - name: api\n    detect: [go.mod]\n"
+	writeFile(t, path, original)
+
+	migration, backup, err := MigrateFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, path+".v1.bak", backup)
+	assert.Equal(t, 2, migration.To)
+
+	data, err := os.ReadFile(backup)
+	require.NoError(t, err)
+	assert.Equal(t, original, string(data))
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "version: 2\nprojects:\n  - name: api\n    detect: [file:go.mod]\n", string(data))
+
+	// An up to date file is not touched
+	_, backup, err = MigrateFile(path)
+	require.NoError(t, err)
+	assert.Empty(t, backup)
+}
+
+func TestUnifiedDiff(t *testing.T) {
+	assert.Empty(t, UnifiedDiff("a", "b", []byte("same\n"), []byte("same\n")))
+
+	from := []byte("one\ntwo\nthree\nfour\nfive\nsix\nseven\neight\nnine\nten\n")
+	to := []byte("one\n2\nthree\nfour\nfive\nsix\nseven\neight\nnine\nten\neleven\n")
+	assert.Equal(t, `--- a
++++ b
+@@ -1,5 +1,5 @@
+ one
+-two
++2
+ three
+ four
+ five
+@@ -8,3 +8,4 @@
+ eight
+ nine
+ ten
++eleven
+`, UnifiedDiff("a", "b", from, to))
+}
+
+func TestMigration_SourceLine(t *testing.T) {
+	migration, err := Migrate([]byte("# header\nsettings:\n  work_hours: 8\n"))
+	require.NoError(t, err)
+	require.Equal(t, "# header\nversion: 2\nsettings:\n  work_hours: 8\n", string(migration.Data))
+	assert.Equal(t, 1, migration.SourceLine(1))
+	assert.Equal(t, 2, migration.SourceLine(2), "the added version line maps to the line after it")
+	assert.Equal(t, 2, migration.SourceLine(3))
+	assert.Equal(t, 3, migration.SourceLine(4))
+}
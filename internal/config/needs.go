@@ -0,0 +1,75 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// commandID matches valid ritual command ids
+var commandID = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// CheckNeeds checks that the ids of commands run together are unique and
+// that their needs refer to known ids without forming a cycle
+func CheckNeeds(commands []Command) error {
+	index := make(map[string]int, len(commands))
+	for i, cmd := range commands {
+		if cmd.ID == "" {
+			continue
+		}
+		if !commandID.MatchString(cmd.ID) {
+			return fmt.Errorf("command %q has invalid id %q (use letters, digits, '.', '_' and '-')", cmd.Name, cmd.ID)
+		}
+		if _, duplicate := index[cmd.ID]; duplicate {
+			return fmt.Errorf("duplicate command id %q", cmd.ID)
+		}
+		index[cmd.ID] = i
+	}
+
+	for _, cmd := range commands {
+		for _, need := range cmd.Needs {
+			if _, ok := index[need]; !ok {
+				return fmt.Errorf("command %q needs unknown id %q", cmd.Name, need)
+			}
+		}
+	}
+
+	// Depth-first search, tracking the ids on the current path to report a cycle
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make([]int, len(commands))
+	var path []string
+	var visit func(i int) error
+	visit = func(i int) error {
+		switch state[i] {
+		case visiting:
+			for start, id := range path {
+				if id == commands[i].ID {
+					return fmt.Errorf("dependency cycle: %s", strings.Join(append(path[start:], id), " -> "))
+				}
+			}
+		case visited:
+			return nil
+		}
+
+		state[i] = visiting
+		path = append(path, commands[i].ID)
+		for _, need := range commands[i].Needs {
+			if err := visit(index[need]); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		state[i] = visited
+		return nil
+	}
+	for i := range commands {
+		if err := visit(i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
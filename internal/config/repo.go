@@ -0,0 +1,247 @@
+package config
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RepoConfigFile is the repository-local config file read from the git root
+const RepoConfigFile = ".rune.yaml"
+
+// RepoConfig is a repository's own .rune.yaml. It names the project, tags
+// its sessions and adds start and stop commands for it on top of the
+// user's config. None of it is used until the user trusts the file.
+type RepoConfig struct {
+	Project string      `yaml:"project"`
+	Tags    []string    `yaml:"tags"`
+	Rituals RepoRituals `yaml:"rituals"`
+
+	// Path is the file the config was read from
+	Path string `yaml:"-"`
+	// Hash identifies the file's path and content; trust is remembered per hash
+	Hash string `yaml:"-"`
+}
+
+// RepoRituals are the commands a repository adds to the start and stop rituals
+type RepoRituals struct {
+	Start []Command `yaml:"start"`
+	Stop  []Command `yaml:"stop"`
+}
+
+// repoName matches the project names and tags a repository may declare.
+// They reach ritual commands and reports before the file is trusted, so
+// anything a shell could interpret is refused.
+var repoName = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+// LoadRepoConfig reads and validates a repository's .rune.yaml
+func LoadRepoConfig(path string) (*RepoConfig, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", path, err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", displayPath(path), err)
+	}
+
+	repo := RepoConfig{Path: path, Hash: repoHash(path, data)}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&repo); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to parse %s: %w", displayPath(path), err)
+	}
+	repo.Project = strings.TrimSpace(repo.Project)
+
+	// Problems are located in the file by its line numbers
+	resolved := &Resolved{Document: &yaml.Node{}}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err == nil && len(doc.Content) > 0 {
+		resolved.Document = doc.Content[0]
+		resolved.recordFile(resolved.Document, path)
+	}
+
+	sample := TemplateData{Project: "project", SessionID: "session", StartTime: time.Now(), Today: time.Now().Format("2006-01-02")}
+	v := &validator{}
+	repo.validate(sample, v)
+	if err := v.err(resolved); err != nil {
+		return nil, err
+	}
+	return &repo, nil
+}
+
+// validate checks the repository's project name, tags and ritual commands
+func (r *RepoConfig) validate(sample TemplateData, v *validator) {
+	if r.Project != "" && !repoName.MatchString(r.Project) {
+		v.addf("project", "project: invalid name %q (use letters, digits, '.', '_' and '-')", r.Project)
+	}
+	for i, tag := range r.Tags {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "#")
+		switch {
+		case tag == "":
+			v.addf(fmt.Sprintf("tags[%d]", i), "tags[%d]: tag cannot be empty", i)
+		case !repoName.MatchString(tag):
+			v.addf(fmt.Sprintf("tags[%d]", i), "tags[%d]: invalid tag %q (use letters, digits, '.', '_' and '-')", i, tag)
+		}
+	}
+	rituals := []struct {
+		name     string
+		commands []Command
+	}{
+		{"start", r.Rituals.Start},
+		{"stop", r.Rituals.Stop},
+	}
+	for _, ritual := range rituals {
+		for i, cmd := range ritual.commands {
+			cmd.validate(fmt.Sprintf("rituals.%s[%d]", ritual.name, i), sample, v)
+		}
+	}
+}
+
+// Root returns the repository directory holding the file
+func (r *RepoConfig) Root() string {
+	return filepath.Dir(r.Path)
+}
+
+// Empty reports whether the file declares nothing, so there is nothing to trust
+func (r *RepoConfig) Empty() bool {
+	return r.Project == "" && len(r.Tags) == 0 && !r.HasRituals()
+}
+
+// HasRituals reports whether the file adds any commands
+func (r *RepoConfig) HasRituals() bool {
+	return len(r.Rituals.Start) > 0 || len(r.Rituals.Stop) > 0
+}
+
+// Apply adds the repository's commands to the project's start and stop
+// rituals. Commands run from the repository root unless they set a dir.
+func (r *RepoConfig) Apply(cfg *Config, project string) error {
+	sets := []struct {
+		name     string
+		set      *RitualSet
+		commands []Command
+	}{
+		{"start", &cfg.Rituals.Start, r.Rituals.Start},
+		{"stop", &cfg.Rituals.Stop, r.Rituals.Stop},
+	}
+	for _, s := range sets {
+		if len(s.commands) == 0 {
+			continue
+		}
+		perProject := make(map[string][]Command, len(s.set.PerProject)+1)
+		for name, commands := range s.set.PerProject {
+			perProject[name] = commands
+		}
+		commands := append([]Command{}, perProject[project]...)
+		for _, cmd := range s.commands {
+			switch {
+			case cmd.Dir == "":
+				cmd.Dir = r.Root()
+			case !filepath.IsAbs(cmd.Dir) && !strings.HasPrefix(cmd.Dir, "~") && !strings.Contains(cmd.Dir, "{{"):
+				cmd.Dir = filepath.Join(r.Root(), cmd.Dir)
+			}
+			commands = append(commands, cmd)
+		}
+		perProject[project] = commands
+		s.set.PerProject = perProject
+
+		// Repository commands share a plan with the user's and may need them
+		if err := CheckNeeds(append(append([]Command{}, s.set.Global...), commands...)); err != nil {
+			return fmt.Errorf("%s: rituals.%s: %w", displayPath(r.Path), s.name, err)
+		}
+	}
+	return nil
+}
+
+// Trusted reports whether the user has trusted this version of the file
+func (r *RepoConfig) Trusted() (bool, error) {
+	path, err := trustPath(r.Hash)
+	if err != nil {
+		return false, err
+	}
+	if _, err := os.Stat(path); err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to check trust: %w", err)
+	}
+	return true, nil
+}
+
+// Trust remembers that the user trusts this version of the file. Any
+// change to the file needs to be trusted again.
+func (r *RepoConfig) Trust() error {
+	path, err := trustPath(r.Hash)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("failed to create trust directory: %w", err)
+	}
+	if err := os.WriteFile(path, []byte(r.Path+"\n"), 0600); err != nil {
+		return fmt.Errorf("failed to save trust: %w", err)
+	}
+	return nil
+}
+
+// Untrust forgets every trusted version of the file at path and reports how many there were
+func Untrust(path string) (int, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to resolve %s: %w", path, err)
+	}
+	dir, err := trustPath("")
+	if err != nil {
+		return 0, err
+	}
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read trust directory: %w", err)
+	}
+
+	removed := 0
+	for _, entry := range entries {
+		file := filepath.Join(dir, entry.Name())
+		data, err := os.ReadFile(file)
+		if err != nil || strings.TrimSpace(string(data)) != path {
+			continue
+		}
+		if err := os.Remove(file); err != nil {
+			return removed, fmt.Errorf("failed to remove trust: %w", err)
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// trustPath returns the file under ~/.rune/trust that records trust in a hash
+func trustPath(hash string) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(home, ".rune", "trust", hash), nil
+}
+
+// repoHash identifies a file by its location and content, so trusting one
+// repository's file does not trust a copy of it elsewhere
+func repoHash(path string, data []byte) string {
+	sum := sha256.New()
+	sum.Write([]byte(path))
+	sum.Write([]byte{0})
+	sum.Write(data)
+	return hex.EncodeToString(sum.Sum(nil))
+}
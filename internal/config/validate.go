@@ -0,0 +1,271 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Issue is one problem found in the config. Path names the offending value,
+// such as "rituals.start.global[0]"; File, Line and Column locate it in the
+// config files when it was read from one.
+type Issue struct {
+	Path    string
+	Message string
+	File    string
+	Line    int
+	Column  int
+}
+
+// String formats the issue with its location, like "config.yaml:12:7: message"
+func (i Issue) String() string {
+	switch {
+	case i.File != "" && i.Line > 0:
+		return fmt.Sprintf("%s:%d:%d: %s", displayPath(i.File), i.Line, i.Column, i.Message)
+	case i.Line > 0:
+		return fmt.Sprintf("line %d:%d: %s", i.Line, i.Column, i.Message)
+	default:
+		return i.Message
+	}
+}
+
+// ValidationError lists every problem found in the config
+type ValidationError struct {
+	Issues []Issue
+}
+
+// Error lists the issues, one per line when there is more than one
+func (e *ValidationError) Error() string {
+	if len(e.Issues) == 1 {
+		return e.Issues[0].String()
+	}
+	lines := make([]string, len(e.Issues))
+	for i, issue := range e.Issues {
+		lines[i] = "  " + issue.String()
+	}
+	return fmt.Sprintf("%d problems:\n%s", len(e.Issues), strings.Join(lines, "\n"))
+}
+
+// validator collects issues instead of stopping at the first one. Warnings
+// are problems that do not stop the config from loading.
+type validator struct {
+	issues   []Issue
+	warnings []Issue
+}
+
+// addf records a problem with the value at path
+func (v *validator) addf(path, format string, args ...interface{}) {
+	v.issues = append(v.issues, Issue{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// add records an error about the value at path, prefixed with the path
+func (v *validator) add(path string, err error) {
+	v.addf(path, "%s: %v", path, err)
+}
+
+// warnf records a warning about the value at path
+func (v *validator) warnf(path, format string, args ...interface{}) {
+	v.warnings = append(v.warnings, Issue{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// err returns the collected issues, located in the resolved config when
+// there is one, or nil if there were none
+func (v *validator) err(resolved *Resolved) error {
+	if len(v.issues) == 0 {
+		return nil
+	}
+	return &ValidationError{Issues: locate(v.issues, resolved)}
+}
+
+// locate fills in where each issue is in the resolved config, when there is
+// one, and sorts them in file order
+func locate(issues []Issue, resolved *Resolved) []Issue {
+	if len(issues) == 0 {
+		return nil
+	}
+	issues = append([]Issue{}, issues...)
+	if resolved != nil {
+		for i := range issues {
+			if issues[i].Line == 0 {
+				issues[i].File, issues[i].Line, issues[i].Column = resolved.position(issues[i].Path)
+			}
+		}
+	}
+
+	// Issues are listed in file order; those that could not be located come last
+	sort.SliceStable(issues, func(i, j int) bool {
+		a, b := issues[i], issues[j]
+		if (a.Line == 0) != (b.Line == 0) {
+			return b.Line == 0
+		}
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	return issues
+}
+
+// position finds the value at a path such as "rituals.start.global[0]" in
+// the resolved document and returns the file and line it came from. A path
+// that cannot be followed all the way is located at its deepest known part.
+func (r *Resolved) position(path string) (string, int, int) {
+	node := r.Document
+	located := node
+	for node != nil && path != "" {
+		switch {
+		case strings.HasPrefix(path, "["):
+			end := strings.Index(path, "]")
+			index, err := strconv.Atoi(path[1:max(end, 1)])
+			if end < 0 || err != nil || node.Kind != yaml.SequenceNode || index >= len(node.Content) {
+				node = nil
+				break
+			}
+			node = node.Content[index]
+			located = node
+			path = path[end+1:]
+		case node.Kind == yaml.MappingNode:
+			path = strings.TrimPrefix(path, ".")
+			// Keys such as project names may contain dots, so take the longest key that fits
+			var key, value *yaml.Node
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				k := node.Content[i]
+				rest := strings.TrimPrefix(path, k.Value)
+				if len(rest) < len(path) && (rest == "" || rest[0] == '.' || rest[0] == '[') && (key == nil || len(k.Value) > len(key.Value)) {
+					key, value = k, node.Content[i+1]
+				}
+			}
+			if key == nil {
+				node = nil
+				break
+			}
+			path = path[len(key.Value):]
+			located = key
+			if path != "" {
+				located = value
+			}
+			node = value
+		default:
+			node = nil
+		}
+	}
+	if located == nil || located.Line == 0 {
+		return "", 0, 0
+	}
+	return r.files[located], located.Line, located.Column
+}
+
+// recordFile remembers which file each node of a document was read from
+func (r *Resolved) recordFile(node *yaml.Node, file string) {
+	if r.files == nil {
+		r.files = make(map[*yaml.Node]string)
+	}
+	r.files[node] = file
+	for _, child := range node.Content {
+		r.recordFile(child, file)
+	}
+}
+
+// checkKeys records an issue for every key in a file that the config schema does not know
+func (r *Resolved) checkKeys(root *yaml.Node, file string) {
+	walkUnknownKeys(root, configSchema(), "", func(key *yaml.Node, path string, known []string) {
+		message := fmt.Sprintf("unknown key %q", key.Value)
+		if path != "" {
+			message = fmt.Sprintf("%s: unknown key %q", path, key.Value)
+		}
+		if suggestion := closestKey(key.Value, known); suggestion != "" {
+			message += fmt.Sprintf(" (did you mean %q?)", suggestion)
+		}
+		r.unknown = append(r.unknown, Issue{Path: path, Message: message, File: file, Line: key.Line, Column: key.Column})
+	})
+}
+
+// walkUnknownKeys calls found for each mapping key that the schema does not allow
+func walkUnknownKeys(node *yaml.Node, schema map[string]interface{}, path string, found func(key *yaml.Node, path string, known []string)) {
+	if branches, ok := schema["oneOf"].([]interface{}); ok {
+		want := map[yaml.Kind]string{yaml.MappingNode: "object", yaml.SequenceNode: "array"}[node.Kind]
+		for _, branch := range branches {
+			if branch := branch.(map[string]interface{}); branch["type"] == want {
+				walkUnknownKeys(node, branch, path, found)
+			}
+		}
+		return
+	}
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		properties, _ := schema["properties"].(map[string]interface{})
+		known := make([]string, 0, len(properties))
+		for name := range properties {
+			known = append(known, name)
+		}
+		sort.Strings(known)
+
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if key.Value == "<<" {
+				continue
+			}
+			child := key.Value
+			if path != "" {
+				child = path + "." + key.Value
+			}
+			if property, ok := properties[key.Value].(map[string]interface{}); ok {
+				walkUnknownKeys(value, property, child, found)
+				continue
+			}
+			switch additional := schema["additionalProperties"].(type) {
+			case map[string]interface{}:
+				walkUnknownKeys(value, additional, child, found)
+			case bool:
+				if !additional && properties != nil {
+					found(key, path, known)
+				}
+			}
+		}
+	case yaml.SequenceNode:
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range node.Content {
+				walkUnknownKeys(item, items, fmt.Sprintf("%s[%d]", path, i), found)
+			}
+		}
+	}
+}
+
+// closestKey returns the known key within two edits of key, if there is one
+func closestKey(key string, known []string) string {
+	best, bestDistance := "", 3
+	for _, candidate := range known {
+		if d := editDistance(key, candidate); d < bestDistance {
+			best, bestDistance = candidate, d
+		}
+	}
+	return best
+}
+
+// editDistance is the Levenshtein distance between two strings
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current := make([]int, len(b)+1)
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous = current
+	}
+	return previous[len(b)]
+}
@@ -0,0 +1,153 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// loadFile resolves and validates a config file the way Load does, without viper
+func loadFile(t *testing.T, path string) (*Config, error) {
+	t.Helper()
+	resolved, err := Resolve(path)
+	require.NoError(t, err)
+	var cfg Config
+	require.NoError(t, resolved.decode(&cfg))
+	return &cfg, cfg.Validate()
+}
+
+func TestValidate_CollectsLocatedIssues(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	path := filepath.Join(dir, "config.yaml")
+	writeFile(t, path, `version: 2
+settings:
+  work_hours: 30
+  break_intervall: 50m
+  break_interval: 50m
+  idle_threshold: 10m
+projects:
+  - name: api
+    detect: [file:go.mod]
+rituals:
+  start:
+    global:
+      - name: Pull
+        comand: git pull
+    per_project:
+      web:
+        - name: Serve
+          command: make serve
+`)
+
+	cfg, err := loadFile(t, path)
+	var validation *ValidationError
+	require.True(t, errors.As(err, &validation))
+
+	var got []string
+	for _, issue := range validation.Issues {
+		got = append(got, issue.String())
+	}
+	assert.Equal(t, []string{
+		`~/config.yaml:3:3: work_hours must be between 0 and 24, got: 30.000000`,
+		`~/config.yaml:13:9: rituals.start.global[0]: command "Pull" is empty`,
+	}, got)
+	assert.Contains(t, err.Error(), "2 problems:")
+
+	// Unknown keys and undeclared projects are warnings that do not stop the config loading
+	got = nil
+	for _, issue := range cfg.Warnings() {
+		got = append(got, issue.String())
+	}
+	assert.Equal(t, []string{
+		`~/config.yaml:4:3: settings: unknown key "break_intervall" (did you mean "break_interval"?)`,
+		`~/config.yaml:14:9: rituals.start.global[0]: unknown key "comand" (did you mean "command"?)`,
+		`~/config.yaml:16:7: rituals.start.per_project.web: project "web" is not declared under projects`,
+	}, got)
+}
+
+func TestValidate_WarningsDoNotStopLoading(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	path := filepath.Join(dir, "config.yaml")
+	writeFile(t, path, `version: 2
+settings:
+  work_hours: 8
+  break_interval: 50m
+  break_intervall: 50m
+  idle_threshold: 10m
+`)
+
+	cfg, err := loadFile(t, path)
+	require.NoError(t, err)
+	require.Len(t, cfg.Warnings(), 1)
+	assert.Equal(t, 5, cfg.Warnings()[0].Line)
+}
+
+func TestValidate_LocatesIncludedFiles(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	writeFile(t, filepath.Join(dir, "team.yaml"), `projects:
+  - name: docs.site
+    detect: [file:mkdocs.yml]
+rituals:
+  stop:
+    per_project:
+      docs.site:
+        - name: Build
+          command: mkdocs build
+          shell: fish
+`)
+	path := filepath.Join(dir, "config.yaml")
+	writeFile(t, path, `version: 2
+include: [team.yaml]
+settings:
+  work_hours: 8
+  break_interval: 50m
+  idle_threshold: 10m
+`)
+
+	_, err := loadFile(t, path)
+	var validation *ValidationError
+	require.True(t, errors.As(err, &validation))
+	require.Len(t, validation.Issues, 1)
+	issue := validation.Issues[0]
+	assert.Equal(t, filepath.Join(dir, "team.yaml"), issue.File)
+	assert.Equal(t, 8, issue.Line)
+	assert.Contains(t, issue.Message, `rituals.stop.per_project.docs.site[0]: command "Build" has unknown shell "fish"`)
+}
+
+func TestValidate_ExamplesHaveNoUnknownKeys(t *testing.T) {
+	examples, err := filepath.Glob(filepath.Join("..", "..", "examples", "*.yaml"))
+	require.NoError(t, err)
+	for _, path := range examples {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			resolved, err := Resolve(path)
+			require.NoError(t, err)
+			assert.Empty(t, resolved.unknown)
+		})
+	}
+}
+
+func TestSchema(t *testing.T) {
+	data, err := Schema()
+	require.NoError(t, err)
+
+	var schema map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &schema))
+	assert.Equal(t, false, schema["additionalProperties"])
+
+	settings := schema["properties"].(map[string]interface{})["settings"].(map[string]interface{})
+	breakInterval := settings["properties"].(map[string]interface{})["break_interval"].(map[string]interface{})
+	assert.Equal(t, "string", breakInterval["type"])
+	assert.Regexp(t, breakInterval["pattern"].(string), "1h30m")
+
+	command := schemaFor(commandType)["properties"].(map[string]interface{})
+	assert.Equal(t, []interface{}{"sh", "bash", "zsh", "pwsh", "none"}, command["shell"].(map[string]interface{})["enum"])
+	assert.Contains(t, command, "needs")
+	assert.NotContains(t, schemaFor(commandType)["properties"], "ID", "fields are named as YAML spells them")
+}
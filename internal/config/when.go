@@ -0,0 +1,150 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/ferg-cod3s/rune/internal/calendar"
+	"gopkg.in/yaml.v3"
+)
+
+// When restricts when a ritual command runs. Every condition that is set
+// must hold; a list condition holds when any of its entries matches.
+type When struct {
+	// Days are weekday names such as "mon" or "friday", or "weekdays" and "weekends"
+	Days StringList `yaml:"days" mapstructure:"days"`
+	// Time is a window such as "09:00-12:00"; a window ending before it starts wraps past midnight
+	Time string `yaml:"time" mapstructure:"time"`
+	// Hosts are hostname globs
+	Hosts StringList `yaml:"hosts" mapstructure:"hosts"`
+	// OS are operating systems as Go names them: darwin, linux, windows
+	OS StringList `yaml:"os" mapstructure:"os"`
+	// Branch are globs for the git branch checked out in the command's directory
+	Branch StringList `yaml:"branch" mapstructure:"branch"`
+	// File are paths or globs, relative to the command's directory, that must exist
+	File StringList `yaml:"file" mapstructure:"file"`
+	// Env are "VAR" (set and non-empty) or "VAR=glob" conditions on the environment
+	Env StringList `yaml:"env" mapstructure:"env"`
+	// Check is a command run like the step itself; a non-zero exit status skips the step
+	Check string `yaml:"check" mapstructure:"check"`
+}
+
+// StringList is a list of strings that may also be written as a single string
+type StringList []string
+
+// UnmarshalYAML accepts a single string as a one-element list
+func (l *StringList) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*l = StringList{node.Value}
+		return nil
+	}
+	var list []string
+	if err := node.Decode(&list); err != nil {
+		return err
+	}
+	*l = list
+	return nil
+}
+
+// IsZero reports whether no condition is set
+func (w When) IsZero() bool {
+	return len(w.Days) == 0 && w.Time == "" && len(w.Hosts) == 0 && len(w.OS) == 0 &&
+		len(w.Branch) == 0 && len(w.File) == 0 && len(w.Env) == 0 && w.Check == ""
+}
+
+// TimeWindow is a daily window between two clock times
+type TimeWindow struct {
+	Start time.Duration
+	End   time.Duration
+}
+
+// Contains reports whether the clock time of t falls within the window
+func (w TimeWindow) Contains(t time.Time) bool {
+	clock := time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute
+	if w.End <= w.Start {
+		return clock >= w.Start || clock < w.End
+	}
+	return clock >= w.Start && clock < w.End
+}
+
+// ParseTimeWindow parses a window such as "09:00-17:00"
+func ParseTimeWindow(window string) (TimeWindow, error) {
+	from, to, ok := strings.Cut(window, "-")
+	if !ok {
+		return TimeWindow{}, fmt.Errorf("invalid time window %q (use HH:MM-HH:MM)", window)
+	}
+	start, err := time.Parse("15:04", strings.TrimSpace(from))
+	if err != nil {
+		return TimeWindow{}, fmt.Errorf("invalid time window %q (use HH:MM-HH:MM)", window)
+	}
+	end, err := time.Parse("15:04", strings.TrimSpace(to))
+	if err != nil {
+		return TimeWindow{}, fmt.Errorf("invalid time window %q (use HH:MM-HH:MM)", window)
+	}
+	if start.Equal(end) {
+		return TimeWindow{}, fmt.Errorf("time window %q is empty", window)
+	}
+	return TimeWindow{
+		Start: time.Duration(start.Hour())*time.Hour + time.Duration(start.Minute())*time.Minute,
+		End:   time.Duration(end.Hour())*time.Hour + time.Duration(end.Minute())*time.Minute,
+	}, nil
+}
+
+// ParseDays parses weekday names, expanding "weekdays" and "weekends"
+func ParseDays(days []string) ([]time.Weekday, error) {
+	var weekdays []time.Weekday
+	for _, day := range days {
+		switch strings.ToLower(strings.TrimSpace(day)) {
+		case "weekdays":
+			weekdays = append(weekdays, time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday)
+		case "weekends":
+			weekdays = append(weekdays, time.Saturday, time.Sunday)
+		default:
+			weekday, err := calendar.ParseWeekday(day)
+			if err != nil {
+				return nil, fmt.Errorf("invalid day %q (expected a weekday, weekdays or weekends)", day)
+			}
+			weekdays = append(weekdays, weekday)
+		}
+	}
+	return weekdays, nil
+}
+
+// validate checks the syntax of every condition
+func (w When) validate() error {
+	if _, err := ParseDays(w.Days); err != nil {
+		return fmt.Errorf("when.days: %w", err)
+	}
+	if w.Time != "" {
+		if _, err := ParseTimeWindow(w.Time); err != nil {
+			return fmt.Errorf("when.time: %w", err)
+		}
+	}
+	for _, field := range []struct {
+		name  string
+		globs StringList
+	}{{"hosts", w.Hosts}, {"branch", w.Branch}, {"file", w.File}} {
+		for _, glob := range field.globs {
+			if _, err := path.Match(glob, ""); err != nil || strings.TrimSpace(glob) == "" {
+				return fmt.Errorf("when.%s: invalid pattern %q", field.name, glob)
+			}
+		}
+	}
+	for _, goos := range w.OS {
+		if strings.TrimSpace(goos) == "" {
+			return fmt.Errorf("when.os: operating system cannot be empty")
+		}
+	}
+	for _, condition := range w.Env {
+		name, glob, hasGlob := strings.Cut(condition, "=")
+		if !envName.MatchString(name) {
+			return fmt.Errorf("when.env: invalid environment variable name in %q", condition)
+		}
+		if _, err := path.Match(glob, ""); hasGlob && err != nil {
+			return fmt.Errorf("when.env: invalid pattern in %q", condition)
+		}
+	}
+	return nil
+}
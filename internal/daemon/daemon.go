@@ -0,0 +1,355 @@
+package daemon
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/rpc"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ferg-cod3s/rune/internal/calendar"
+	"github.com/ferg-cod3s/rune/internal/config"
+	"github.com/ferg-cod3s/rune/internal/notifications"
+	"github.com/ferg-cod3s/rune/internal/rituals"
+	"github.com/ferg-cod3s/rune/internal/tracking"
+)
+
+// serviceName is the name the tracker service is registered under on the RPC server
+const serviceName = "Rune"
+
+// Server is the long-running rune daemon. It owns the session database and
+// runs idle detection, break reminders and end-of-day reminders.
+type Server struct {
+	tracker  *tracking.Tracker
+	config   *config.Config
+	notifier *notifications.NotificationManager
+	calendar calendar.Calendar
+	listener net.Listener
+	started  time.Time
+
+	// tick is how often reminders are checked
+	tick time.Duration
+
+	mu                sync.Mutex
+	lastBreakReminder time.Time
+	lastEndOfDay      string
+
+	// active counts connections and idle pauses still using the tracker;
+	// Shutdown waits for them before closing it. connMu guards the
+	// listener, conns and closing.
+	active   sync.WaitGroup
+	connMu   sync.Mutex
+	conns    map[net.Conn]bool
+	closing  bool
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// Status describes a running daemon
+type Status struct {
+	PID        int
+	StartedAt  time.Time
+	SocketPath string
+}
+
+// NewServer creates a daemon server using the given configuration. A nil
+// configuration falls back to tracker defaults with notifications disabled.
+func NewServer(cfg *config.Config) (*Server, error) {
+	var tracker *tracking.Tracker
+	var err error
+	if cfg != nil {
+		tracker, err = tracking.NewTrackerWithIdleThreshold(cfg.Settings.IdleThreshold)
+	} else {
+		tracker, err = tracking.NewTracker()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize tracker: %w", err)
+	}
+
+	var notificationsEnabled bool
+	cal := calendar.Default()
+	if cfg != nil {
+		notificationsEnabled = cfg.Settings.Notifications.Enabled
+		if cal, err = cfg.Calendar(); err != nil {
+			tracker.Close()
+			return nil, err
+		}
+	}
+	tracker.SetCalendar(cal)
+
+	return &Server{
+		tracker:  tracker,
+		config:   cfg,
+		calendar: cal,
+		notifier: notifications.NewNotificationManager(notificationsEnabled),
+		tick:     time.Minute,
+		conns:    make(map[net.Conn]bool),
+		done:     make(chan struct{}),
+	}, nil
+}
+
+// Serve listens on the given Unix socket and serves requests until Shutdown is called
+func (s *Server) Serve(socketPath string) error {
+	if err := removeStaleSocket(socketPath); err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", socketPath, err)
+	}
+	s.connMu.Lock()
+	s.listener = listener
+	s.started = time.Now()
+	closing := s.closing
+	s.connMu.Unlock()
+	if closing {
+		listener.Close()
+		return nil
+	}
+
+	server := rpc.NewServer()
+	if err := server.RegisterName(serviceName, &Service{server: s}); err != nil {
+		listener.Close()
+		return fmt.Errorf("failed to register daemon service: %w", err)
+	}
+
+	if err := s.tracker.StartIdleMonitoring(s.idlePaused); err != nil {
+		fmt.Printf("Warning: Failed to start idle monitoring: %v\n", err)
+	}
+	go s.runReminders()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			select {
+			case <-s.done:
+				return nil
+			default:
+			}
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return fmt.Errorf("failed to accept connection: %w", err)
+		}
+		if !s.track(conn) {
+			conn.Close()
+			return nil
+		}
+		go func() {
+			defer s.untrack(conn)
+			// ServeConn returns once the connection closes and its calls finish
+			server.ServeConn(conn)
+		}()
+	}
+}
+
+// Shutdown stops the daemon and releases the session database once the
+// requests and idle pauses in flight have finished
+func (s *Server) Shutdown() {
+	s.stopOnce.Do(func() {
+		s.connMu.Lock()
+		s.closing = true
+		listener := s.listener
+		conns := make([]net.Conn, 0, len(s.conns))
+		for conn := range s.conns {
+			conns = append(conns, conn)
+		}
+		s.connMu.Unlock()
+
+		close(s.done)
+		if listener != nil {
+			listener.Close()
+		}
+		s.tracker.StopIdleMonitoring()
+		for _, conn := range conns {
+			conn.Close()
+		}
+		s.active.Wait()
+		s.tracker.Close()
+	})
+}
+
+// track registers work that needs the tracker, and the connection serving
+// it if any, reporting false once the daemon is shutting down
+func (s *Server) track(conn net.Conn) bool {
+	s.connMu.Lock()
+	defer s.connMu.Unlock()
+	if s.closing {
+		return false
+	}
+	s.active.Add(1)
+	if conn != nil {
+		s.conns[conn] = true
+	}
+	return true
+}
+
+// untrack marks work registered by track as finished
+func (s *Server) untrack(conn net.Conn) {
+	if conn != nil {
+		s.connMu.Lock()
+		delete(s.conns, conn)
+		s.connMu.Unlock()
+	}
+	s.active.Done()
+}
+
+// idlePaused runs the pause hook for a session the daemon paused because
+// the user went idle, as rune pause does for a manual pause
+func (s *Server) idlePaused(session *tracking.Session) {
+	if s.config == nil || !s.track(nil) {
+		return
+	}
+	defer s.untrack(nil)
+	engine := rituals.NewEngine(s.config)
+	engine.RecordRuns(s.tracker)
+	if err := engine.ExecuteHook(rituals.PauseHook(s.config.Rituals.Hooks, tracking.PauseIdle), session); err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+}
+
+// runReminders periodically checks whether break or end-of-day reminders are due
+func (s *Server) runReminders() {
+	ticker := time.NewTicker(s.tick)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case now := <-ticker.C:
+			s.checkReminders(now)
+		}
+	}
+}
+
+// checkReminders sends any reminders that are due at the given time
+func (s *Server) checkReminders(now time.Time) {
+	if s.config == nil {
+		return
+	}
+	settings := s.config.Settings
+
+	session, err := s.tracker.GetCurrentSession()
+	if err != nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Break reminders count continuous running time; any pause resets the clock
+	if session != nil && session.State == tracking.StateRunning {
+		workingSince := session.CurrentWorkStart()
+		if s.lastBreakReminder.After(workingSince) {
+			workingSince = s.lastBreakReminder
+		}
+		worked := now.Sub(workingSince)
+		if settings.Notifications.BreakReminders && settings.BreakInterval > 0 && worked >= settings.BreakInterval {
+			if err := s.notifier.SendBreakReminder(worked); err != nil {
+				fmt.Printf("Warning: Failed to send break reminder: %v\n", err)
+			}
+			s.lastBreakReminder = now
+		}
+	}
+
+	// End-of-day reminders fire once per day when the work hours target is reached
+	today := s.calendar.Date(now).Format("2006-01-02")
+	if !settings.Notifications.EndOfDayReminders || s.lastEndOfDay == today {
+		return
+	}
+	total, err := s.tracker.GetDailyTotal()
+	if err != nil {
+		return
+	}
+	if session != nil && session.State != tracking.StateStopped {
+		if current, err := s.tracker.GetSessionDuration(); err == nil {
+			total += current
+		}
+	}
+	if total.Hours() >= settings.WorkHours {
+		if err := s.notifier.SendEndOfDayReminder(total, settings.WorkHours); err != nil {
+			fmt.Printf("Warning: Failed to send end-of-day reminder: %v\n", err)
+		}
+		s.lastEndOfDay = today
+	}
+}
+
+// runeDir returns the ~/.rune directory, creating it if necessary
+func runeDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+
+	dir := filepath.Join(home, ".rune")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create .rune directory: %w", err)
+	}
+	return dir, nil
+}
+
+// SocketPath returns the path of the daemon's Unix socket
+func SocketPath() (string, error) {
+	dir, err := runeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "daemon.sock"), nil
+}
+
+// PIDPath returns the path of the daemon's PID file
+func PIDPath() (string, error) {
+	dir, err := runeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "daemon.pid"), nil
+}
+
+// LogPath returns the path of the daemon's log file
+func LogPath() (string, error) {
+	dir, err := runeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "daemon.log"), nil
+}
+
+// WritePIDFile records the current process ID in the daemon PID file
+func WritePIDFile() error {
+	pidPath, err := PIDPath()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(pidPath, []byte(strconv.Itoa(os.Getpid())+"\n"), 0644)
+}
+
+// RemovePIDFile removes the daemon PID file
+func RemovePIDFile() {
+	if pidPath, err := PIDPath(); err == nil {
+		_ = os.Remove(pidPath)
+	}
+}
+
+// removeStaleSocket removes a socket file left behind by a daemon that is no longer running
+func removeStaleSocket(socketPath string) error {
+	if _, err := os.Stat(socketPath); os.IsNotExist(err) {
+		return nil
+	}
+
+	if conn, err := net.DialTimeout("unix", socketPath, time.Second); err == nil {
+		conn.Close()
+		return fmt.Errorf("daemon already running (socket: %s)", socketPath)
+	}
+
+	if err := os.Remove(socketPath); err != nil {
+		return fmt.Errorf("failed to remove stale socket: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,246 @@
+package daemon
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ferg-cod3s/rune/internal/config"
+	"github.com/ferg-cod3s/rune/internal/tracking"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer_ClientRoundTrip(t *testing.T) {
+	server, socketPath := setupTestServer(t, nil)
+
+	client, err := DialPath(socketPath)
+	require.NoError(t, err)
+	defer client.Close()
+
+	session, err := client.Start("test-project")
+	require.NoError(t, err)
+	assert.Equal(t, "test-project", session.Project)
+	assert.Equal(t, tracking.StateRunning, session.State)
+
+	// Errors from the tracker are passed through unchanged
+	_, err = client.Start("another-project")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "session already active")
+
+	current, err := client.GetCurrentSession()
+	require.NoError(t, err)
+	require.NotNil(t, current)
+	assert.Equal(t, session.ID, current.ID)
+
+	paused, err := client.Pause()
+	require.NoError(t, err)
+	assert.Equal(t, tracking.StatePaused, paused.State)
+
+	resumed, err := client.Resume()
+	require.NoError(t, err)
+	assert.Equal(t, tracking.StateRunning, resumed.State)
+
+	stopped, err := client.Stop()
+	require.NoError(t, err)
+	assert.Equal(t, tracking.StateStopped, stopped.State)
+
+	current, err = client.GetCurrentSession()
+	require.NoError(t, err)
+	assert.Nil(t, current)
+
+	history, err := client.GetSessionHistory(10)
+	require.NoError(t, err)
+	assert.Len(t, history, 1)
+
+	status, err := client.Status()
+	require.NoError(t, err)
+	assert.Equal(t, os.Getpid(), status.PID)
+	assert.Equal(t, socketPath, status.SocketPath)
+
+	server.Shutdown()
+}
+
+func TestServer_RejectsSecondDaemon(t *testing.T) {
+	_, socketPath := setupTestServer(t, nil)
+
+	err := removeStaleSocket(socketPath)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "daemon already running")
+}
+
+func TestServer_ConcurrentStartsStartOneSession(t *testing.T) {
+	_, socketPath := setupTestServer(t, nil)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	started := 0
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			client, err := DialPath(socketPath)
+			if err != nil {
+				return
+			}
+			defer client.Close()
+			if _, err := client.Start("race"); err == nil {
+				mu.Lock()
+				started++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 1, started)
+}
+
+func TestServer_ShutdownClosesOpenConnections(t *testing.T) {
+	server, socketPath := setupTestServer(t, nil)
+
+	client, err := DialPath(socketPath)
+	require.NoError(t, err)
+	defer client.Close()
+	_, err = client.Start("work")
+	require.NoError(t, err)
+
+	// Shutdown returns once the idle client's connection is closed, and
+	// only then closes the tracker
+	done := make(chan struct{})
+	go func() {
+		server.Shutdown()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("shutdown did not finish")
+	}
+	_, err = client.GetCurrentSession()
+	assert.Error(t, err)
+}
+
+func TestServer_EndOfDayReminderFiresOnce(t *testing.T) {
+	cfg := &config.Config{
+		Settings: config.Settings{
+			WorkHours:     0.0000001,
+			BreakInterval: time.Hour,
+			IdleThreshold: time.Hour,
+			Notifications: config.NotificationSettings{
+				EndOfDayReminders: true,
+			},
+		},
+	}
+	server, _ := setupTestServer(t, cfg)
+
+	_, err := server.tracker.Start("test-project")
+	require.NoError(t, err)
+	time.Sleep(5 * time.Millisecond)
+
+	now := time.Now()
+	server.checkReminders(now)
+	assert.Equal(t, now.Format("2006-01-02"), server.lastEndOfDay)
+}
+
+func TestServer_BreakReminderUsesCurrentWorkInterval(t *testing.T) {
+	cfg := &config.Config{
+		Settings: config.Settings{
+			WorkHours:     8,
+			BreakInterval: time.Hour,
+			IdleThreshold: time.Hour,
+			Notifications: config.NotificationSettings{
+				BreakReminders: true,
+			},
+		},
+	}
+	server, _ := setupTestServer(t, cfg)
+
+	session, err := server.tracker.Start("test-project")
+	require.NoError(t, err)
+
+	// Not yet due
+	server.checkReminders(session.StartTime.Add(30 * time.Minute))
+	assert.True(t, server.lastBreakReminder.IsZero())
+
+	// Due once a full interval of continuous work has passed
+	due := session.StartTime.Add(time.Hour)
+	server.checkReminders(due)
+	assert.Equal(t, due, server.lastBreakReminder)
+
+	// Paused sessions never trigger reminders
+	_, err = server.tracker.Pause()
+	require.NoError(t, err)
+	server.checkReminders(due.Add(2 * time.Hour))
+	assert.Equal(t, due, server.lastBreakReminder)
+}
+
+func TestServer_IdlePauseRunsPauseHook(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("ritual commands use POSIX sh")
+	}
+	marker := filepath.Join(t.TempDir(), "paused")
+	cfg := &config.Config{
+		Settings: config.Settings{WorkHours: 8, BreakInterval: time.Hour, IdleThreshold: time.Hour},
+		Rituals: config.Rituals{
+			Custom: map[string]config.RitualSet{
+				"away": {Global: []config.Command{{Name: "Mark", Shell: "sh", Command: "echo {{.Project}} > " + marker}}},
+			},
+			Hooks: config.RitualHooks{Pause: "away"},
+		},
+	}
+	server, _ := setupTestServer(t, cfg)
+
+	_, err := server.tracker.Start("test-project")
+	require.NoError(t, err)
+	paused, err := server.tracker.PauseWithReason(tracking.PauseIdle)
+	require.NoError(t, err)
+	server.idlePaused(paused)
+
+	data, err := os.ReadFile(marker)
+	require.NoError(t, err)
+	assert.Equal(t, "test-project\n", string(data))
+
+	runs, err := server.tracker.RitualRuns(10, false)
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	assert.Equal(t, "away", runs[0].Ritual)
+	assert.Equal(t, paused.ID, runs[0].SessionID)
+}
+
+// setupTestServer starts a daemon server backed by a temporary home directory
+func setupTestServer(t *testing.T, cfg *config.Config) (*Server, string) {
+	tempDir := t.TempDir()
+
+	originalHome := os.Getenv("HOME")
+	os.Setenv("HOME", tempDir)
+	t.Cleanup(func() {
+		os.Setenv("HOME", originalHome)
+	})
+
+	server, err := NewServer(cfg)
+	require.NoError(t, err)
+
+	socketPath := filepath.Join(tempDir, "daemon.sock")
+	errs := make(chan error, 1)
+	go func() {
+		errs <- server.Serve(socketPath)
+	}()
+	t.Cleanup(func() {
+		server.Shutdown()
+		require.NoError(t, <-errs)
+	})
+
+	require.Eventually(t, func() bool {
+		client, err := DialPath(socketPath)
+		if err != nil {
+			return false
+		}
+		client.Close()
+		return true
+	}, 2*time.Second, 10*time.Millisecond)
+
+	return server, socketPath
+}
@@ -0,0 +1,10 @@
+//go:build !windows
+
+package daemon
+
+import "syscall"
+
+// detachedAttr returns process attributes that detach the daemon from the launching terminal
+func detachedAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{Setsid: true}
+}
@@ -0,0 +1,12 @@
+//go:build windows
+
+package daemon
+
+import "syscall"
+
+// detachedAttr returns process attributes that detach the daemon from the launching console
+func detachedAttr() *syscall.SysProcAttr {
+	const createNewProcessGroup = 0x00000200
+	const detachedProcess = 0x00000008
+	return &syscall.SysProcAttr{CreationFlags: createNewProcessGroup | detachedProcess}
+}
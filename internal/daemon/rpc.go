@@ -0,0 +1,608 @@
+package daemon
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/rpc"
+	"os"
+	"time"
+
+	"github.com/ferg-cod3s/rune/internal/tracking"
+)
+
+// Empty is used for RPC methods that take no arguments or return no value
+type Empty struct{}
+
+// StartArgs are the arguments to Service.Start
+type StartArgs struct {
+	Project string
+	Options tracking.StartOptions
+}
+
+// PauseArgs are the arguments to Service.Pause
+type PauseArgs struct {
+	Reason tracking.PauseReason
+}
+
+// LogArgs are the arguments to Service.LogSession
+type LogArgs struct {
+	Project string
+	Start   time.Time
+	End     time.Time
+	Note    string
+	Tags    []string
+}
+
+// EditArgs are the arguments to Service.EditSession
+type EditArgs struct {
+	ID   string
+	Edit tracking.SessionEdit
+}
+
+// ImportArgs are the arguments to Service.ImportSessions
+type ImportArgs struct {
+	Sessions []*tracking.Session
+	DryRun   bool
+}
+
+// RitualRunsArgs are the arguments to Service.RitualRuns
+type RitualRunsArgs struct {
+	Limit      int
+	FailedOnly bool
+}
+
+// RangeArgs are the arguments for Sessions
+type RangeArgs struct {
+	From   time.Time
+	To     time.Time
+	Filter tracking.SessionFilter
+}
+
+// SessionReply wraps a session that may be nil
+type SessionReply struct {
+	Session *tracking.Session
+}
+
+// Service exposes tracker operations over RPC
+type Service struct {
+	server *Server
+}
+
+// Start starts a new work session
+func (s *Service) Start(args *StartArgs, reply *tracking.Session) error {
+	session, err := s.server.tracker.StartWithOptions(args.Project, args.Options)
+	if err != nil {
+		return err
+	}
+	*reply = *session
+	return nil
+}
+
+// Stop stops the current work session
+func (s *Service) Stop(_ *Empty, reply *tracking.Session) error {
+	session, err := s.server.tracker.Stop()
+	if err != nil {
+		return err
+	}
+	*reply = *session
+	return nil
+}
+
+// Pause pauses the current work session
+func (s *Service) Pause(args *PauseArgs, reply *tracking.Session) error {
+	session, err := s.server.tracker.PauseWithReason(args.Reason)
+	if err != nil {
+		return err
+	}
+	*reply = *session
+	return nil
+}
+
+// Resume resumes a paused work session
+func (s *Service) Resume(_ *Empty, reply *tracking.Session) error {
+	session, err := s.server.tracker.Resume()
+	if err != nil {
+		return err
+	}
+	*reply = *session
+	return nil
+}
+
+// CurrentSession returns the current active session, if any
+func (s *Service) CurrentSession(_ *Empty, reply *SessionReply) error {
+	session, err := s.server.tracker.GetCurrentSession()
+	if err != nil {
+		return err
+	}
+	reply.Session = session
+	return nil
+}
+
+// SessionDuration returns the current session duration
+func (s *Service) SessionDuration(_ *Empty, reply *time.Duration) error {
+	duration, err := s.server.tracker.GetSessionDuration()
+	*reply = duration
+	return err
+}
+
+// DailyTotal returns the total time worked today
+func (s *Service) DailyTotal(_ *Empty, reply *time.Duration) error {
+	total, err := s.server.tracker.GetDailyTotal()
+	*reply = total
+	return err
+}
+
+// WeeklyTotal returns the total time worked this week
+func (s *Service) WeeklyTotal(_ *Empty, reply *time.Duration) error {
+	total, err := s.server.tracker.GetWeeklyTotal()
+	*reply = total
+	return err
+}
+
+// SessionHistory returns recent sessions
+func (s *Service) SessionHistory(limit *int, reply *[]*tracking.Session) error {
+	sessions, err := s.server.tracker.GetSessionHistory(*limit)
+	*reply = sessions
+	return err
+}
+
+// Sessions returns completed sessions that started within a time range
+func (s *Service) Sessions(args *RangeArgs, reply *[]*tracking.Session) error {
+	sessions, err := s.server.tracker.Sessions(args.From, args.To, args.Filter)
+	*reply = sessions
+	return err
+}
+
+// ProjectStats returns time statistics by project
+func (s *Service) ProjectStats(_ *Empty, reply *map[string]time.Duration) error {
+	stats, err := s.server.tracker.GetProjectStats()
+	*reply = stats
+	return err
+}
+
+// GetSession returns the session with the given ID
+func (s *Service) GetSession(id *string, reply *tracking.Session) error {
+	session, err := s.server.tracker.GetSession(*id)
+	if err != nil {
+		return err
+	}
+	*reply = *session
+	return nil
+}
+
+// LogSession records a completed session retroactively
+func (s *Service) LogSession(args *LogArgs, reply *tracking.Session) error {
+	session, err := s.server.tracker.LogSession(args.Project, args.Start, args.End, args.Note, args.Tags)
+	if err != nil {
+		return err
+	}
+	*reply = *session
+	return nil
+}
+
+// EditSession changes a completed session
+func (s *Service) EditSession(args *EditArgs, reply *tracking.Session) error {
+	session, err := s.server.tracker.EditSession(args.ID, args.Edit)
+	if err != nil {
+		return err
+	}
+	*reply = *session
+	return nil
+}
+
+// DeleteSession removes a completed session
+func (s *Service) DeleteSession(id *string, reply *tracking.Session) error {
+	session, err := s.server.tracker.DeleteSession(*id)
+	if err != nil {
+		return err
+	}
+	*reply = *session
+	return nil
+}
+
+// ImportSessions stores completed sessions from another tool
+func (s *Service) ImportSessions(args *ImportArgs, reply *tracking.ImportResult) error {
+	result, err := s.server.tracker.ImportSessions(args.Sessions, args.DryRun)
+	if err != nil {
+		return err
+	}
+	*reply = *result
+	return nil
+}
+
+// RecordRitualRun stores the record of a ritual run, replying with its ID filled in
+func (s *Service) RecordRitualRun(run *tracking.RitualRun, reply *tracking.RitualRun) error {
+	if err := s.server.tracker.RecordRitualRun(run); err != nil {
+		return err
+	}
+	*reply = *run
+	return nil
+}
+
+// RitualRuns returns recent ritual runs, newest first
+func (s *Service) RitualRuns(args *RitualRunsArgs, reply *[]*tracking.RitualRun) error {
+	runs, err := s.server.tracker.RitualRuns(args.Limit, args.FailedOnly)
+	*reply = runs
+	return err
+}
+
+// GetRitualRun returns a ritual run by ID
+func (s *Service) GetRitualRun(id *string, reply *tracking.RitualRun) error {
+	run, err := s.server.tracker.GetRitualRun(*id)
+	if err != nil {
+		return err
+	}
+	*reply = *run
+	return nil
+}
+
+// Undo reverts the most recent manual change
+func (s *Service) Undo(_ *Empty, reply *tracking.JournalEntry) error {
+	entry, err := s.server.tracker.Undo()
+	if err != nil {
+		return err
+	}
+	*reply = *entry
+	return nil
+}
+
+// AddTags adds tags to the current session
+func (s *Service) AddTags(tags *[]string, reply *tracking.Session) error {
+	session, err := s.server.tracker.AddTags(*tags...)
+	if err != nil {
+		return err
+	}
+	*reply = *session
+	return nil
+}
+
+// RemoveTags removes tags from the current session
+func (s *Service) RemoveTags(tags *[]string, reply *tracking.Session) error {
+	session, err := s.server.tracker.RemoveTags(*tags...)
+	if err != nil {
+		return err
+	}
+	*reply = *session
+	return nil
+}
+
+// SessionsWithTag returns completed sessions carrying a tag
+func (s *Service) SessionsWithTag(tag *string, reply *[]*tracking.Session) error {
+	sessions, err := s.server.tracker.SessionsWithTag(*tag)
+	*reply = sessions
+	return err
+}
+
+// TagStats returns per-tag statistics
+func (s *Service) TagStats(_ *Empty, reply *[]tracking.TagStats) error {
+	stats, err := s.server.tracker.GetTagStats()
+	*reply = stats
+	return err
+}
+
+// IdleTime returns the current system idle time
+func (s *Service) IdleTime(_ *Empty, reply *time.Duration) error {
+	idle, err := s.server.tracker.GetIdleTime()
+	*reply = idle
+	return err
+}
+
+// IsIdle returns true if the system is currently idle
+func (s *Service) IsIdle(_ *Empty, reply *bool) error {
+	idle, err := s.server.tracker.IsIdle()
+	*reply = idle
+	return err
+}
+
+// Status returns information about the running daemon
+func (s *Service) Status(_ *Empty, reply *Status) error {
+	s.server.connMu.Lock()
+	defer s.server.connMu.Unlock()
+	reply.PID = os.Getpid()
+	reply.StartedAt = s.server.started
+	if s.server.listener != nil {
+		reply.SocketPath = s.server.listener.Addr().String()
+	}
+	return nil
+}
+
+// Shutdown stops the daemon once the reply has been sent
+func (s *Service) Shutdown(_ *Empty, _ *Empty) error {
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		s.server.Shutdown()
+	}()
+	return nil
+}
+
+// ErrNotRunning is returned by Dial when no daemon is listening
+var ErrNotRunning = errors.New("daemon is not running")
+
+// Client talks to a running daemon over its Unix socket
+type Client struct {
+	rpc *rpc.Client
+}
+
+// Dial connects to the daemon listening on the default socket
+func Dial() (*Client, error) {
+	socketPath, err := SocketPath()
+	if err != nil {
+		return nil, err
+	}
+	return DialPath(socketPath)
+}
+
+// DialPath connects to the daemon listening on the given socket
+func DialPath(socketPath string) (*Client, error) {
+	conn, err := net.DialTimeout("unix", socketPath, time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrNotRunning, err)
+	}
+	return &Client{rpc: rpc.NewClient(conn)}, nil
+}
+
+// IsRunning reports whether a daemon is accepting connections
+func IsRunning() bool {
+	client, err := Dial()
+	if err != nil {
+		return false
+	}
+	client.Close()
+	return true
+}
+
+// Close closes the connection to the daemon
+func (c *Client) Close() error {
+	return c.rpc.Close()
+}
+
+// call invokes a method on the daemon service
+func (c *Client) call(method string, args, reply interface{}) error {
+	if err := c.rpc.Call(serviceName+"."+method, args, reply); err != nil {
+		var serverErr rpc.ServerError
+		if errors.As(err, &serverErr) {
+			return errors.New(string(serverErr))
+		}
+		return fmt.Errorf("daemon request failed: %w", err)
+	}
+	return nil
+}
+
+// Start starts a new work session
+func (c *Client) Start(project string) (*tracking.Session, error) {
+	return c.StartWithOptions(project, tracking.StartOptions{})
+}
+
+// StartWithOptions starts a new work session with tags and a note
+func (c *Client) StartWithOptions(project string, opts tracking.StartOptions) (*tracking.Session, error) {
+	var session tracking.Session
+	if err := c.call("Start", &StartArgs{Project: project, Options: opts}, &session); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// Stop stops the current work session
+func (c *Client) Stop() (*tracking.Session, error) {
+	var session tracking.Session
+	if err := c.call("Stop", &Empty{}, &session); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// Pause pauses the current work session
+func (c *Client) Pause() (*tracking.Session, error) {
+	return c.PauseWithReason(tracking.PauseManual)
+}
+
+// PauseWithReason pauses the current work session, recording why it was paused
+func (c *Client) PauseWithReason(reason tracking.PauseReason) (*tracking.Session, error) {
+	var session tracking.Session
+	if err := c.call("Pause", &PauseArgs{Reason: reason}, &session); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// Resume resumes a paused work session
+func (c *Client) Resume() (*tracking.Session, error) {
+	var session tracking.Session
+	if err := c.call("Resume", &Empty{}, &session); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// GetCurrentSession returns the current active session
+func (c *Client) GetCurrentSession() (*tracking.Session, error) {
+	var reply SessionReply
+	if err := c.call("CurrentSession", &Empty{}, &reply); err != nil {
+		return nil, err
+	}
+	return reply.Session, nil
+}
+
+// GetSessionDuration returns the current session duration
+func (c *Client) GetSessionDuration() (time.Duration, error) {
+	var duration time.Duration
+	err := c.call("SessionDuration", &Empty{}, &duration)
+	return duration, err
+}
+
+// GetDailyTotal returns the total time worked today
+func (c *Client) GetDailyTotal() (time.Duration, error) {
+	var total time.Duration
+	err := c.call("DailyTotal", &Empty{}, &total)
+	return total, err
+}
+
+// GetWeeklyTotal returns the total time worked this week
+func (c *Client) GetWeeklyTotal() (time.Duration, error) {
+	var total time.Duration
+	err := c.call("WeeklyTotal", &Empty{}, &total)
+	return total, err
+}
+
+// GetSessionHistory returns recent sessions
+func (c *Client) GetSessionHistory(limit int) ([]*tracking.Session, error) {
+	var sessions []*tracking.Session
+	err := c.call("SessionHistory", &limit, &sessions)
+	return sessions, err
+}
+
+// Sessions returns completed sessions that started in [from, to), oldest first
+func (c *Client) Sessions(from, to time.Time, filter tracking.SessionFilter) ([]*tracking.Session, error) {
+	var sessions []*tracking.Session
+	err := c.call("Sessions", &RangeArgs{From: from, To: to, Filter: filter}, &sessions)
+	return sessions, err
+}
+
+// GetProjectStats returns time statistics by project
+func (c *Client) GetProjectStats() (map[string]time.Duration, error) {
+	var stats map[string]time.Duration
+	err := c.call("ProjectStats", &Empty{}, &stats)
+	return stats, err
+}
+
+// GetSession returns the session with the given ID
+func (c *Client) GetSession(id string) (*tracking.Session, error) {
+	var session tracking.Session
+	if err := c.call("GetSession", &id, &session); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// LogSession records a completed session retroactively
+func (c *Client) LogSession(project string, start, end time.Time, note string, tags []string) (*tracking.Session, error) {
+	var session tracking.Session
+	args := &LogArgs{Project: project, Start: start, End: end, Note: note, Tags: tags}
+	if err := c.call("LogSession", args, &session); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// ImportSessions stores completed sessions from another tool
+func (c *Client) ImportSessions(sessions []*tracking.Session, dryRun bool) (*tracking.ImportResult, error) {
+	var result tracking.ImportResult
+	if err := c.call("ImportSessions", &ImportArgs{Sessions: sessions, DryRun: dryRun}, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// RecordRitualRun stores the record of a ritual run, giving it an ID if it has none
+func (c *Client) RecordRitualRun(run *tracking.RitualRun) error {
+	var stored tracking.RitualRun
+	if err := c.call("RecordRitualRun", run, &stored); err != nil {
+		return err
+	}
+	run.ID = stored.ID
+	return nil
+}
+
+// RitualRuns returns up to limit ritual runs, newest first, optionally only failed ones
+func (c *Client) RitualRuns(limit int, failedOnly bool) ([]*tracking.RitualRun, error) {
+	var runs []*tracking.RitualRun
+	err := c.call("RitualRuns", &RitualRunsArgs{Limit: limit, FailedOnly: failedOnly}, &runs)
+	return runs, err
+}
+
+// GetRitualRun returns a ritual run by ID
+func (c *Client) GetRitualRun(id string) (*tracking.RitualRun, error) {
+	var run tracking.RitualRun
+	if err := c.call("GetRitualRun", &id, &run); err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+// EditSession changes a completed session
+func (c *Client) EditSession(id string, edit tracking.SessionEdit) (*tracking.Session, error) {
+	var session tracking.Session
+	if err := c.call("EditSession", &EditArgs{ID: id, Edit: edit}, &session); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// DeleteSession removes a completed session
+func (c *Client) DeleteSession(id string) (*tracking.Session, error) {
+	var session tracking.Session
+	if err := c.call("DeleteSession", &id, &session); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// Undo reverts the most recent manual change
+func (c *Client) Undo() (*tracking.JournalEntry, error) {
+	var entry tracking.JournalEntry
+	if err := c.call("Undo", &Empty{}, &entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// AddTags adds tags to the current session
+func (c *Client) AddTags(tags ...string) (*tracking.Session, error) {
+	var session tracking.Session
+	if err := c.call("AddTags", &tags, &session); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// RemoveTags removes tags from the current session
+func (c *Client) RemoveTags(tags ...string) (*tracking.Session, error) {
+	var session tracking.Session
+	if err := c.call("RemoveTags", &tags, &session); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// SessionsWithTag returns completed sessions carrying a tag
+func (c *Client) SessionsWithTag(tag string) ([]*tracking.Session, error) {
+	var sessions []*tracking.Session
+	err := c.call("SessionsWithTag", &tag, &sessions)
+	return sessions, err
+}
+
+// GetTagStats returns per-tag statistics
+func (c *Client) GetTagStats() ([]tracking.TagStats, error) {
+	var stats []tracking.TagStats
+	err := c.call("TagStats", &Empty{}, &stats)
+	return stats, err
+}
+
+// GetIdleTime returns the current system idle time
+func (c *Client) GetIdleTime() (time.Duration, error) {
+	var idle time.Duration
+	err := c.call("IdleTime", &Empty{}, &idle)
+	return idle, err
+}
+
+// IsIdle returns true if the system is currently idle
+func (c *Client) IsIdle() (bool, error) {
+	var idle bool
+	err := c.call("IsIdle", &Empty{}, &idle)
+	return idle, err
+}
+
+// Status returns information about the running daemon
+func (c *Client) Status() (*Status, error) {
+	var status Status
+	if err := c.call("Status", &Empty{}, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// Shutdown asks the daemon to stop
+func (c *Client) Shutdown() error {
+	return c.call("Shutdown", &Empty{}, &Empty{})
+}
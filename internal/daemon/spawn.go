@@ -0,0 +1,49 @@
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+)
+
+// Spawn starts the daemon as a detached background process running
+// `<executable> daemon run` and waits until it accepts connections.
+func Spawn() (int, error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return 0, fmt.Errorf("failed to locate rune executable: %w", err)
+	}
+
+	logPath, err := LogPath()
+	if err != nil {
+		return 0, err
+	}
+	logFile, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open daemon log: %w", err)
+	}
+	defer logFile.Close()
+
+	cmd := exec.Command(executable, "daemon", "run")
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	cmd.SysProcAttr = detachedAttr()
+
+	if err := cmd.Start(); err != nil {
+		return 0, fmt.Errorf("failed to start daemon: %w", err)
+	}
+	pid := cmd.Process.Pid
+	_ = cmd.Process.Release()
+
+	// Wait for the daemon to start accepting connections
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if IsRunning() {
+			return pid, nil
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	return pid, fmt.Errorf("daemon did not start within 5s (see %s)", logPath)
+}
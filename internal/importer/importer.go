@@ -0,0 +1,135 @@
+package importer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ferg-cod3s/rune/internal/tracking"
+)
+
+// Supported import formats
+const (
+	FormatWatson      = "watson"
+	FormatTimewarrior = "timewarrior"
+	FormatTogglCSV    = "toggl-csv"
+)
+
+// Formats lists the supported import formats
+var Formats = []string{FormatWatson, FormatTimewarrior, FormatTogglCSV}
+
+// Options controls how entries from other tools become sessions
+type Options struct {
+	// ProjectMap renames projects from the source tool
+	ProjectMap map[string]string
+	// DefaultProject is used for entries without a project
+	DefaultProject string
+	// Location is the timezone of sources that record local times (Toggl CSV)
+	Location *time.Location
+}
+
+// Skipped is a source entry that could not be imported
+type Skipped struct {
+	Source string
+	Reason string
+}
+
+// Result holds the sessions read from a source, ordered by start time
+type Result struct {
+	Sessions []*tracking.Session
+	Skipped  []Skipped
+}
+
+// entry is a completed time record read from another tool
+type entry struct {
+	source  string
+	project string
+	start   time.Time
+	end     time.Time
+	tags    []string
+	note    string
+}
+
+// Load reads the history at path in the given format
+func Load(format, path string, opts Options) (*Result, error) {
+	var entries []entry
+	var skipped []Skipped
+	var err error
+
+	switch format {
+	case FormatWatson:
+		entries, skipped, err = loadWatson(path)
+	case FormatTimewarrior:
+		entries, skipped, err = loadTimewarrior(path)
+	case FormatTogglCSV:
+		entries, skipped, err = loadTogglCSV(path, opts.location())
+	default:
+		return nil, fmt.Errorf("unsupported import format %q (expected %s)", format, strings.Join(Formats, ", "))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{Skipped: skipped}
+	for _, e := range entries {
+		session, err := tracking.NewStoppedSession(opts.project(e.project), e.start, e.end, e.note, e.tags)
+		if err != nil {
+			result.Skipped = append(result.Skipped, Skipped{Source: e.source, Reason: err.Error()})
+			continue
+		}
+		result.Sessions = append(result.Sessions, session)
+	}
+
+	sort.SliceStable(result.Sessions, func(i, j int) bool {
+		return result.Sessions[i].StartTime.Before(result.Sessions[j].StartTime)
+	})
+	return result, nil
+}
+
+// ParseProjectMap parses "from=to" project renames
+func ParseProjectMap(pairs []string) (map[string]string, error) {
+	mapping := make(map[string]string)
+	for _, pair := range pairs {
+		from, to, ok := strings.Cut(pair, "=")
+		from, to = strings.TrimSpace(from), strings.TrimSpace(to)
+		if !ok || from == "" || to == "" {
+			return nil, fmt.Errorf("invalid project mapping %q (use source=project)", pair)
+		}
+		mapping[from] = to
+	}
+	return mapping, nil
+}
+
+// project returns the rune project for a source project name
+func (o Options) project(name string) string {
+	if mapped, ok := o.ProjectMap[name]; ok {
+		return mapped
+	}
+	if name == "" {
+		return o.DefaultProject
+	}
+	return name
+}
+
+// location returns the timezone for local times, defaulting to local time
+func (o Options) location() *time.Location {
+	if o.Location == nil {
+		return time.Local
+	}
+	return o.Location
+}
+
+// resolvePath returns file inside path when path is a directory
+func resolvePath(path, file string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if info.IsDir() {
+		return filepath.Join(path, file), nil
+	}
+	return path, nil
+}
@@ -0,0 +1,136 @@
+package importer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+}
+
+func TestLoad_Watson(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "frames"), `[
+  [1749546000, 1749555000, "client-a", "f1", ["review", "ops"], 1749555000],
+  [1749560400, 1749564000, "personal", "f2", [], 1749564000],
+  [1749564000, 1749560400, "broken", "f3", [], 1749564000],
+  ["oops"]
+]`)
+
+	result, err := Load(FormatWatson, dir, Options{ProjectMap: map[string]string{"client-a": "acme"}})
+	require.NoError(t, err)
+	require.Len(t, result.Sessions, 2)
+
+	first := result.Sessions[0]
+	assert.Equal(t, "acme", first.Project)
+	assert.Equal(t, []string{"review", "ops"}, first.Tags)
+	assert.True(t, time.Unix(1749546000, 0).Equal(first.StartTime))
+	assert.Equal(t, 150*time.Minute, first.Duration)
+	assert.Equal(t, "personal", result.Sessions[1].Project)
+
+	require.Len(t, result.Skipped, 2)
+	assert.Equal(t, "frame 4", result.Skipped[0].Source)
+	assert.Equal(t, "frame f3", result.Skipped[1].Source)
+}
+
+func TestLoad_TimewarriorData(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "data", "2025-06.data"), strings.Join([]string{
+		`inc 20250610T090000Z - 20250610T113000Z # api "code review" # "Release \"v2\""`,
+		`inc 20250610T130000Z - 20250610T140000Z`,
+		`inc 20250611T090000Z # api`,
+		``,
+	}, "\n"))
+	writeFile(t, filepath.Join(dir, "data", "tags.data"), `{"api": {"count": 2}}`)
+
+	result, err := Load(FormatTimewarrior, dir, Options{DefaultProject: "imported"})
+	require.NoError(t, err)
+	require.Len(t, result.Sessions, 2)
+
+	first := result.Sessions[0]
+	assert.Equal(t, "api", first.Project)
+	assert.Equal(t, []string{"code review"}, first.Tags)
+	assert.Equal(t, `Release "v2"`, first.Note)
+	assert.Equal(t, time.Date(2025, 6, 10, 9, 0, 0, 0, time.UTC), first.StartTime.UTC())
+
+	assert.Equal(t, "imported", result.Sessions[1].Project, "untagged intervals use the default project")
+
+	require.Len(t, result.Skipped, 1)
+	assert.Equal(t, "2025-06.data:3", result.Skipped[0].Source)
+	assert.Contains(t, result.Skipped[0].Reason, "still open")
+}
+
+func TestLoad_TimewarriorExport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "export.json")
+	writeFile(t, path, `[
+  {"id": 2, "start": "20250610T090000Z", "end": "20250610T100000Z", "tags": ["web", "bug"], "annotation": "login"},
+  {"id": 1, "start": "20250611T090000Z", "tags": ["web"]}
+]`)
+
+	result, err := Load(FormatTimewarrior, path, Options{})
+	require.NoError(t, err)
+	require.Len(t, result.Sessions, 1)
+	assert.Equal(t, "web", result.Sessions[0].Project)
+	assert.Equal(t, []string{"bug"}, result.Sessions[0].Tags)
+	assert.Equal(t, "login", result.Sessions[0].Note)
+	require.Len(t, result.Skipped, 1)
+	assert.Equal(t, "interval @1", result.Skipped[0].Source)
+}
+
+func TestLoad_TogglCSV(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "toggl.csv")
+	writeFile(t, path, "\ufeffUser,Email,Client,Project,Task,Description,Billable,Start date,Start time,End date,End time,Duration,Tags\n"+
+		"Sam,sam@example.com,Acme,Website,,Fix header,Yes,2025-06-10,09:00:00,2025-06-10,10:30:00,01:30:00,\"design, bug\"\n"+
+		"Sam,sam@example.com,,,,Email,No,2025-06-10,23:30:00,2025-06-11,00:15:00,00:45:00,\n"+
+		"Sam,sam@example.com,,,,Bad,No,yesterday,09:00:00,2025-06-10,10:00:00,01:00:00,\n")
+
+	result, err := Load(FormatTogglCSV, path, Options{DefaultProject: "misc", Location: berlin})
+	require.NoError(t, err)
+	require.Len(t, result.Sessions, 2)
+
+	first := result.Sessions[0]
+	assert.Equal(t, "Website", first.Project)
+	assert.Equal(t, []string{"design", "bug"}, first.Tags)
+	assert.Equal(t, "Fix header", first.Note)
+	assert.True(t, time.Date(2025, 6, 10, 9, 0, 0, 0, berlin).Equal(first.StartTime))
+
+	assert.Equal(t, "misc", result.Sessions[1].Project)
+	assert.Equal(t, 45*time.Minute, result.Sessions[1].Duration)
+
+	require.Len(t, result.Skipped, 1)
+	assert.Equal(t, "line 4", result.Skipped[0].Source)
+}
+
+func TestLoad_Errors(t *testing.T) {
+	_, err := Load("hamster", t.TempDir(), Options{})
+	assert.ErrorContains(t, err, "unsupported import format")
+
+	path := filepath.Join(t.TempDir(), "other.csv")
+	writeFile(t, path, "a,b,c\n1,2,3\n")
+	_, err = Load(FormatTogglCSV, path, Options{})
+	assert.ErrorContains(t, err, "not a Toggl CSV export")
+
+	_, err = Load(FormatTimewarrior, t.TempDir(), Options{})
+	assert.ErrorContains(t, err, "no Timewarrior data files")
+}
+
+func TestParseProjectMap(t *testing.T) {
+	mapping, err := ParseProjectMap([]string{"client-a=acme", " old = new "})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"client-a": "acme", "old": "new"}, mapping)
+
+	_, err = ParseProjectMap([]string{"acme"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,232 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// timewLayout is the timestamp format of Timewarrior data files and exports
+const timewLayout = "20060102T150405Z"
+
+// timewDataFile matches Timewarrior's monthly data files such as 2025-06.data
+var timewDataFile = regexp.MustCompile(`^\d{4}-\d{2}\.data$`)
+
+// timewInterval is an interval in the output of `timew export`
+type timewInterval struct {
+	ID         int      `json:"id"`
+	Start      string   `json:"start"`
+	End        string   `json:"end"`
+	Tags       []string `json:"tags"`
+	Annotation string   `json:"annotation"`
+}
+
+// loadTimewarrior reads Timewarrior history. path may be the Timewarrior
+// directory, its data directory, a single monthly data file or the JSON
+// output of `timew export`. Timewarrior has no projects, so an interval's
+// first tag is used as the project and the remaining tags are kept as tags.
+func loadTimewarrior(path string) ([]entry, []Skipped, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	if !info.IsDir() {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read Timewarrior data: %w", err)
+		}
+		if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+			return parseTimewExport(data)
+		}
+		entries, skipped := parseTimewData(filepath.Base(path), data)
+		return entries, skipped, nil
+	}
+
+	if sub := filepath.Join(path, "data"); isDir(sub) {
+		path = sub
+	}
+	files, err := os.ReadDir(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read Timewarrior data: %w", err)
+	}
+
+	var names []string
+	for _, file := range files {
+		if !file.IsDir() && timewDataFile.MatchString(file.Name()) {
+			names = append(names, file.Name())
+		}
+	}
+	if len(names) == 0 {
+		return nil, nil, fmt.Errorf("no Timewarrior data files found in %s", path)
+	}
+	sort.Strings(names)
+
+	var entries []entry
+	var skipped []Skipped
+	for _, name := range names {
+		data, err := os.ReadFile(filepath.Join(path, name))
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read Timewarrior data: %w", err)
+		}
+		fileEntries, fileSkipped := parseTimewData(name, data)
+		entries = append(entries, fileEntries...)
+		skipped = append(skipped, fileSkipped...)
+	}
+	return entries, skipped, nil
+}
+
+// parseTimewExport parses the JSON output of `timew export`
+func parseTimewExport(data []byte) ([]entry, []Skipped, error) {
+	var intervals []timewInterval
+	if err := json.Unmarshal(data, &intervals); err != nil {
+		return nil, nil, fmt.Errorf("invalid Timewarrior export: %w", err)
+	}
+
+	var entries []entry
+	var skipped []Skipped
+	for i, interval := range intervals {
+		source := fmt.Sprintf("interval %d", i+1)
+		if interval.ID != 0 {
+			source = fmt.Sprintf("interval @%d", interval.ID)
+		}
+		e, err := timewEntry(source, interval.Start, interval.End, interval.Tags, interval.Annotation)
+		if err != nil {
+			skipped = append(skipped, Skipped{Source: source, Reason: err.Error()})
+			continue
+		}
+		entries = append(entries, e)
+	}
+	return entries, skipped, nil
+}
+
+// parseTimewData parses a Timewarrior data file, where each line reads
+//
+//	inc 20250610T090000Z - 20250610T113000Z # project "tag two" # "annotation"
+func parseTimewData(name string, data []byte) ([]entry, []Skipped) {
+	var entries []entry
+	var skipped []Skipped
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		source := fmt.Sprintf("%s:%d", name, line)
+
+		tokens := tokenizeTimew(text)
+		if len(tokens) < 2 || tokens[0].text != "inc" {
+			skipped = append(skipped, Skipped{Source: source, Reason: "not an interval"})
+			continue
+		}
+
+		start, end := tokens[1].text, ""
+		rest := tokens[2:]
+		if len(rest) >= 2 && rest[0].text == "-" && !rest[0].quoted {
+			end = rest[1].text
+			rest = rest[2:]
+		}
+
+		// The first "#" starts the tags and a second one the annotation
+		var tags []string
+		var annotation string
+		section := 0
+		for _, token := range rest {
+			if token.text == "#" && !token.quoted {
+				section++
+				continue
+			}
+			switch section {
+			case 1:
+				tags = append(tags, token.text)
+			case 2:
+				annotation = strings.TrimSpace(annotation + " " + token.text)
+			}
+		}
+
+		e, err := timewEntry(source, start, end, tags, annotation)
+		if err != nil {
+			skipped = append(skipped, Skipped{Source: source, Reason: err.Error()})
+			continue
+		}
+		entries = append(entries, e)
+	}
+	return entries, skipped
+}
+
+// timewEntry builds an entry from a Timewarrior interval
+func timewEntry(source, start, end string, tags []string, annotation string) (entry, error) {
+	if end == "" {
+		return entry{}, fmt.Errorf("interval is still open")
+	}
+	startTime, err := time.Parse(timewLayout, start)
+	if err != nil {
+		return entry{}, fmt.Errorf("invalid start time %q", start)
+	}
+	endTime, err := time.Parse(timewLayout, end)
+	if err != nil {
+		return entry{}, fmt.Errorf("invalid end time %q", end)
+	}
+
+	e := entry{source: source, start: startTime, end: endTime, note: annotation}
+	if len(tags) > 0 {
+		e.project, e.tags = tags[0], tags[1:]
+	}
+	return e, nil
+}
+
+// timewToken is a word of a Timewarrior data line
+type timewToken struct {
+	text   string
+	quoted bool
+}
+
+// tokenizeTimew splits a data line into words, keeping double-quoted
+// strings (with backslash escapes) together
+func tokenizeTimew(line string) []timewToken {
+	var tokens []timewToken
+	var current strings.Builder
+	inWord, quoted, inQuotes, escaped := false, false, false, false
+
+	flush := func() {
+		if inWord {
+			tokens = append(tokens, timewToken{text: current.String(), quoted: quoted})
+		}
+		current.Reset()
+		inWord, quoted = false, false
+	}
+
+	for _, r := range line {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case inQuotes && r == '\\':
+			escaped = true
+		case r == '"':
+			inQuotes = !inQuotes
+			inWord, quoted = true, true
+		case !inQuotes && (r == ' ' || r == '\t'):
+			flush()
+		default:
+			current.WriteRune(r)
+			inWord = true
+		}
+	}
+	flush()
+	return tokens
+}
+
+// isDir reports whether path is an existing directory
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
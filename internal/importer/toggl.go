@@ -0,0 +1,104 @@
+package importer
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// togglColumns are the Toggl Track detailed report columns used for import
+var togglColumns = []string{"start date", "start time", "end date", "end time"}
+
+// loadTogglCSV reads a Toggl Track detailed report CSV export. Toggl exports
+// times without a timezone, so they are read in loc.
+func loadTogglCSV(path string, loc *time.Location) ([]entry, []Skipped, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open Toggl export: %w", err)
+	}
+	defer file.Close()
+
+	return parseTogglCSV(file, loc)
+}
+
+// parseTogglCSV parses a Toggl Track CSV export
+func parseTogglCSV(r io.Reader, loc *time.Location) ([]entry, []Skipped, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read Toggl CSV header: %w", err)
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		columns[name] = i
+	}
+	for _, name := range togglColumns {
+		if _, ok := columns[name]; !ok {
+			return nil, nil, fmt.Errorf("not a Toggl CSV export: missing %q column", name)
+		}
+	}
+
+	var entries []entry
+	var skipped []Skipped
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		source := fmt.Sprintf("line %d", line)
+		if err != nil {
+			skipped = append(skipped, Skipped{Source: source, Reason: err.Error()})
+			continue
+		}
+
+		field := func(name string) string {
+			i, ok := columns[name]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+
+		start, err := parseTogglTime(field("start date"), field("start time"), loc)
+		if err != nil {
+			skipped = append(skipped, Skipped{Source: source, Reason: err.Error()})
+			continue
+		}
+		end, err := parseTogglTime(field("end date"), field("end time"), loc)
+		if err != nil {
+			skipped = append(skipped, Skipped{Source: source, Reason: err.Error()})
+			continue
+		}
+
+		var tags []string
+		if value := field("tags"); value != "" {
+			tags = strings.Split(value, ",")
+		}
+
+		entries = append(entries, entry{
+			source:  source,
+			project: field("project"),
+			start:   start,
+			end:     end,
+			tags:    tags,
+			note:    field("description"),
+		})
+	}
+
+	return entries, skipped, nil
+}
+
+// parseTogglTime parses a Toggl date and time in the given timezone
+func parseTogglTime(date, clock string, loc *time.Location) (time.Time, error) {
+	t, err := time.ParseInLocation("2006-01-02 15:04:05", date+" "+clock, loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date and time %q", date+" "+clock)
+	}
+	return t, nil
+}
@@ -0,0 +1,80 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// loadWatson reads Watson's frames file, a JSON array of
+// [start, stop, project, id, tags, updated_at] frames. path may be the
+// frames file or Watson's configuration directory.
+func loadWatson(path string) ([]entry, []Skipped, error) {
+	path, err := resolvePath(path, "frames")
+	if err != nil {
+		return nil, nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read Watson frames: %w", err)
+	}
+	return parseWatson(data)
+}
+
+// parseWatson parses the contents of a Watson frames file
+func parseWatson(data []byte) ([]entry, []Skipped, error) {
+	var frames [][]json.RawMessage
+	if err := json.Unmarshal(data, &frames); err != nil {
+		return nil, nil, fmt.Errorf("invalid Watson frames file: %w", err)
+	}
+
+	var entries []entry
+	var skipped []Skipped
+	for i, frame := range frames {
+		source := fmt.Sprintf("frame %d", i+1)
+		if len(frame) < 3 {
+			skipped = append(skipped, Skipped{Source: source, Reason: "frame has too few fields"})
+			continue
+		}
+
+		var start, stop float64
+		var project, id string
+		var tags []string
+		err := json.Unmarshal(frame[0], &start)
+		if err == nil {
+			err = json.Unmarshal(frame[1], &stop)
+		}
+		if err == nil {
+			err = json.Unmarshal(frame[2], &project)
+		}
+		if err == nil && len(frame) > 3 {
+			err = json.Unmarshal(frame[3], &id)
+		}
+		if err == nil && len(frame) > 4 {
+			err = json.Unmarshal(frame[4], &tags)
+		}
+		if err != nil {
+			skipped = append(skipped, Skipped{Source: source, Reason: fmt.Sprintf("invalid frame: %v", err)})
+			continue
+		}
+		if id != "" {
+			source = "frame " + id
+		}
+
+		entries = append(entries, entry{
+			source:  source,
+			project: project,
+			start:   unixSeconds(start),
+			end:     unixSeconds(stop),
+			tags:    tags,
+		})
+	}
+
+	return entries, skipped, nil
+}
+
+// unixSeconds converts a Unix timestamp in seconds to a time
+func unixSeconds(seconds float64) time.Time {
+	return time.Unix(0, int64(seconds*float64(time.Second)))
+}
@@ -0,0 +1,352 @@
+// Package procs tracks the background processes started by rituals, with a
+// PID file, metadata and stdout and stderr logs for each under ~/.rune/procs.
+package procs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultGracePeriod is how long Kill waits for a process to exit before forcing it
+const DefaultGracePeriod = 5 * time.Second
+
+// Process is a background process started by a ritual
+type Process struct {
+	Name      string    `json:"name"`
+	PID       int       `json:"pid"`
+	Command   string    `json:"command"`
+	Project   string    `json:"project,omitempty"`
+	SessionID string    `json:"session_id,omitempty"`
+	Started   time.Time `json:"started"`
+	// Identity is the start time the OS reports for the process, so that
+	// another process reusing its PID is not mistaken for it
+	Identity string `json:"identity,omitempty"`
+}
+
+// Running reports whether the process is still alive. A process that
+// cannot be identified as the one rune started is not running.
+func (p Process) Running() bool {
+	if p.PID <= 0 || p.Identity == "" || !alive(p.PID) {
+		return false
+	}
+	identity, err := processIdentity(p.PID)
+	return err == nil && identity == p.Identity
+}
+
+// Dir returns the ~/.rune/procs directory, creating it if necessary
+func Dir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+
+	dir := filepath.Join(home, ".rune", "procs")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create procs directory: %w", err)
+	}
+	return dir, nil
+}
+
+// validName matches the names Name produces. Names given on the command
+// line are checked against it so none can reach outside the procs directory.
+var validName = regexp.MustCompile(`^[a-z0-9._-]+$`)
+
+// path returns the file of the named process with the given suffix
+func path(name, suffix string) (string, error) {
+	if !validName.MatchString(name) {
+		return "", fmt.Errorf("invalid background process name %q", name)
+	}
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name+suffix), nil
+}
+
+// LogPaths returns the stdout and stderr log files of the named process
+func LogPaths(name string) (string, string, error) {
+	stdout, err := path(name, ".out.log")
+	if err != nil {
+		return "", "", err
+	}
+	stderr, err := path(name, ".err.log")
+	if err != nil {
+		return "", "", err
+	}
+	return stdout, stderr, nil
+}
+
+// Name turns a command name into a process name: lowercase letters, digits,
+// '.', '_' and '-', with other characters collapsed into single dashes
+func Name(command string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(command) {
+		switch {
+		case (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '.' || r == '_':
+			b.WriteRune(r)
+			dash = false
+		case !dash && b.Len() > 0:
+			b.WriteRune('-')
+			dash = true
+		}
+	}
+	name := strings.TrimRight(b.String(), "-")
+	if name == "" {
+		return "process"
+	}
+	return name
+}
+
+// Start starts cmd detached from rune as the named process, appending its
+// output to the process's log files and recording its PID
+func Start(cmd *exec.Cmd, proc Process) (*Process, error) {
+	if existing, err := Get(proc.Name); err == nil && existing.Running() {
+		return nil, fmt.Errorf("background process %q is already running (pid %d)", proc.Name, existing.PID)
+	}
+
+	stdoutPath, stderrPath, err := LogPaths(proc.Name)
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := openLog(stdoutPath)
+	if err != nil {
+		return nil, err
+	}
+	defer stdout.Close()
+	stderr, err := openLog(stderrPath)
+	if err != nil {
+		return nil, err
+	}
+	defer stderr.Close()
+
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	cmd.SysProcAttr = detachedAttr()
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	// Reap the process if it exits while rune is still running
+	go func() { _ = cmd.Wait() }()
+
+	proc.PID = cmd.Process.Pid
+	proc.Started = time.Now()
+	// A process that exits before it can be identified is recorded as gone
+	proc.Identity, _ = processIdentity(proc.PID)
+	if err := save(proc); err != nil {
+		return nil, err
+	}
+	return &proc, nil
+}
+
+// openLog opens a log file for appending, marking where a new run starts
+func openLog(path string) (*os.File, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open log file: %w", err)
+	}
+	fmt.Fprintf(file, "--- started %s ---\n", time.Now().Format(time.RFC3339))
+	return file, nil
+}
+
+// save writes the PID file and metadata of a process
+func save(proc Process) error {
+	pidPath, err := path(proc.Name, ".pid")
+	if err != nil {
+		return err
+	}
+	procPath, err := path(proc.Name, ".json")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(pidPath, []byte(strconv.Itoa(proc.PID)+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to write PID file: %w", err)
+	}
+	data, err := json.MarshalIndent(proc, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode process: %w", err)
+	}
+	if err := os.WriteFile(procPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write process file: %w", err)
+	}
+	return nil
+}
+
+// Get returns the named process
+func Get(name string) (*Process, error) {
+	procPath, err := path(name, ".json")
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(procPath)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("no background process named %q", name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read process file: %w", err)
+	}
+
+	var proc Process
+	if err := json.Unmarshal(data, &proc); err != nil {
+		return nil, fmt.Errorf("invalid process file for %q: %w", name, err)
+	}
+	return &proc, nil
+}
+
+// List returns the tracked processes that are still running, sorted by name,
+// and stops tracking the rest
+func List() ([]Process, error) {
+	processes, err := listAll()
+	if err != nil {
+		return nil, err
+	}
+	running := processes[:0]
+	for _, proc := range processes {
+		if proc.Running() {
+			running = append(running, proc)
+		} else if err := forget(proc.Name); err != nil {
+			return nil, err
+		}
+	}
+	return running, nil
+}
+
+// Prune stops tracking the processes that have exited and returns them. Their logs are kept.
+func Prune() ([]Process, error) {
+	processes, err := listAll()
+	if err != nil {
+		return nil, err
+	}
+	var exited []Process
+	for _, proc := range processes {
+		if proc.Running() {
+			continue
+		}
+		if err := forget(proc.Name); err != nil {
+			return nil, err
+		}
+		exited = append(exited, proc)
+	}
+	return exited, nil
+}
+
+// listAll returns every tracked process, sorted by name
+func listAll() ([]Process, error) {
+	dir, err := Dir()
+	if err != nil {
+		return nil, err
+	}
+	matches, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var processes []Process
+	for _, match := range matches {
+		name := strings.TrimSuffix(filepath.Base(match), ".json")
+		if !validName.MatchString(name) {
+			// Not written by rune
+			continue
+		}
+		proc, err := Get(name)
+		if err != nil {
+			return nil, err
+		}
+		processes = append(processes, *proc)
+	}
+	sort.Slice(processes, func(i, j int) bool { return processes[i].Name < processes[j].Name })
+	return processes, nil
+}
+
+// Kill stops the named process and the processes it started, forcing them
+// if they are still running after the grace period, and stops tracking it.
+// Nothing is signalled unless the process is still the one rune started.
+// Its logs are kept.
+func Kill(name string, grace time.Duration) (*Process, error) {
+	proc, err := Get(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if proc.Running() {
+		if err := terminate(proc.PID); err != nil {
+			return nil, fmt.Errorf("failed to stop %s (pid %d): %w", name, proc.PID, err)
+		}
+		deadline := time.Now().Add(grace)
+		for proc.Running() && time.Now().Before(deadline) {
+			time.Sleep(50 * time.Millisecond)
+		}
+		if proc.Running() {
+			if err := forceKill(proc.PID); err != nil {
+				return nil, fmt.Errorf("failed to kill %s (pid %d): %w", name, proc.PID, err)
+			}
+		}
+	}
+
+	return proc, forget(name)
+}
+
+// forget removes the PID file and metadata of a process
+func forget(name string) error {
+	for _, suffix := range []string{".pid", ".json"} {
+		file, err := path(name, suffix)
+		if err != nil {
+			return err
+		}
+		if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove %s: %w", filepath.Base(file), err)
+		}
+	}
+	return nil
+}
+
+// Tail returns the last n lines of a log file and the file's size, from
+// which newer output can be read
+func Tail(path string, n int) ([]byte, int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, 0, err
+	}
+	size := info.Size()
+
+	// Read backwards in chunks until the buffer holds n complete lines
+	const chunk = 8192
+	var buf []byte
+	offset := size
+	for offset > 0 && bytes.Count(buf, []byte("\n")) <= n {
+		read := int64(chunk)
+		if read > offset {
+			read = offset
+		}
+		offset -= read
+		part := make([]byte, read)
+		if _, err := file.ReadAt(part, offset); err != nil {
+			return nil, 0, err
+		}
+		buf = append(part, buf...)
+	}
+
+	lines := bytes.SplitAfter(buf, []byte("\n"))
+	if len(lines) > 0 && len(lines[len(lines)-1]) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) > n {
+		lines = lines[len(lines)-n:]
+	}
+	return bytes.Join(lines, nil), size, nil
+}
@@ -0,0 +1,156 @@
+package procs
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestName(t *testing.T) {
+	tests := map[string]string{
+		"Start dev server":    "start-dev-server",
+		"  API: watch (v2) ":  "api-watch-v2",
+		"docker_compose.logs": "docker_compose.logs",
+		"🚀":                   "process",
+	}
+	for input, expected := range tests {
+		assert.Equal(t, expected, Name(input), input)
+	}
+}
+
+func TestStartListKill(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("process tests use POSIX sh")
+	}
+	t.Setenv("HOME", t.TempDir())
+
+	cmd := exec.Command("sh", "-c", "echo ready; echo oops >&2; exec sleep 30")
+	proc, err := Start(cmd, Process{Name: "server", Command: "sleep 30", Project: "app"})
+	require.NoError(t, err)
+	assert.True(t, proc.Running())
+
+	dir, err := Dir()
+	require.NoError(t, err)
+	pid, err := os.ReadFile(filepath.Join(dir, "server.pid"))
+	require.NoError(t, err)
+	assert.Equal(t, strconv.Itoa(proc.PID), strings.TrimSpace(string(pid)))
+
+	_, err = Start(exec.Command("sh", "-c", "sleep 30"), Process{Name: "server"})
+	assert.ErrorContains(t, err, `background process "server" is already running`)
+
+	processes, err := List()
+	require.NoError(t, err)
+	require.Len(t, processes, 1)
+	assert.Equal(t, "app", processes[0].Project)
+
+	stdoutPath, stderrPath, err := LogPaths("server")
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		data, _ := os.ReadFile(stderrPath)
+		return strings.Contains(string(data), "oops")
+	}, 5*time.Second, 20*time.Millisecond)
+	data, err := os.ReadFile(stdoutPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "ready")
+
+	killed, err := Kill("server", time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, proc.PID, killed.PID)
+	assert.Eventually(t, func() bool { return !killed.Running() }, 5*time.Second, 20*time.Millisecond)
+
+	_, err = Get("server")
+	assert.ErrorContains(t, err, `no background process named "server"`)
+	assert.FileExists(t, stdoutPath)
+}
+
+func TestReusedPIDIsNotSignalled(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("process tests use POSIX sh")
+	}
+	t.Setenv("HOME", t.TempDir())
+
+	// A record whose PID now belongs to another process, this test, is gone
+	stale := Process{Name: "stale", PID: os.Getpid(), Identity: "started long ago", Started: time.Now()}
+	require.NoError(t, save(stale))
+	assert.False(t, stale.Running())
+
+	killed, err := Kill("stale", time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, os.Getpid(), killed.PID)
+	_, err = Get("stale")
+	assert.Error(t, err)
+
+	// Listing drops records of processes that have exited
+	require.NoError(t, save(stale))
+	require.NoError(t, save(Process{Name: "unidentified", PID: os.Getpid()}))
+	processes, err := List()
+	require.NoError(t, err)
+	assert.Empty(t, processes)
+	exited, err := Prune()
+	require.NoError(t, err)
+	assert.Empty(t, exited)
+	_, err = Get("unidentified")
+	assert.Error(t, err)
+}
+
+func TestPrune(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("process tests use POSIX sh")
+	}
+	t.Setenv("HOME", t.TempDir())
+
+	proc, err := Start(exec.Command("sh", "-c", "exit 0"), Process{Name: "quick"})
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool { return !proc.Running() }, 5*time.Second, 20*time.Millisecond)
+
+	exited, err := Prune()
+	require.NoError(t, err)
+	require.Len(t, exited, 1)
+	assert.Equal(t, "quick", exited[0].Name)
+	_, err = Get("quick")
+	assert.Error(t, err)
+}
+
+func TestNamesCannotLeaveTheProcsDirectory(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	outside := filepath.Join(home, "victim.json")
+	require.NoError(t, os.WriteFile(outside, []byte(`{"name":"victim","pid":1}`), 0644))
+
+	for _, name := range []string{"../../victim", "../victim", "a/b", `a\b`, ""} {
+		_, err := Get(name)
+		assert.ErrorContains(t, err, "invalid background process name", name)
+		_, err = Kill(name, time.Millisecond)
+		assert.ErrorContains(t, err, "invalid background process name", name)
+		_, _, err = LogPaths(name)
+		assert.ErrorContains(t, err, "invalid background process name", name)
+	}
+	assert.FileExists(t, outside)
+}
+
+func TestTail(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log")
+	var content strings.Builder
+	for i := 0; i < 5000; i++ {
+		content.WriteString("line " + strings.Repeat("x", i%7) + "\n")
+	}
+	content.WriteString("last")
+	require.NoError(t, os.WriteFile(path, []byte(content.String()), 0644))
+
+	output, size, err := Tail(path, 3)
+	require.NoError(t, err)
+	assert.Equal(t, int64(content.Len()), size)
+	assert.Equal(t, "line \nline x\nlast", string(output))
+
+	output, _, err = Tail(path, 10000)
+	require.NoError(t, err)
+	assert.Equal(t, content.String(), string(output))
+}
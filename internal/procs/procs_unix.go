@@ -0,0 +1,72 @@
+//go:build !windows
+
+package procs
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// detachedAttr returns process attributes that start a process in its own
+// session, so it outlives rune and can be stopped with its children
+func detachedAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{Setsid: true}
+}
+
+// alive reports whether a process exists
+func alive(pid int) bool {
+	err := syscall.Kill(pid, 0)
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
+
+// processIdentity returns a process's start time as the OS records it: from
+// /proc where there is one, and from ps otherwise
+func processIdentity(pid int) (string, error) {
+	if _, err := os.Stat("/proc/self/stat"); err == nil {
+		data, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+		if err != nil {
+			return "", fmt.Errorf("failed to identify process %d: %w", pid, err)
+		}
+		// The command name in parentheses may contain spaces, so count the
+		// fields after it; the start time is field 22 of the whole line
+		fields := strings.Fields(string(data[bytes.LastIndexByte(data, ')')+1:]))
+		if len(fields) < 20 {
+			return "", fmt.Errorf("failed to identify process %d: unexpected /proc format", pid)
+		}
+		return fields[19], nil
+	}
+
+	out, err := exec.Command("ps", "-o", "lstart=", "-p", strconv.Itoa(pid)).Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to identify process %d: %w", pid, err)
+	}
+	identity := strings.TrimSpace(string(out))
+	if identity == "" {
+		return "", fmt.Errorf("failed to identify process %d", pid)
+	}
+	return identity, nil
+}
+
+// terminate asks a process and its group to exit
+func terminate(pid int) error {
+	return signalGroup(pid, syscall.SIGTERM)
+}
+
+// forceKill kills a process and its group
+func forceKill(pid int) error {
+	return signalGroup(pid, syscall.SIGKILL)
+}
+
+// signalGroup signals the process group led by pid, ignoring groups that are already gone
+func signalGroup(pid int, signal syscall.Signal) error {
+	if err := syscall.Kill(-pid, signal); err != nil && !errors.Is(err, syscall.ESRCH) {
+		return err
+	}
+	return nil
+}
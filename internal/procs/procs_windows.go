@@ -0,0 +1,64 @@
+//go:build windows
+
+package procs
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"syscall"
+)
+
+// detachedAttr returns process attributes that detach a process from the launching console
+func detachedAttr() *syscall.SysProcAttr {
+	const createNewProcessGroup = 0x00000200
+	const detachedProcess = 0x00000008
+	return &syscall.SysProcAttr{CreationFlags: createNewProcessGroup | detachedProcess}
+}
+
+// alive reports whether a process is still running
+func alive(pid int) bool {
+	const processQueryLimitedInformation = 0x1000
+	const stillActive = 259
+	handle, err := syscall.OpenProcess(processQueryLimitedInformation, false, uint32(pid))
+	if err != nil {
+		return false
+	}
+	defer syscall.CloseHandle(handle)
+
+	var code uint32
+	if err := syscall.GetExitCodeProcess(handle, &code); err != nil {
+		return false
+	}
+	return code == stillActive
+}
+
+// processIdentity returns a process's creation time as Windows records it
+func processIdentity(pid int) (string, error) {
+	const processQueryLimitedInformation = 0x1000
+	handle, err := syscall.OpenProcess(processQueryLimitedInformation, false, uint32(pid))
+	if err != nil {
+		return "", fmt.Errorf("failed to identify process %d: %w", pid, err)
+	}
+	defer syscall.CloseHandle(handle)
+
+	var creation, exit, kernel, user syscall.Filetime
+	if err := syscall.GetProcessTimes(handle, &creation, &exit, &kernel, &user); err != nil {
+		return "", fmt.Errorf("failed to identify process %d: %w", pid, err)
+	}
+	return strconv.FormatInt(creation.Nanoseconds(), 10), nil
+}
+
+// terminate stops a process; Windows has no graceful equivalent of SIGTERM for detached processes
+func terminate(pid int) error {
+	return forceKill(pid)
+}
+
+// forceKill kills a process
+func forceKill(pid int) error {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return nil
+	}
+	return process.Kill()
+}
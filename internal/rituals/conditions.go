@@ -0,0 +1,171 @@
+package rituals
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/ferg-cod3s/rune/internal/config"
+)
+
+// conditionContext is what a command's when conditions are evaluated against
+type conditionContext struct {
+	now      time.Time
+	hostname func() (string, error)
+	goos     string
+	getenv   func(string) string
+	// environ is the environment check commands run in
+	environ func() []string
+}
+
+// currentConditions returns the context of the machine rune is running on,
+// with the clock in the configured timezone
+func (e *Engine) currentConditions() conditionContext {
+	now := time.Now()
+	if cal, err := e.config.Calendar(); err == nil {
+		now = now.In(cal.Location)
+	}
+	return conditionContext{
+		now:      now,
+		hostname: os.Hostname,
+		goos:     runtime.GOOS,
+		getenv:   e.getenv,
+		environ:  e.environ,
+	}
+}
+
+// shouldRun reports whether every when condition of a command holds,
+// and if not, the reason the command is skipped
+func (c conditionContext) shouldRun(cmd config.Command) (bool, string, error) {
+	when := cmd.When
+	if when.IsZero() {
+		return true, "", nil
+	}
+
+	if len(when.Days) > 0 {
+		days, err := config.ParseDays(when.Days)
+		if err != nil {
+			return false, "", err
+		}
+		today := c.now.Weekday()
+		matched := false
+		for _, day := range days {
+			matched = matched || day == today
+		}
+		if !matched {
+			return false, fmt.Sprintf("today is %s, runs on %s", today, strings.Join(when.Days, ", ")), nil
+		}
+	}
+
+	if when.Time != "" {
+		window, err := config.ParseTimeWindow(when.Time)
+		if err != nil {
+			return false, "", err
+		}
+		if !window.Contains(c.now) {
+			return false, fmt.Sprintf("%s is outside %s", c.now.Format("15:04"), when.Time), nil
+		}
+	}
+
+	if len(when.Hosts) > 0 {
+		host, err := c.hostname()
+		if err != nil {
+			return false, "", fmt.Errorf("failed to get hostname: %w", err)
+		}
+		if !matchAny(when.Hosts, host) {
+			return false, fmt.Sprintf("host is %s, runs on %s", host, strings.Join(when.Hosts, ", ")), nil
+		}
+	}
+
+	if len(when.OS) > 0 && !matchAny(when.OS, c.goos) {
+		return false, fmt.Sprintf("OS is %s, runs on %s", c.goos, strings.Join(when.OS, ", ")), nil
+	}
+
+	dir := ""
+	if cmd.Dir != "" && (len(when.Branch) > 0 || len(when.File) > 0) {
+		expanded, err := expandDir(cmd.Dir)
+		if err != nil {
+			return false, "", err
+		}
+		dir = expanded
+	}
+
+	if len(when.Branch) > 0 {
+		branch, err := currentBranch(dir)
+		if err != nil {
+			return false, "not on a git branch", nil
+		}
+		if !matchAny(when.Branch, branch) {
+			return false, fmt.Sprintf("branch is %s, runs on %s", branch, strings.Join(when.Branch, ", ")), nil
+		}
+	}
+
+	if len(when.File) > 0 && !anyFileExists(dir, when.File) {
+		return false, fmt.Sprintf("no file matches %s", strings.Join(when.File, ", ")), nil
+	}
+
+	for _, condition := range when.Env {
+		name, glob, hasGlob := strings.Cut(condition, "=")
+		value := c.getenv(name)
+		if !hasGlob && value == "" {
+			return false, fmt.Sprintf("%s is not set", name), nil
+		}
+		if matched, _ := path.Match(glob, value); hasGlob && !matched {
+			return false, fmt.Sprintf("%s does not match %s", name, glob), nil
+		}
+	}
+
+	if when.Check != "" {
+		check := config.Command{Command: when.Check, Shell: cmd.Shell, Dir: cmd.Dir, Env: cmd.Env}
+		if _, err := runAttempt(check, c.environ()); err != nil {
+			return false, fmt.Sprintf("check %q failed: %v", when.Check, err), nil
+		}
+	}
+
+	return true, "", nil
+}
+
+// matchAny reports whether value matches any of the globs
+func matchAny(globs []string, value string) bool {
+	for _, glob := range globs {
+		if matched, _ := path.Match(glob, value); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// currentBranch returns the git branch checked out in dir, or the current directory when dir is empty
+func currentBranch(dir string) (string, error) {
+	cmd := exec.Command("git", "symbolic-ref", "--short", "-q", "HEAD")
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// anyFileExists reports whether any of the paths or globs, relative to dir, matches an existing file
+func anyFileExists(dir string, patterns []string) bool {
+	for _, pattern := range patterns {
+		pattern = os.ExpandEnv(pattern)
+		if pattern == "~" || strings.HasPrefix(pattern, "~/") {
+			if home, err := os.UserHomeDir(); err == nil {
+				pattern = filepath.Join(home, strings.TrimPrefix(pattern, "~"))
+			}
+		}
+		if !filepath.IsAbs(pattern) && dir != "" {
+			pattern = filepath.Join(dir, pattern)
+		}
+		if matches, _ := filepath.Glob(pattern); len(matches) > 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,108 @@
+package rituals
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/ferg-cod3s/rune/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShouldRun(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "docker-compose.yml"), nil, 0644))
+
+	env := map[string]string{"CI": "true", "DEPLOY_ENV": "staging"}
+	conditions := conditionContext{
+		// A Saturday evening
+		now:      time.Date(2024, 6, 15, 22, 30, 0, 0, time.UTC),
+		hostname: func() (string, error) { return "work-laptop", nil },
+		goos:     "linux",
+		getenv:   func(key string) string { return env[key] },
+	}
+
+	tests := []struct {
+		name   string
+		when   config.When
+		run    bool
+		reason string
+	}{
+		{name: "no conditions", when: config.When{}, run: true},
+		{name: "matching day", when: config.When{Days: config.StringList{"weekends"}}, run: true},
+		{name: "other day", when: config.When{Days: config.StringList{"mon", "tue"}}, reason: "today is Saturday, runs on mon, tue"},
+		{name: "inside window wrapping midnight", when: config.When{Time: "22:00-02:00"}, run: true},
+		{name: "outside window", when: config.When{Time: "09:00-17:00"}, reason: "22:30 is outside 09:00-17:00"},
+		{name: "matching host glob", when: config.When{Hosts: config.StringList{"work-*"}}, run: true},
+		{name: "other host", when: config.When{Hosts: config.StringList{"home-*"}}, reason: "host is work-laptop, runs on home-*"},
+		{name: "matching OS", when: config.When{OS: config.StringList{"darwin", "linux"}}, run: true},
+		{name: "other OS", when: config.When{OS: config.StringList{"windows"}}, reason: "OS is linux, runs on windows"},
+		{name: "existing file glob", when: config.When{File: config.StringList{"docker-compose.y*ml"}}, run: true},
+		{name: "missing file", when: config.When{File: config.StringList{"Makefile"}}, reason: "no file matches Makefile"},
+		{name: "env set", when: config.When{Env: config.StringList{"CI"}}, run: true},
+		{name: "env unset", when: config.When{Env: config.StringList{"HOME_OFFICE"}}, reason: "HOME_OFFICE is not set"},
+		{name: "env matches glob", when: config.When{Env: config.StringList{"DEPLOY_ENV=stag*"}}, run: true},
+		{name: "env does not match glob", when: config.When{Env: config.StringList{"DEPLOY_ENV=prod"}}, reason: "DEPLOY_ENV does not match prod"},
+		{
+			name:   "all conditions must hold",
+			when:   config.When{Days: config.StringList{"sat"}, OS: config.StringList{"darwin"}},
+			reason: "OS is linux, runs on darwin",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			run, reason, err := conditions.shouldRun(config.Command{Name: "Step", Command: "true", Dir: dir, When: tt.when})
+			require.NoError(t, err)
+			assert.Equal(t, tt.run, run)
+			assert.Equal(t, tt.reason, reason)
+		})
+	}
+}
+
+func TestShouldRun_Check(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("check tests use POSIX sh")
+	}
+	conditions := conditionContext{now: time.Now(), goos: runtime.GOOS, getenv: os.Getenv, environ: os.Environ}
+
+	run, _, err := conditions.shouldRun(config.Command{Command: "true", Shell: "sh", When: config.When{Check: "test 1 -eq 1"}})
+	require.NoError(t, err)
+	assert.True(t, run)
+
+	run, reason, err := conditions.shouldRun(config.Command{Command: "true", Shell: "sh", When: config.When{Check: "exit 3"}})
+	require.NoError(t, err)
+	assert.False(t, run)
+	assert.Contains(t, reason, `check "exit 3" failed`)
+}
+
+func TestShouldRun_Branch(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	dir := t.TempDir()
+	conditions := conditionContext{now: time.Now(), goos: runtime.GOOS, getenv: os.Getenv, environ: os.Environ}
+	cmd := config.Command{Command: "true", Dir: dir, When: config.When{Branch: config.StringList{"feature/*"}}}
+
+	run, reason, err := conditions.shouldRun(cmd)
+	require.NoError(t, err)
+	assert.False(t, run)
+	assert.Equal(t, "not on a git branch", reason)
+
+	output, err := exec.Command("git", "-C", dir, "init", "-q", "-b", "feature/login").CombinedOutput()
+	require.NoError(t, err, string(output))
+
+	run, _, err = conditions.shouldRun(cmd)
+	require.NoError(t, err)
+	assert.True(t, run)
+
+	cmd.When.Branch = config.StringList{"main"}
+	run, reason, err = conditions.shouldRun(cmd)
+	require.NoError(t, err)
+	assert.False(t, run)
+	assert.Equal(t, "branch is feature/login, runs on main", reason)
+}
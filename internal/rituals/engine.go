@@ -1,128 +1,616 @@
 package rituals
 
 import (
+	"bufio"
+	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/ferg-cod3s/rune/internal/config"
+	"github.com/ferg-cod3s/rune/internal/procs"
+	"github.com/ferg-cod3s/rune/internal/tracking"
 )
 
 // Engine handles ritual execution
 type Engine struct {
-	config *config.Config
+	config   *config.Config
+	out      io.Writer
+	recorder RunRecorder
+	// session is the work session the rituals run for, if any
+	session *tracking.Session
+
+	// in is where answers to confirmations and prompts are read from
+	in          *bufio.Reader
+	interactive bool
+	assumeYes   bool
+	answersMu   sync.Mutex
+	answers     map[string]string
+}
+
+// RunRecorder stores the record of a ritual run
+type RunRecorder interface {
+	RecordRitualRun(run *tracking.RitualRun) error
+}
+
+// RecordRuns makes the engine store a record of every ritual it runs
+func (e *Engine) RecordRuns(recorder RunRecorder) {
+	e.recorder = recorder
+}
+
+// SetSession links ritual runs to a work session and makes its details
+// available to ritual templates
+func (e *Engine) SetSession(session *tracking.Session) {
+	e.session = session
 }
 
 // NewEngine creates a new ritual engine
 func NewEngine(cfg *config.Config) *Engine {
 	return &Engine{
-		config: cfg,
+		config:      cfg,
+		out:         os.Stdout,
+		in:          bufio.NewReader(os.Stdin),
+		interactive: stdinIsTerminal(),
 	}
 }
 
 // ExecuteStartRituals executes start rituals for the given project
 func (e *Engine) ExecuteStartRituals(project string) error {
-	fmt.Println("🔮 Executing start rituals...")
+	return e.ExecuteRitual("start", project)
+}
+
+// ExecuteStopRituals executes stop rituals for the given project
+func (e *Engine) ExecuteStopRituals(project string) error {
+	return e.ExecuteRitual("stop", project)
+}
+
+// ExecuteRitual executes the named ritual, start, stop or custom, for the given project
+func (e *Engine) ExecuteRitual(name, project string) error {
+	set, ok := e.config.Rituals.Ritual(name)
+	if !ok {
+		return fmt.Errorf("unknown ritual: %s", name)
+	}
+	if name == "start" || name == "stop" {
+		fmt.Fprintf(e.out, "🔮 Executing %s rituals...\n", name)
+	} else {
+		fmt.Fprintf(e.out, "🔮 Executing %s ritual...\n", name)
+	}
+
+	sections, finally, err := e.expandTemplates(ritualSections(name, set, project), set.Finally, project)
+	if err != nil {
+		return fmt.Errorf("invalid %s rituals: %w", name, err)
+	}
+	p, err := newPlan(sections...)
+	if err != nil {
+		return fmt.Errorf("invalid %s rituals: %w", name, err)
+	}
+	err = e.runRitual(p, name, project, finally)
+	if set.KillBackground {
+		e.killBackground(project)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to execute %s rituals: %w", name, err)
+	}
+	return nil
+}
+
+// ritualSections returns the sections of a ritual in the order they run:
+// project-specific commands come before global ones when stopping, and after
+// them otherwise
+func ritualSections(name string, set config.RitualSet, project string) []section {
+	global := section{scope: "global", commands: set.Global}
+	local := section{scope: project, commands: set.PerProject[project]}
+	if name == "stop" {
+		return []section{local, global}
+	}
+	return []section{global, local}
+}
+
+// parallelism returns how many steps may run at once
+func (e *Engine) parallelism() int {
+	if e.config.Rituals.Parallelism < 1 {
+		return 1
+	}
+	return e.config.Rituals.Parallelism
+}
+
+// stepResult is the report of running one step of a plan
+type stepResult struct {
+	index  int
+	report stepReport
+	err    error
+}
 
-	// Execute global start rituals
-	if err := e.executeCommands(e.config.Rituals.Start.Global, "global"); err != nil {
-		return fmt.Errorf("failed to execute global start rituals: %w", err)
+// runRitual runs a plan for a project, undoes its completed steps in reverse order
+// if a required step failed, then runs the finally commands, prints a summary
+// and records the run
+func (e *Engine) runRitual(p *plan, ritual, project string, finally []config.Command) error {
+	if len(p.steps) == 0 && len(finally) == 0 {
+		return nil
 	}
+	started := time.Now()
+	out := &statusPrinter{w: e.out}
+	conditions := e.currentConditions()
 
-	// Execute project-specific start rituals
-	if projectCommands, exists := e.config.Rituals.Start.PerProject[project]; exists {
-		if err := e.executeCommands(projectCommands, project); err != nil {
-			return fmt.Errorf("failed to execute project start rituals: %w", err)
+	reports, completed, err := e.runPlan(p, project, conditions, out)
+	if err != nil {
+		e.undoSteps(p, completed, reports, out)
+	}
+	finallyReports, finallyErr := e.runFinally(finally, project, conditions, out)
+	e.printSummary(p, reports, finally, finallyReports)
+
+	if err == nil {
+		err = finallyErr
+	}
+	if e.recorder != nil {
+		run := newRitualRun(p, reports, finally, finallyReports)
+		run.Ritual = ritual
+		run.Project = project
+		if e.session != nil {
+			run.SessionID = e.session.ID
+		}
+		run.StartTime = started
+		run.Duration = time.Since(started)
+		if err != nil {
+			run.Error = err.Error()
+		}
+		if recordErr := e.recorder.RecordRitualRun(run); recordErr != nil {
+			fmt.Fprintf(e.out, "⚠ Failed to record ritual run: %v\n", recordErr)
+		} else if err != nil {
+			fmt.Fprintf(e.out, "💡 Use 'rune ritual show %s' to see the output of each step\n", run.ID)
 		}
 	}
+	return err
+}
 
-	return nil
+// runPlan runs every step once its needs are done, up to the configured
+// parallelism at a time. A failed step skips everything that needs it, while
+// independent steps keep running; an optional step's failure is only reported.
+// It returns a report per step and the steps that succeeded, in the order they finished.
+func (e *Engine) runPlan(p *plan, project string, conditions conditionContext, out *statusPrinter) ([]stepReport, []int, error) {
+	reports := make([]stepReport, len(p.steps))
+	results := make(chan stepResult)
+
+	waiting := make([]int, len(p.steps))
+	var ready []int
+	for i, s := range p.steps {
+		waiting[i] = len(s.needs)
+		if waiting[i] == 0 {
+			ready = append(ready, i)
+		}
+	}
+
+	var completed []int
+	var failures []string
+	var firstErr error
+	running := 0
+	for {
+		for running < e.parallelism() && len(ready) > 0 {
+			i := ready[0]
+			ready = ready[1:]
+			running++
+			go func(i int) {
+				report, err := e.runStep(p.steps[i].cmd, project, conditions, out)
+				results <- stepResult{index: i, report: report, err: err}
+			}(i)
+		}
+		if running == 0 {
+			break
+		}
+
+		result := <-results
+		running--
+		reports[result.index] = result.report
+		s := p.steps[result.index]
+		switch {
+		case result.err != nil && s.cmd.Optional:
+			out.printf("  ⚠ Optional command failed: %s (%v)\n", s.cmd.Name, result.err)
+		case result.err != nil:
+			if firstErr == nil {
+				firstErr = fmt.Errorf("command '%s' failed: %w", s.cmd.Name, result.err)
+			}
+			failures = append(failures, s.cmd.Name)
+			p.skipDependents(result.index, s.cmd.Name, reports, out)
+			continue
+		case result.report.outcome == stepSucceeded:
+			completed = append(completed, result.index)
+		}
+
+		for _, dependent := range s.dependents {
+			waiting[dependent]--
+			if waiting[dependent] == 0 && reports[dependent].outcome != stepBlocked {
+				ready = append(ready, dependent)
+			}
+		}
+		// Prefer steps in the order they are declared
+		sort.Ints(ready)
+	}
+
+	if len(failures) > 1 {
+		return reports, completed, fmt.Errorf("%w (%d commands failed: %s)", firstErr, len(failures), strings.Join(failures, ", "))
+	}
+	return reports, completed, firstErr
 }
 
-// ExecuteStopRituals executes stop rituals for the given project
-func (e *Engine) ExecuteStopRituals(project string) error {
-	fmt.Println("🔮 Executing stop rituals...")
+// skipDependents marks every step that needs the failed step, directly or not, as blocked
+func (p *plan) skipDependents(failed int, name string, reports []stepReport, out *statusPrinter) {
+	for _, dependent := range p.steps[failed].dependents {
+		if reports[dependent].outcome == stepBlocked {
+			continue
+		}
+		reports[dependent] = stepReport{outcome: stepBlocked, detail: fmt.Sprintf("needs %s, which failed", name)}
+		out.printf("  ⏭  %s (skipped: needs %s, which failed)\n", p.steps[dependent].cmd.Name, name)
+		p.skipDependents(dependent, name, reports, out)
+	}
+}
 
-	// Execute project-specific stop rituals first
-	if projectCommands, exists := e.config.Rituals.Stop.PerProject[project]; exists {
-		if err := e.executeCommands(projectCommands, project); err != nil {
-			return fmt.Errorf("failed to execute project stop rituals: %w", err)
+// undoSteps runs the undo commands of completed steps, most recently finished first
+func (e *Engine) undoSteps(p *plan, completed []int, reports []stepReport, out *statusPrinter) {
+	header := false
+	for i := len(completed) - 1; i >= 0; i-- {
+		cmd := p.steps[completed[i]].cmd
+		if cmd.Undo == "" {
+			continue
+		}
+		if !header {
+			out.printf("↩ Undoing completed steps...\n")
+			header = true
 		}
+		reports[completed[i]].undo = e.runCompensation(cmd, "undo", cmd.Undo, out)
 	}
+}
 
-	// Execute global stop rituals
-	if err := e.executeCommands(e.config.Rituals.Stop.Global, "global"); err != nil {
-		return fmt.Errorf("failed to execute global stop rituals: %w", err)
+// runFinally runs the finally commands in order, all of them even if some fail
+func (e *Engine) runFinally(finally []config.Command, project string, conditions conditionContext, out *statusPrinter) ([]stepReport, error) {
+	if len(finally) == 0 {
+		return nil, nil
 	}
+	out.printf("🧹 Running finally commands...\n")
+
+	reports := make([]stepReport, len(finally))
+	var firstErr error
+	for i, cmd := range finally {
+		report, err := e.runStep(cmd, project, conditions, out)
+		reports[i] = report
+		if err == nil {
+			continue
+		}
+		if cmd.Optional {
+			out.printf("  ⚠ Optional command failed: %s (%v)\n", cmd.Name, err)
+		} else if firstErr == nil {
+			firstErr = fmt.Errorf("finally command '%s' failed: %w", cmd.Name, err)
+		}
+	}
+	return reports, firstErr
+}
 
-	return nil
+// runStep runs a command unless its when conditions skip it or it is not
+// confirmed, asking for its prompt first and running its on_failure command if it fails
+func (e *Engine) runStep(cmd config.Command, project string, conditions conditionContext, out *statusPrinter) (stepReport, error) {
+	run, reason, err := conditions.shouldRun(cmd)
+	if err == nil && run && cmd.Confirm {
+		run, reason = e.confirm(cmd, out)
+	}
+	if err == nil && !run {
+		out.printf("  ⏭  %s (skipped: %s)\n", cmd.Name, reason)
+		return stepReport{outcome: stepSkipped, detail: reason}, nil
+	}
+	if err == nil && cmd.Prompt != nil {
+		e.ask(*cmd.Prompt, out)
+		if strings.TrimSpace(cmd.Command) == "" {
+			return stepReport{outcome: stepSucceeded}, nil
+		}
+	}
+	var result execution
+	if err != nil {
+		out.printf("  ❌ %s: %v\n", cmd.Name, err)
+		result.exitCode = -1
+	} else {
+		result, err = e.executeCommand(cmd, project, out)
+	}
+	if err == nil {
+		return stepReport{outcome: stepSucceeded, run: result}, nil
+	}
+
+	report := stepReport{outcome: stepFailed, detail: err.Error(), run: result}
+	if cmd.Optional {
+		report.outcome = stepFailedOptional
+	}
+	if cmd.OnFailure != "" {
+		report.onFailure = e.runCompensation(cmd, "on_failure", cmd.OnFailure, out)
+	}
+	return report, err
 }
 
-// executeCommands executes a list of commands
-func (e *Engine) executeCommands(commands []config.Command, scope string) error {
-	for _, cmd := range commands {
-		if err := e.executeCommand(cmd, scope); err != nil {
-			if cmd.Optional {
-				fmt.Printf("⚠ Optional command failed: %s (%v)\n", cmd.Name, err)
-				continue
-			}
-			return fmt.Errorf("command '%s' failed: %w", cmd.Name, err)
+// runCompensation runs a step's undo or on_failure command with the step's
+// shell, directory, environment and timeout, and describes how it went
+func (e *Engine) runCompensation(cmd config.Command, kind, command string, out *statusPrinter) string {
+	compensation := config.Command{
+		Name:    cmd.Name,
+		Command: command,
+		Shell:   cmd.Shell,
+		Dir:     cmd.Dir,
+		Env:     cmd.Env,
+		Timeout: cmd.Timeout,
+	}
+	result, err := runAttempt(compensation, e.environ())
+	if err != nil {
+		message := fmt.Sprintf("  ❌ %s %s: %v\n", cmd.Name, kind, err)
+		if len(result.combined) > 0 {
+			message += fmt.Sprintf("    Output: %s\n", strings.TrimSpace(string(result.combined)))
 		}
+		out.printf("%s", message)
+		return fmt.Sprintf("failed: %v", err)
 	}
-	return nil
+	out.printf("  ↩ %s %s ✓\n", cmd.Name, kind)
+	return "ran"
 }
 
-// executeCommand executes a single command
-func (e *Engine) executeCommand(cmd config.Command, _ string) error {
-	fmt.Printf("  ⚡ %s...", cmd.Name)
+// processName returns the name a background command is tracked under: its id, or its name in lowercase with dashes
+func processName(cmd config.Command) string {
+	if cmd.ID != "" {
+		return cmd.ID
+	}
+	return procs.Name(cmd.Name)
+}
 
-	// Create context with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
+// sessionID returns the ID of the session the rituals run for, or "" without one
+func (e *Engine) sessionID() string {
+	if e.session == nil {
+		return ""
+	}
+	return e.session.ID
+}
 
-	// Parse command and arguments
-	parts := strings.Fields(cmd.Command)
-	if len(parts) == 0 {
-		return fmt.Errorf("empty command")
+// killBackground stops the background processes that rituals started for
+// the project in the same session, leaving those of other sessions alone
+func (e *Engine) killBackground(project string) {
+	processes, err := procs.List()
+	if err != nil {
+		fmt.Fprintf(e.out, "⚠ Failed to list background processes: %v\n", err)
+		return
 	}
 
-	// Create the command
-	execCmd := exec.CommandContext(ctx, parts[0], parts[1:]...)
+	for _, proc := range processes {
+		if proc.Project != project || proc.SessionID != e.sessionID() {
+			continue
+		}
+		if _, err := procs.Kill(proc.Name, procs.DefaultGracePeriod); err != nil {
+			fmt.Fprintf(e.out, "  ⚠ %v\n", err)
+			continue
+		}
+		fmt.Fprintf(e.out, "  🛑 Stopped %s (pid %d)\n", proc.Name, proc.PID)
+	}
+}
 
-	// Set up environment
-	execCmd.Env = os.Environ()
+// statusPrinter writes status lines of steps running at the same time without interleaving them
+type statusPrinter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// printf writes one status message
+func (p *statusPrinter) printf(format string, args ...any) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	fmt.Fprintf(p.w, format, args...)
+}
+
+// execution is what running a step's command produced
+type execution struct {
+	attempts int
+	exitCode int
+	duration time.Duration
+	// stdout and stderr are those of the last attempt
+	stdout []byte
+	stderr []byte
+}
+
+// executeCommand executes a single command for a project, retrying failed attempts with backoff
+func (e *Engine) executeCommand(cmd config.Command, project string, out *statusPrinter) (execution, error) {
+	out.printf("  ⚡ %s...\n", cmd.Name)
+	started := time.Now()
 
 	if cmd.Background {
-		// For background commands, just start them
-		if err := execCmd.Start(); err != nil {
-			fmt.Printf(" ❌\n")
-			return err
+		// Background commands outlive the ritual, so they get no timeout
+		execCmd, err := prepareCommand(context.Background(), cmd, e.environ())
+		var proc *procs.Process
+		if err == nil {
+			proc, err = procs.Start(execCmd, procs.Process{Name: processName(cmd), Command: cmd.Command, Project: project, SessionID: e.sessionID()})
 		}
-		fmt.Printf(" ✓ (background)\n")
-		return nil
+		if err != nil {
+			out.printf("  ❌ %s: %v\n", cmd.Name, err)
+			return execution{attempts: 1, exitCode: -1, duration: time.Since(started)}, err
+		}
+		out.printf("  ✓ %s (background, pid %d; see rune logs %s)\n", cmd.Name, proc.PID, proc.Name)
+		return execution{attempts: 1, duration: time.Since(started)}, nil
 	}
 
-	// For foreground commands, wait for completion
-	output, err := execCmd.CombinedOutput()
-	if err != nil {
-		fmt.Printf(" ❌\n")
+	delay := cmd.RetryDelay
+	if delay == 0 {
+		delay = config.DefaultRetryDelay
+	}
+
+	for attempt := 0; ; attempt++ {
+		attemptStarted := time.Now()
+		result, err := runAttempt(cmd, e.environ())
+		elapsed := time.Since(attemptStarted).Round(100 * time.Millisecond)
+		run := execution{
+			attempts: attempt + 1,
+			exitCode: result.exitCode,
+			duration: time.Since(started),
+			stdout:   result.stdout,
+			stderr:   result.stderr,
+		}
+		output := result.combined
+		if err == nil {
+			message := fmt.Sprintf("  ✓ %s (%s)\n", cmd.Name, elapsed)
+			// Show output if verbose mode is enabled
+			if len(output) > 0 && shouldShowOutput(string(output)) {
+				message += fmt.Sprintf("    %s\n", strings.TrimSpace(string(output)))
+			}
+			out.printf("%s", message)
+			return run, nil
+		}
+
+		message := fmt.Sprintf("  ❌ %s: %v\n", cmd.Name, err)
 		if len(output) > 0 {
-			fmt.Printf("    Output: %s\n", strings.TrimSpace(string(output)))
+			message += fmt.Sprintf("    Output: %s\n", strings.TrimSpace(string(output)))
 		}
-		return err
+		if attempt >= cmd.Retries {
+			out.printf("%s", message)
+			return run, err
+		}
+
+		out.printf("%s    ↻ Retrying in %s (%d/%d)\n", message, delay, attempt+1, cmd.Retries)
+		time.Sleep(delay)
+		delay *= 2
 	}
+}
 
-	fmt.Printf(" ✓\n")
+// attemptResult is the output and exit code of running a command once
+type attemptResult struct {
+	stdout   []byte
+	stderr   []byte
+	combined []byte
+	// exitCode is -1 when the command could not start, timed out or was killed
+	exitCode int
+}
 
-	// Show output if verbose mode is enabled
-	if len(output) > 0 && shouldShowOutput(string(output)) {
-		fmt.Printf("    %s\n", strings.TrimSpace(string(output)))
+// runAttempt runs a foreground command once within its timeout, in the given environment
+func runAttempt(cmd config.Command, environ []string) (attemptResult, error) {
+	timeout := cmd.Timeout
+	if timeout == 0 {
+		timeout = config.DefaultCommandTimeout
 	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
 
-	return nil
+	execCmd, err := prepareCommand(ctx, cmd, environ)
+	if err != nil {
+		return attemptResult{exitCode: -1}, err
+	}
+	// Don't wait on children of a killed shell that still hold the output open
+	execCmd.WaitDelay = time.Second
+
+	var stdout, stderr bytes.Buffer
+	combined := &syncBuffer{}
+	execCmd.Stdout = io.MultiWriter(&stdout, combined)
+	execCmd.Stderr = io.MultiWriter(&stderr, combined)
+	err = execCmd.Run()
+
+	result := attemptResult{stdout: stdout.Bytes(), stderr: stderr.Bytes(), combined: combined.Bytes(), exitCode: -1}
+	if ctx.Err() == context.DeadlineExceeded {
+		return result, fmt.Errorf("timed out after %s", timeout)
+	}
+	if execCmd.ProcessState != nil {
+		result.exitCode = execCmd.ProcessState.ExitCode()
+	}
+	return result, err
+}
+
+// syncBuffer is a buffer that stdout and stderr can be copied into at the same time
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+// Write appends to the buffer
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+// Bytes returns the buffer's contents
+func (b *syncBuffer) Bytes() []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Bytes()
+}
+
+// prepareCommand builds a command with its working directory and environment
+func prepareCommand(ctx context.Context, cmd config.Command, environ []string) (*exec.Cmd, error) {
+	// Create the command, through the configured shell unless it is "none",
+	// with the environment it runs with so "none" expands from it too
+	env := commandEnv(environ, cmd.Env)
+	execCmd, err := buildCommand(ctx, cmd, env)
+	if err != nil {
+		return nil, err
+	}
+
+	if cmd.Dir != "" {
+		dir, err := expandDir(cmd.Dir)
+		if err != nil {
+			return nil, err
+		}
+		execCmd.Dir = dir
+	}
+	execCmd.Env = env
+
+	return execCmd, nil
+}
+
+// commandEnv returns the parent environment with a command's variables added.
+// Values are expanded against the parent environment, so PATH: "${PATH}:./bin" works.
+func commandEnv(parent []string, vars map[string]string) []string {
+	if len(vars) == 0 {
+		return parent
+	}
+
+	lookup := make(map[string]string, len(parent))
+	for _, kv := range parent {
+		if name, value, ok := strings.Cut(kv, "="); ok {
+			lookup[name] = value
+		}
+	}
+
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	env := make([]string, 0, len(parent)+len(vars))
+	for _, kv := range parent {
+		name, _, _ := strings.Cut(kv, "=")
+		if _, overridden := vars[name]; overridden {
+			continue
+		}
+		env = append(env, kv)
+	}
+	for _, name := range names {
+		env = append(env, name+"="+os.Expand(vars[name], func(key string) string { return lookup[key] }))
+	}
+	return env
+}
+
+// expandDir expands ~ and environment variables in a working directory and checks it exists
+func expandDir(dir string) (string, error) {
+	dir = os.ExpandEnv(dir)
+	if dir == "~" || strings.HasPrefix(dir, "~/") {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to get home directory: %w", err)
+		}
+		dir = filepath.Join(home, strings.TrimPrefix(dir, "~"))
+	}
+
+	info, err := os.Stat(dir)
+	if err != nil {
+		return "", fmt.Errorf("working directory %s: %w", dir, err)
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("working directory %s is not a directory", dir)
+	}
+	return dir, nil
 }
 
 // shouldShowOutput determines if command output should be displayed
@@ -148,44 +636,118 @@ func shouldShowOutput(output string) bool {
 	return true
 }
 
-// TestRitual tests a ritual without executing it
+// TestRitual tests a ritual without executing it, printing its execution plan
 func (e *Engine) TestRitual(ritualType string, project string) error {
-	fmt.Printf("🧪 Testing %s ritual for project: %s\n", ritualType, project)
-
-	var commands []config.Command
+	fmt.Fprintf(e.out, "🧪 Testing %s ritual for project: %s\n", ritualType, project)
 
-	switch ritualType {
-	case "start":
-		commands = append(commands, e.config.Rituals.Start.Global...)
-		if projectCommands, exists := e.config.Rituals.Start.PerProject[project]; exists {
-			commands = append(commands, projectCommands...)
-		}
-	case "stop":
-		if projectCommands, exists := e.config.Rituals.Stop.PerProject[project]; exists {
-			commands = append(commands, projectCommands...)
-		}
-		commands = append(commands, e.config.Rituals.Stop.Global...)
-	default:
-		return fmt.Errorf("unknown ritual type: %s", ritualType)
+	set, ok := e.config.Rituals.Ritual(ritualType)
+	if !ok {
+		return fmt.Errorf("unknown ritual: %s", ritualType)
+	}
+	sections, finally, err := e.expandTemplates(ritualSections(ritualType, set, project), set.Finally, project)
+	if err != nil {
+		return fmt.Errorf("invalid %s rituals: %w", ritualType, err)
 	}
 
-	if len(commands) == 0 {
-		fmt.Println("  No commands configured for this ritual")
+	p, err := newPlan(sections...)
+	if err != nil {
+		return fmt.Errorf("invalid %s rituals: %w", ritualType, err)
+	}
+	if len(p.steps) == 0 && len(finally) == 0 && !set.KillBackground {
+		fmt.Fprintln(e.out, "  No commands configured for this ritual")
 		return nil
 	}
 
-	fmt.Println("Commands that would be executed:")
-	for i, cmd := range commands {
-		optional := ""
-		background := ""
-		if cmd.Optional {
-			optional = " (optional)"
+	conditions := e.currentConditions()
+	if len(p.steps) > 0 || len(finally) > 0 {
+		fmt.Fprintf(e.out, "Execution plan (up to %d at a time):\n", e.parallelism())
+	}
+	n := 0
+	for stage, indexes := range p.stages() {
+		fmt.Fprintf(e.out, "  Stage %d:\n", stage+1)
+		for _, i := range indexes {
+			n++
+			e.printStep(p.steps[i].cmd, p.needNames(i), n, conditions)
 		}
-		if cmd.Background {
-			background = " (background)"
+	}
+
+	if len(finally) > 0 {
+		fmt.Fprintln(e.out, "  Finally:")
+		for _, cmd := range finally {
+			n++
+			e.printStep(cmd, nil, n, conditions)
 		}
-		fmt.Printf("  %d. %s: %s%s%s\n", i+1, cmd.Name, cmd.Command, optional, background)
+	}
+	if set.KillBackground {
+		fmt.Fprintf(e.out, "  Then stops the background processes started for %s\n", project)
 	}
 
 	return nil
 }
+
+// needNames returns the names of the steps a step needs
+func (p *plan) needNames(i int) []string {
+	names := make([]string, 0, len(p.steps[i].needs))
+	for _, need := range p.steps[i].needs {
+		names = append(names, p.steps[need].cmd.Name)
+	}
+	return names
+}
+
+// printStep prints one step of an execution plan
+func (e *Engine) printStep(cmd config.Command, needs []string, n int, conditions conditionContext) {
+	optional := ""
+	background := ""
+	shell := ""
+	if cmd.Optional {
+		optional = " (optional)"
+	}
+	if cmd.Background {
+		background = " (background)"
+	}
+	if cmd.Shell != "" {
+		shell = fmt.Sprintf(" (shell: %s)", cmd.Shell)
+	}
+	name := cmd.Name
+	if cmd.ID != "" {
+		name = fmt.Sprintf("%s [%s]", cmd.Name, cmd.ID)
+	}
+	confirm := ""
+	if cmd.Confirm {
+		confirm = " (confirm)"
+	}
+	fmt.Fprintf(e.out, "    %d. %s: %s%s%s%s%s\n", n, name, cmd.Command, optional, background, shell, confirm)
+	if cmd.Prompt != nil {
+		fmt.Fprintf(e.out, "       asks for %s\n", cmd.Prompt.Var)
+	}
+	if len(needs) > 0 {
+		fmt.Fprintf(e.out, "       after %s\n", strings.Join(needs, ", "))
+	}
+	if cmd.Dir != "" {
+		fmt.Fprintf(e.out, "       in %s\n", cmd.Dir)
+	}
+	if cmd.Timeout != 0 || cmd.Retries != 0 {
+		timeout := cmd.Timeout
+		if timeout == 0 {
+			timeout = config.DefaultCommandTimeout
+		}
+		fmt.Fprintf(e.out, "       timeout %s, %d retries\n", timeout, cmd.Retries)
+	}
+	if cmd.OnFailure != "" {
+		fmt.Fprintf(e.out, "       on failure: %s\n", cmd.OnFailure)
+	}
+	if cmd.Undo != "" {
+		fmt.Fprintf(e.out, "       undo: %s\n", cmd.Undo)
+	}
+	if !cmd.When.IsZero() {
+		run, reason, err := conditions.shouldRun(cmd)
+		switch {
+		case err != nil:
+			fmt.Fprintf(e.out, "       ⚠ conditions could not be checked: %v\n", err)
+		case !run:
+			fmt.Fprintf(e.out, "       ⏭ skipped: %s\n", reason)
+		default:
+			fmt.Fprintf(e.out, "       ✓ conditions met\n")
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package rituals
+
+import (
+	"fmt"
+
+	"github.com/ferg-cod3s/rune/internal/config"
+	"github.com/ferg-cod3s/rune/internal/tracking"
+)
+
+// PauseHook returns the ritual to run when a session pauses for reason:
+// break_start for breaks when it is set, and pause otherwise
+func PauseHook(hooks config.RitualHooks, reason tracking.PauseReason) string {
+	if reason == tracking.PauseBreak && hooks.BreakStart != "" {
+		return hooks.BreakStart
+	}
+	return hooks.Pause
+}
+
+// ResumeHook returns the ritual to run when a session resumes: break_end
+// after a break when it is set, and resume otherwise
+func ResumeHook(hooks config.RitualHooks, session *tracking.Session) string {
+	if session.LastPauseReason() == tracking.PauseBreak && hooks.BreakEnd != "" {
+		return hooks.BreakEnd
+	}
+	return hooks.Resume
+}
+
+// ExecuteHook runs the ritual a hook names, if any, for the session's project
+func (e *Engine) ExecuteHook(ritual string, session *tracking.Session) error {
+	if ritual == "" {
+		return nil
+	}
+	e.SetSession(session)
+	if err := e.ExecuteRitual(ritual, session.Project); err != nil {
+		return fmt.Errorf("%s ritual failed: %w", ritual, err)
+	}
+	return nil
+}
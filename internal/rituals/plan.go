@@ -0,0 +1,94 @@
+package rituals
+
+import (
+	"github.com/ferg-cod3s/rune/internal/config"
+)
+
+// section is a list of ritual commands from one scope, global or a project
+type section struct {
+	scope    string
+	commands []config.Command
+}
+
+// step is a command scheduled in a plan
+type step struct {
+	cmd        config.Command
+	scope      string
+	needs      []int
+	dependents []int
+}
+
+// plan is the dependency graph of the commands run by one ritual
+type plan struct {
+	steps []*step
+}
+
+// newPlan builds the dependency graph of the given sections, in order.
+// When no command declares needs, each command needs the one before it, so
+// rituals without dependencies keep running one after another.
+func newPlan(sections ...section) (*plan, error) {
+	var commands []config.Command
+	p := &plan{}
+	for _, sec := range sections {
+		for _, cmd := range sec.commands {
+			commands = append(commands, cmd)
+			p.steps = append(p.steps, &step{cmd: cmd, scope: sec.scope})
+		}
+	}
+	if err := config.CheckNeeds(commands); err != nil {
+		return nil, err
+	}
+
+	ids := make(map[string]int)
+	hasNeeds := false
+	for i, cmd := range commands {
+		if cmd.ID != "" {
+			ids[cmd.ID] = i
+		}
+		hasNeeds = hasNeeds || len(cmd.Needs) > 0
+	}
+
+	for i, s := range p.steps {
+		switch {
+		case hasNeeds:
+			for _, need := range s.cmd.Needs {
+				s.needs = append(s.needs, ids[need])
+			}
+		case i > 0:
+			s.needs = []int{i - 1}
+		}
+		for _, need := range s.needs {
+			p.steps[need].dependents = append(p.steps[need].dependents, i)
+		}
+	}
+	return p, nil
+}
+
+// stages groups steps by how many steps must run before them: every step in a
+// stage can start once the earlier stages are done. Steps keep their order within a stage.
+func (p *plan) stages() [][]int {
+	depth := make([]int, len(p.steps))
+	var resolve func(i int) int
+	resolve = func(i int) int {
+		if depth[i] > 0 {
+			return depth[i]
+		}
+		depth[i] = 1
+		for _, need := range p.steps[i].needs {
+			if d := resolve(need) + 1; d > depth[i] {
+				depth[i] = d
+			}
+		}
+		return depth[i]
+	}
+
+	var stages [][]int
+	for i := range p.steps {
+		d := resolve(i)
+		for len(stages) < d {
+			stages = append(stages, nil)
+		}
+		stages[d-1] = append(stages[d-1], i)
+	}
+	return stages
+}
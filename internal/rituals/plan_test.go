@@ -0,0 +1,325 @@
+package rituals
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ferg-cod3s/rune/internal/config"
+	"github.com/ferg-cod3s/rune/internal/tracking"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewPlan(t *testing.T) {
+	t.Run("commands without needs run in order", func(t *testing.T) {
+		p, err := newPlan(
+			section{scope: "global", commands: []config.Command{{Name: "a"}, {Name: "b"}}},
+			section{scope: "app", commands: []config.Command{{Name: "c"}}},
+		)
+		require.NoError(t, err)
+		assert.Equal(t, [][]int{{0}, {1}, {2}}, p.stages())
+		assert.Equal(t, "app", p.steps[2].scope)
+	})
+
+	t.Run("needs form stages", func(t *testing.T) {
+		p, err := newPlan(
+			section{scope: "global", commands: []config.Command{
+				{ID: "api", Name: "Pull api"},
+				{ID: "web", Name: "Pull web"},
+				{ID: "docker", Name: "Start Docker"},
+			}},
+			section{scope: "app", commands: []config.Command{
+				{ID: "up", Name: "Compose up", Needs: config.StringList{"docker", "api"}},
+				{Name: "Open browser", Needs: config.StringList{"up", "web"}},
+			}},
+		)
+		require.NoError(t, err)
+		assert.Equal(t, [][]int{{0, 1, 2}, {3}, {4}}, p.stages())
+		assert.Equal(t, []int{3}, p.steps[2].dependents)
+	})
+
+	t.Run("cycle", func(t *testing.T) {
+		_, err := newPlan(section{scope: "global", commands: []config.Command{
+			{ID: "a", Name: "a", Needs: config.StringList{"b"}},
+			{ID: "b", Name: "b", Needs: config.StringList{"a"}},
+		}})
+		assert.ErrorContains(t, err, "dependency cycle: a -> b -> a")
+	})
+}
+
+func TestRunPlan(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("ritual tests use POSIX sh")
+	}
+
+	run := func(t *testing.T, parallelism int, commands ...config.Command) (string, error) {
+		t.Helper()
+		for i := range commands {
+			commands[i].Shell = "sh"
+		}
+		var out bytes.Buffer
+		engine := NewEngine(&config.Config{Rituals: config.Rituals{Parallelism: parallelism}})
+		engine.out = &out
+		p, err := newPlan(section{scope: "global", commands: commands})
+		require.NoError(t, err)
+		err = engine.runRitual(p, "start", "app", nil)
+		return out.String(), err
+	}
+
+	t.Run("independent steps run in parallel", func(t *testing.T) {
+		dir := t.TempDir()
+		// Each step waits until all three have started
+		barrier := func(name string) config.Command {
+			return config.Command{ID: name, Name: name, Command: fmt.Sprintf(
+				`cd %q && touch %s && for i in $(seq 50); do [ -e a ] && [ -e b ] && [ -e c ] && exit 0; sleep 0.1; done; exit 1`,
+				dir, name)}
+		}
+		last := config.Command{Name: "last", Command: "true", Needs: config.StringList{"a", "b", "c"}}
+
+		output, err := run(t, 3, barrier("a"), barrier("b"), barrier("c"), last)
+		require.NoError(t, err, output)
+		assert.Contains(t, output, "✓ last")
+	})
+
+	t.Run("failure skips dependents but not independent steps", func(t *testing.T) {
+		marker := filepath.Join(t.TempDir(), "independent")
+		output, err := run(t, 2,
+			config.Command{ID: "build", Name: "build", Command: "exit 1"},
+			config.Command{ID: "test", Name: "test", Command: "true", Needs: config.StringList{"build"}},
+			config.Command{Name: "deploy", Command: "true", Needs: config.StringList{"test"}},
+			config.Command{Name: "independent", Command: fmt.Sprintf("touch %q", marker)},
+		)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "command 'build' failed")
+		assert.Contains(t, output, "⏭  test (skipped: needs build, which failed)")
+		assert.Contains(t, output, "⏭  deploy (skipped: needs build, which failed)")
+		assert.FileExists(t, marker)
+	})
+
+	t.Run("optional failure lets dependents run", func(t *testing.T) {
+		output, err := run(t, 1,
+			config.Command{ID: "lint", Name: "lint", Command: "exit 1", Optional: true},
+			config.Command{Name: "build", Command: "true", Needs: config.StringList{"lint"}},
+		)
+		require.NoError(t, err)
+		assert.Contains(t, output, "⚠ Optional command failed: lint")
+		assert.Contains(t, output, "✓ build")
+	})
+
+	t.Run("without needs a failure stops the ritual", func(t *testing.T) {
+		marker := filepath.Join(t.TempDir(), "after")
+		output, err := run(t, 4,
+			config.Command{Name: "first", Command: "exit 1"},
+			config.Command{Name: "second", Command: fmt.Sprintf("touch %q", marker)},
+		)
+		require.Error(t, err)
+		assert.Contains(t, output, "⏭  second (skipped: needs first, which failed)")
+		_, statErr := os.Stat(marker)
+		assert.True(t, os.IsNotExist(statErr))
+	})
+}
+
+func TestRunRitual_UndoAndFinally(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("ritual tests use POSIX sh")
+	}
+	log := filepath.Join(t.TempDir(), "log")
+	record := func(text string) string { return fmt.Sprintf("echo %s >> %q", text, log) }
+
+	var out bytes.Buffer
+	engine := NewEngine(&config.Config{})
+	engine.out = &out
+	p, err := newPlan(section{scope: "global", commands: []config.Command{
+		{Name: "db", Shell: "sh", Command: record("db-up"), Undo: record("db-down")},
+		{Name: "cache", Shell: "sh", Command: record("cache-up"), Undo: record("cache-down")},
+		{Name: "lint", Shell: "sh", Command: "exit 1", Optional: true, Undo: record("lint-undo")},
+		{Name: "migrate", Shell: "sh", Command: "exit 2", OnFailure: record("migrate-cleanup")},
+		{Name: "serve", Shell: "sh", Command: record("serve"), Undo: record("serve-down")},
+	}})
+	require.NoError(t, err)
+
+	err = engine.runRitual(p, "start", "app", []config.Command{
+		{Name: "notify", Shell: "sh", Command: record("notify")},
+		{Name: "broken", Shell: "sh", Command: "exit 3", Optional: true},
+		{Name: "report", Shell: "sh", Command: record("report")},
+	})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "command 'migrate' failed")
+
+	data, readErr := os.ReadFile(log)
+	require.NoError(t, readErr)
+	assert.Equal(t, []string{"db-up", "cache-up", "migrate-cleanup", "cache-down", "db-down", "notify", "report"},
+		strings.Fields(string(data)))
+
+	summary := out.String()[strings.Index(out.String(), "Summary:"):]
+	assert.Equal(t, []string{
+		"Summary:",
+		"  ✓ db",
+		"     undo ran",
+		"  ✓ cache",
+		"     undo ran",
+		"  ⚠ lint: exit status 1 (optional)",
+		"  ❌ migrate: exit status 2",
+		"     on_failure ran",
+		"  ⏭  serve: not run (needs migrate, which failed)",
+		"  ✓ finally: notify",
+		"  ⚠ finally: broken: exit status 3 (optional)",
+		"  ✓ finally: report",
+	}, strings.Split(strings.TrimSpace(summary), "\n"))
+}
+
+func TestRunRitual_FinallyFailure(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("ritual tests use POSIX sh")
+	}
+	var out bytes.Buffer
+	engine := NewEngine(&config.Config{})
+	engine.out = &out
+	p, err := newPlan(section{scope: "global", commands: []config.Command{{Name: "ok", Shell: "sh", Command: "true", Undo: "false"}}})
+	require.NoError(t, err)
+
+	err = engine.runRitual(p, "start", "app", []config.Command{{Name: "cleanup", Shell: "sh", Command: "exit 1"}})
+	assert.ErrorContains(t, err, "finally command 'cleanup' failed")
+	// Undo only runs when a step fails
+	assert.NotContains(t, out.String(), "Undoing")
+}
+
+type fakeRecorder struct {
+	runs []*tracking.RitualRun
+}
+
+func (r *fakeRecorder) RecordRitualRun(run *tracking.RitualRun) error {
+	run.ID = "ritual_1"
+	r.runs = append(r.runs, run)
+	return nil
+}
+
+func TestRunRitual_RecordsRun(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("ritual tests use POSIX sh")
+	}
+	var out bytes.Buffer
+	recorder := &fakeRecorder{}
+	engine := NewEngine(&config.Config{})
+	engine.out = &out
+	engine.RecordRuns(recorder)
+	engine.SetSession(&tracking.Session{ID: "session_1"})
+	p, err := newPlan(section{scope: "global", commands: []config.Command{
+		{Name: "greet", Shell: "sh", Command: "echo hello"},
+		{Name: "check", Shell: "sh", Command: "echo broken >&2; exit 3"},
+		{Name: "after", Shell: "sh", Command: "true"},
+	}})
+	require.NoError(t, err)
+
+	err = engine.runRitual(p, "stop", "app", []config.Command{{Name: "cleanup", Shell: "sh", Command: "true"}})
+	require.Error(t, err)
+	assert.Contains(t, out.String(), "rune ritual show ritual_1")
+
+	require.Len(t, recorder.runs, 1)
+	run := recorder.runs[0]
+	assert.Equal(t, "stop", run.Ritual)
+	assert.Equal(t, "app", run.Project)
+	assert.Equal(t, "session_1", run.SessionID)
+	assert.Equal(t, err.Error(), run.Error)
+	require.Len(t, run.Steps, 4)
+
+	assert.Equal(t, tracking.StepSucceeded, run.Steps[0].Outcome)
+	assert.Equal(t, "hello\n", run.Steps[0].Stdout)
+	assert.Equal(t, tracking.StepFailed, run.Steps[1].Outcome)
+	assert.Equal(t, 3, run.Steps[1].ExitCode)
+	assert.Equal(t, "broken\n", run.Steps[1].Stderr)
+	assert.Equal(t, tracking.StepBlocked, run.Steps[2].Outcome)
+	assert.Equal(t, "cleanup", run.Steps[3].Name)
+	assert.True(t, run.Steps[3].Finally)
+}
+
+func TestExecuteRitual_Custom(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("ritual tests use POSIX sh")
+	}
+	log := filepath.Join(t.TempDir(), "log")
+	record := func(text string) string { return fmt.Sprintf("echo %s >> %q", text, log) }
+
+	var out bytes.Buffer
+	recorder := &fakeRecorder{}
+	engine := NewEngine(&config.Config{Rituals: config.Rituals{Custom: map[string]config.RitualSet{
+		"lunch": {
+			Global:     []config.Command{{Name: "lock", Shell: "sh", Command: record("lock")}},
+			PerProject: map[string][]config.Command{"app": {{Name: "stash", Shell: "sh", Command: record("stash")}}},
+		},
+	}}})
+	engine.out = &out
+	engine.RecordRuns(recorder)
+
+	require.NoError(t, engine.ExecuteRitual("lunch", "app"))
+	data, err := os.ReadFile(log)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"lock", "stash"}, strings.Fields(string(data)))
+	assert.Contains(t, out.String(), "🔮 Executing lunch ritual...")
+	require.Len(t, recorder.runs, 1)
+	assert.Equal(t, "lunch", recorder.runs[0].Ritual)
+
+	assert.ErrorContains(t, engine.ExecuteRitual("dinner", "app"), "unknown ritual: dinner")
+	assert.ErrorContains(t, engine.TestRitual("dinner", "app"), "unknown ritual: dinner")
+}
+
+func TestExecuteRitual_Templates(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("ritual tests use POSIX sh")
+	}
+	log := filepath.Join(t.TempDir(), "log")
+	end := time.Now()
+	var out bytes.Buffer
+	engine := NewEngine(&config.Config{Rituals: config.Rituals{Stop: config.RitualSet{Global: []config.Command{{
+		Name:    "commit",
+		Shell:   "sh",
+		Command: fmt.Sprintf(`echo "WIP {{.Project}} {{.Duration}} {{.SessionID}} $DAY" >> %q`, log),
+		Env:     map[string]string{"DAY": "{{.Today}}"},
+	}}}}})
+	engine.out = &out
+	engine.SetSession(&tracking.Session{ID: "session_1", StartTime: end.Add(-90 * time.Minute), EndTime: &end, Duration: 90 * time.Minute})
+
+	require.NoError(t, engine.ExecuteStopRituals("api"), out.String())
+	data, err := os.ReadFile(log)
+	require.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("WIP api 1h30m session_1 %s\n", time.Now().Format("2006-01-02")), string(data))
+
+	out.Reset()
+	require.NoError(t, engine.TestRitual("stop", "api"))
+	assert.Contains(t, out.String(), `echo "WIP api 1h30m session_1 $DAY"`)
+
+	engine.config.Rituals.Stop.Global[0].Command = "echo {{.Nope}}"
+	assert.ErrorContains(t, engine.ExecuteStopRituals("api"), `invalid stop rituals: command "commit" has an invalid template in command`)
+}
+
+func TestTestRitual_PrintsPlan(t *testing.T) {
+	var out bytes.Buffer
+	engine := NewEngine(&config.Config{Rituals: config.Rituals{
+		Parallelism: 2,
+		Start: config.RitualSet{Global: []config.Command{
+			{ID: "pull", Name: "Pull", Command: "git pull"},
+			{ID: "docker", Name: "Docker", Command: "docker-compose up -d"},
+			{Name: "Serve", Command: "bun run dev", Needs: config.StringList{"pull", "docker"}},
+		}},
+	}})
+	engine.out = &out
+
+	require.NoError(t, engine.TestRitual("start", "app"))
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	assert.Equal(t, []string{
+		"🧪 Testing start ritual for project: app",
+		"Execution plan (up to 2 at a time):",
+		"  Stage 1:",
+		"    1. Pull [pull]: git pull",
+		"    2. Docker [docker]: docker-compose up -d",
+		"  Stage 2:",
+		"    3. Serve: bun run dev",
+		"       after Pull, Docker",
+	}, lines)
+}
@@ -0,0 +1,122 @@
+package rituals
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/ferg-cod3s/rune/internal/config"
+)
+
+// AssumeYes makes the engine answer yes to confirmations and use prompt
+// defaults without asking
+func (e *Engine) AssumeYes(yes bool) {
+	e.assumeYes = yes
+}
+
+// stdinIsTerminal reports whether rune can ask the user questions
+func stdinIsTerminal() bool {
+	info, err := os.Stdin.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// confirm asks whether to run a command that needs confirmation, and if not,
+// the reason it is skipped. Without a terminal the command is skipped.
+func (e *Engine) confirm(cmd config.Command, out *statusPrinter) (bool, string) {
+	if e.assumeYes {
+		return true, ""
+	}
+	if !e.interactive {
+		return false, "not confirmed, no terminal (use --yes)"
+	}
+
+	out.mu.Lock()
+	defer out.mu.Unlock()
+	for {
+		fmt.Fprintf(out.w, "  ❓ Run %s? [y/N]: ", cmd.Name)
+		answer, err := e.in.ReadString('\n')
+		if err != nil && answer == "" {
+			fmt.Fprintln(out.w)
+			return false, "not confirmed"
+		}
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "y", "yes":
+			return true, ""
+		case "n", "no", "":
+			return false, "declined"
+		default:
+			fmt.Fprintln(out.w, "  Please answer 'y' for yes or 'n' for no.")
+		}
+	}
+}
+
+// ask asks for the value of a command's prompt, falling back to its default,
+// and passes it to the commands that run from now on
+func (e *Engine) ask(prompt config.Prompt, out *statusPrinter) {
+	value := prompt.Default
+	if !e.assumeYes && e.interactive {
+		message := prompt.Message
+		if message == "" {
+			message = prompt.Var
+		}
+		out.mu.Lock()
+		if prompt.Default != "" {
+			fmt.Fprintf(out.w, "  ✏️  %s [%s]: ", message, prompt.Default)
+		} else {
+			fmt.Fprintf(out.w, "  ✏️  %s: ", message)
+		}
+		answer, err := e.in.ReadString('\n')
+		if err != nil && answer == "" {
+			fmt.Fprintln(out.w)
+		}
+		out.mu.Unlock()
+		if answer = strings.TrimSpace(answer); answer != "" {
+			value = answer
+		}
+	}
+
+	e.answersMu.Lock()
+	defer e.answersMu.Unlock()
+	if e.answers == nil {
+		e.answers = make(map[string]string)
+	}
+	e.answers[prompt.Var] = value
+}
+
+// getenv looks up a variable among the prompt answers, then the environment
+func (e *Engine) getenv(key string) string {
+	e.answersMu.Lock()
+	defer e.answersMu.Unlock()
+	if value, ok := e.answers[key]; ok {
+		return value
+	}
+	return os.Getenv(key)
+}
+
+// environ returns the environment with the prompt answers so far added
+func (e *Engine) environ() []string {
+	e.answersMu.Lock()
+	defer e.answersMu.Unlock()
+	if len(e.answers) == 0 {
+		return os.Environ()
+	}
+
+	names := make([]string, 0, len(e.answers))
+	for name := range e.answers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	env := make([]string, 0, len(names))
+	for _, kv := range os.Environ() {
+		name, _, _ := strings.Cut(kv, "=")
+		if _, answered := e.answers[name]; !answered {
+			env = append(env, kv)
+		}
+	}
+	for _, name := range names {
+		env = append(env, name+"="+e.answers[name])
+	}
+	return env
+}
@@ -0,0 +1,89 @@
+package rituals
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/ferg-cod3s/rune/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunRitual_ConfirmAndPrompt(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("ritual tests use POSIX sh")
+	}
+
+	commands := func(log string) []config.Command {
+		return []config.Command{
+			{Name: "note", Prompt: &config.Prompt{Var: "NOTE", Message: "Standup note", Default: "nothing"}},
+			{Name: "commit", Shell: "sh", Confirm: true, Command: fmt.Sprintf(`echo "commit $NOTE" >> %q`, log)},
+			{Name: "push", Shell: "sh", Confirm: true, Command: fmt.Sprintf(`echo push >> %q`, log)},
+			{Name: "save", Shell: "sh", Command: fmt.Sprintf(`echo "save $NOTE" >> %q`, log)},
+		}
+	}
+	run := func(t *testing.T, configure func(e *Engine)) (string, string) {
+		t.Helper()
+		log := filepath.Join(t.TempDir(), "log")
+		var out bytes.Buffer
+		engine := NewEngine(&config.Config{})
+		engine.out = &out
+		engine.interactive = false
+		configure(engine)
+		p, err := newPlan(section{scope: "global", commands: commands(log)})
+		require.NoError(t, err)
+		require.NoError(t, engine.runRitual(p, "stop", "app", nil), out.String())
+		data, err := os.ReadFile(log)
+		require.NoError(t, err)
+		return string(data), out.String()
+	}
+
+	t.Run("interactive answers", func(t *testing.T) {
+		log, output := run(t, func(e *Engine) {
+			e.interactive = true
+			e.in = bufio.NewReader(strings.NewReader("fixed $HOME bug\nmaybe\ny\nn\n"))
+		})
+		assert.Equal(t, "commit fixed $HOME bug\nsave fixed $HOME bug\n", log)
+		assert.Contains(t, output, "Standup note [nothing]: ")
+		assert.Contains(t, output, "Please answer 'y' for yes or 'n' for no.")
+		assert.Contains(t, output, "⏭  push (skipped: declined)")
+	})
+
+	t.Run("without a terminal", func(t *testing.T) {
+		log, output := run(t, func(e *Engine) {})
+		assert.Equal(t, "save nothing\n", log)
+		assert.Contains(t, output, "⏭  commit (skipped: not confirmed, no terminal (use --yes))")
+	})
+
+	t.Run("assume yes", func(t *testing.T) {
+		log, _ := run(t, func(e *Engine) { e.AssumeYes(true) })
+		assert.Equal(t, "commit nothing\npush\nsave nothing\n", log)
+	})
+}
+
+func TestRunRitual_PromptAnswerReachesDirectCommands(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("ritual tests use POSIX touch")
+	}
+
+	dir := t.TempDir()
+	var out bytes.Buffer
+	engine := NewEngine(&config.Config{})
+	engine.out = &out
+	engine.interactive = true
+	engine.in = bufio.NewReader(strings.NewReader("fixed bug\n"))
+
+	p, err := newPlan(section{scope: "global", commands: []config.Command{
+		{Name: "note", Prompt: &config.Prompt{Var: "NOTE", Message: "Standup note"}},
+		{Name: "save", Shell: "none", Command: fmt.Sprintf(`touch %q`, filepath.Join(dir, "note-$NOTE"))},
+	}})
+	require.NoError(t, err)
+	require.NoError(t, engine.runRitual(p, "stop", "app", nil), out.String())
+	assert.FileExists(t, filepath.Join(dir, "note-fixed bug"))
+}
@@ -0,0 +1,215 @@
+package rituals
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/ferg-cod3s/rune/internal/config"
+)
+
+// ShellNone runs a command directly from its quote-aware argument list, without a shell
+const ShellNone = "none"
+
+// buildCommand prepares a ritual command to run through its configured shell,
+// or directly when the shell is "none". A direct command's variables and
+// executable are resolved against env, the environment it will run with.
+func buildCommand(ctx context.Context, cmd config.Command, env []string) (*exec.Cmd, error) {
+	if strings.TrimSpace(cmd.Command) == "" {
+		return nil, fmt.Errorf("empty command")
+	}
+
+	shell := cmd.Shell
+	if shell == "" {
+		shell = defaultShell()
+	}
+
+	if shell == ShellNone {
+		getenv := envLookup(env)
+		args, err := splitArgs(cmd.Command, getenv)
+		if err != nil {
+			return nil, err
+		}
+		if len(args) == 0 {
+			return nil, fmt.Errorf("empty command")
+		}
+		execCmd := exec.CommandContext(ctx, lookPath(args[0], getenv("PATH")), args[1:]...)
+		execCmd.Args[0] = args[0]
+		return execCmd, nil
+	}
+
+	return exec.CommandContext(ctx, shell, append(shellFlags(shell), cmd.Command)...), nil
+}
+
+// envLookup returns a getenv over an environment list, where later
+// entries win as they do for exec
+func envLookup(env []string) func(string) string {
+	vars := make(map[string]string, len(env))
+	for _, kv := range env {
+		if name, value, ok := strings.Cut(kv, "="); ok {
+			vars[name] = value
+		}
+	}
+	return func(name string) string { return vars[name] }
+}
+
+// lookPath finds a bare executable name in the directories of path, the
+// command's own PATH rather than rune's. Names with a separator, and names
+// not found, are returned unchanged for exec to resolve or report.
+func lookPath(name, path string) string {
+	if strings.ContainsAny(name, `/\`) {
+		return name
+	}
+	for _, dir := range filepath.SplitList(path) {
+		if dir == "" {
+			continue
+		}
+		if found, err := exec.LookPath(filepath.Join(dir, name)); err == nil {
+			return found
+		}
+	}
+	return name
+}
+
+// defaultShell returns the user's shell: $SHELL on Unix-like systems,
+// PowerShell on Windows, and sh when neither is available
+func defaultShell() string {
+	if runtime.GOOS == "windows" {
+		if _, err := exec.LookPath("pwsh"); err == nil {
+			return "pwsh"
+		}
+		return "powershell"
+	}
+	if shell := os.Getenv("SHELL"); shell != "" {
+		return shell
+	}
+	return "sh"
+}
+
+// shellFlags returns the arguments that make a shell run a command string
+func shellFlags(shell string) []string {
+	name := strings.TrimSuffix(strings.ToLower(filepath.Base(shell)), ".exe")
+	switch name {
+	case "pwsh", "powershell":
+		return []string{"-NoProfile", "-NonInteractive", "-Command"}
+	case "cmd":
+		return []string{"/C"}
+	default:
+		return []string{"-c"}
+	}
+}
+
+// splitArgs splits a command line into arguments the way a POSIX shell
+// would, without running one. Single quotes keep their contents literal,
+// double quotes allow $VAR and ${VAR} expansion and backslash escapes, and
+// unquoted text is expanded as well. Pipes, redirects and operators such as
+// && are passed through as plain arguments.
+func splitArgs(line string, getenv func(string) string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	inArg := false
+	runes := []rune(line)
+
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case r == ' ' || r == '\t' || r == '\n':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+
+		case r == '\'':
+			inArg = true
+			end := indexRune(runes, i+1, '\'')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated single quote in %q", line)
+			}
+			current.WriteString(string(runes[i+1 : end]))
+			i = end
+
+		case r == '"':
+			inArg = true
+			i++
+			for ; i < len(runes) && runes[i] != '"'; i++ {
+				switch {
+				case runes[i] == '\\' && i+1 < len(runes) && strings.ContainsRune(`"\$`+"`", runes[i+1]):
+					i++
+					current.WriteRune(runes[i])
+				case runes[i] == '$':
+					i = expandVar(runes, i, &current, getenv)
+				default:
+					current.WriteRune(runes[i])
+				}
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("unterminated double quote in %q", line)
+			}
+
+		case r == '\\':
+			inArg = true
+			if i+1 < len(runes) {
+				i++
+				current.WriteRune(runes[i])
+			}
+
+		case r == '$':
+			inArg = true
+			i = expandVar(runes, i, &current, getenv)
+
+		default:
+			inArg = true
+			current.WriteRune(r)
+		}
+	}
+
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
+
+// expandVar expands the $VAR or ${VAR} reference starting at runes[i] and
+// returns the index of its last rune. A lone $ is kept literally.
+func expandVar(runes []rune, i int, out *strings.Builder, getenv func(string) string) int {
+	if i+1 < len(runes) && runes[i+1] == '{' {
+		end := indexRune(runes, i+2, '}')
+		if end < 0 {
+			out.WriteRune('$')
+			return i
+		}
+		out.WriteString(getenv(string(runes[i+2 : end])))
+		return end
+	}
+
+	end := i + 1
+	for end < len(runes) && isNameRune(runes[end], end == i+1) {
+		end++
+	}
+	if end == i+1 {
+		out.WriteRune('$')
+		return i
+	}
+	out.WriteString(getenv(string(runes[i+1 : end])))
+	return end - 1
+}
+
+// isNameRune reports whether r can appear in an environment variable name
+func isNameRune(r rune, first bool) bool {
+	return r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (!first && r >= '0' && r <= '9')
+}
+
+// indexRune returns the index of the first r in runes at or after from, or -1
+func indexRune(runes []rune, from int, r rune) int {
+	for i := from; i < len(runes); i++ {
+		if runes[i] == r {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,152 @@
+package rituals
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/ferg-cod3s/rune/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitArgs(t *testing.T) {
+	env := map[string]string{"NAME": "rune", "GREETING": "hello world"}
+	getenv := func(key string) string { return env[key] }
+
+	tests := []struct {
+		name     string
+		line     string
+		expected []string
+	}{
+		{name: "plain words", line: "git  status\t-s", expected: []string{"git", "status", "-s"}},
+		{name: "single quotes keep spaces", line: "git commit -m 'WIP: End of day'", expected: []string{"git", "commit", "-m", "WIP: End of day"}},
+		{name: "double quotes keep spaces", line: `echo "a  b"`, expected: []string{"echo", "a  b"}},
+		{name: "adjacent quotes join", line: `echo pre'fix'"ed"`, expected: []string{"echo", "prefixed"}},
+		{name: "empty quotes are an argument", line: `printf ''`, expected: []string{"printf", ""}},
+		{name: "escaped space", line: `ls my\ dir`, expected: []string{"ls", "my dir"}},
+		{name: "escapes in double quotes", line: `echo "say \"hi\" \$HOME"`, expected: []string{"echo", `say "hi" $HOME`}},
+		{name: "expands unquoted variables", line: "echo $NAME-${NAME}s", expected: []string{"echo", "rune-runes"}},
+		{name: "expanded values are not split", line: "echo $GREETING", expected: []string{"echo", "hello world"}},
+		{name: "expands in double quotes", line: `echo "$GREETING!"`, expected: []string{"echo", "hello world!"}},
+		{name: "no expansion in single quotes", line: `echo '$NAME'`, expected: []string{"echo", "$NAME"}},
+		{name: "lone dollar is literal", line: "echo $ 5$", expected: []string{"echo", "$", "5$"}},
+		{name: "operators are plain arguments", line: "echo a && echo b | wc", expected: []string{"echo", "a", "&&", "echo", "b", "|", "wc"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args, err := splitArgs(tt.line, getenv)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, args)
+		})
+	}
+
+	_, err := splitArgs(`echo 'open`, getenv)
+	assert.ErrorContains(t, err, "unterminated single quote")
+	_, err = splitArgs(`echo "open`, getenv)
+	assert.ErrorContains(t, err, "unterminated double quote")
+}
+
+func TestBuildCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell tests use POSIX sh")
+	}
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	t.Setenv("RUNE_RITUAL_TEST", "from env")
+
+	run := func(t *testing.T, cmd config.Command) string {
+		t.Helper()
+		execCmd, err := buildCommand(context.Background(), cmd, os.Environ())
+		require.NoError(t, err)
+		output, err := execCmd.CombinedOutput()
+		require.NoError(t, err, string(output))
+		return strings.TrimSpace(string(output))
+	}
+
+	tests := []struct {
+		name     string
+		cmd      config.Command
+		expected string
+	}{
+		{name: "pipes", cmd: config.Command{Shell: "sh", Command: "echo hello | tr a-z A-Z"}, expected: "HELLO"},
+		{name: "and list", cmd: config.Command{Shell: "sh", Command: "true && echo ran"}, expected: "ran"},
+		{name: "quoting", cmd: config.Command{Shell: "sh", Command: "printf '%s|' 'WIP: End of day' b"}, expected: "WIP: End of day|b|"},
+		{name: "env expansion in shell", cmd: config.Command{Shell: "sh", Command: `echo "$RUNE_RITUAL_TEST"`}, expected: "from env"},
+		{name: "argv mode quoting", cmd: config.Command{Shell: "none", Command: "printf '%s|' 'WIP: End of day' b"}, expected: "WIP: End of day|b|"},
+		{name: "argv mode env expansion", cmd: config.Command{Shell: "none", Command: `echo "$RUNE_RITUAL_TEST"`}, expected: "from env"},
+		{name: "argv mode does not interpret operators", cmd: config.Command{Shell: "none", Command: "echo a && echo b"}, expected: "a && echo b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, run(t, tt.cmd))
+		})
+	}
+
+	t.Run("defaults to the user's shell", func(t *testing.T) {
+		t.Setenv("SHELL", "/bin/sh")
+		execCmd, err := buildCommand(context.Background(), config.Command{Command: "echo hi"}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"/bin/sh", "-c", "echo hi"}, execCmd.Args)
+	})
+
+	t.Run("empty command", func(t *testing.T) {
+		_, err := buildCommand(context.Background(), config.Command{Shell: "none", Command: "  "}, nil)
+		assert.ErrorContains(t, err, "empty command")
+	})
+}
+
+func TestPrepareCommand_DirectUsesCommandEnv(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a POSIX executable")
+	}
+	bin := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(bin, "deploy"), []byte("#!/bin/sh\n"), 0755))
+
+	cmd := config.Command{
+		Shell:   "none",
+		Command: `deploy $TARGET "${TARGET}-2"`,
+		Env:     map[string]string{"TARGET": "x", "PATH": bin},
+	}
+	execCmd, err := prepareCommand(context.Background(), cmd, []string{"PATH=/usr/bin"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"deploy", "x", "x-2"}, execCmd.Args)
+	assert.Equal(t, filepath.Join(bin, "deploy"), execCmd.Path)
+}
+
+func TestShellFlags(t *testing.T) {
+	assert.Equal(t, []string{"-c"}, shellFlags("/usr/bin/zsh"))
+	assert.Equal(t, []string{"-NoProfile", "-NonInteractive", "-Command"}, shellFlags("pwsh"))
+	assert.Equal(t, []string{"-NoProfile", "-NonInteractive", "-Command"}, shellFlags("powershell.exe"))
+}
+
+func TestQuotedTemplateValuesStayLiteral(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses POSIX sh")
+	}
+	dir := t.TempDir()
+	hostile := "feat/$(touch pwned)`touch pwned2`'; touch pwned3; echo '"
+	data := config.TemplateData{GitBranch: config.UntrustedString(hostile)}
+	run := func(command string) string {
+		t.Helper()
+		cmd, err := config.ExpandCommand(config.Command{Name: "Branch", Shell: "sh", Dir: dir, Command: command}, data)
+		require.NoError(t, err)
+		result, err := runAttempt(cmd, nil)
+		require.NoError(t, err)
+		return string(result.stdout)
+	}
+
+	assert.Equal(t, hostile, run("printf %s {{q .GitBranch}}"))
+	// Unquoted, the branch is made safe rather than run
+	assert.Equal(t, "feat/--touch-pwned--touch-pwned2----touch-pwned3--echo--", run("printf %s {{.GitBranch}}"))
+	for _, name := range []string{"pwned", "pwned2", "pwned3"} {
+		assert.NoFileExists(t, filepath.Join(dir, name))
+	}
+}
@@ -0,0 +1,114 @@
+package rituals
+
+import (
+	"fmt"
+
+	"github.com/ferg-cod3s/rune/internal/config"
+	"github.com/ferg-cod3s/rune/internal/tracking"
+)
+
+// stepOutcome is what happened to a step of a ritual
+type stepOutcome int
+
+const (
+	stepNotRun stepOutcome = iota
+	stepSucceeded
+	stepFailed
+	stepFailedOptional
+	// stepSkipped steps did not meet their when conditions
+	stepSkipped
+	// stepBlocked steps need a step that failed
+	stepBlocked
+)
+
+// String returns how ritual history describes the outcome
+func (o stepOutcome) String() string {
+	switch o {
+	case stepSucceeded:
+		return tracking.StepSucceeded
+	case stepFailed:
+		return tracking.StepFailed
+	case stepFailedOptional:
+		return tracking.StepFailedOptional
+	case stepSkipped:
+		return tracking.StepSkipped
+	default:
+		return tracking.StepBlocked
+	}
+}
+
+// stepReport records the outcome of a step and of its compensating commands
+type stepReport struct {
+	outcome stepOutcome
+	// detail is the error of a failed step or the reason a step was skipped
+	detail    string
+	onFailure string
+	undo      string
+	// run is what the step's command produced, if it ran
+	run execution
+}
+
+// printSummary prints the outcome of every step and finally command
+func (e *Engine) printSummary(p *plan, reports []stepReport, finally []config.Command, finallyReports []stepReport) {
+	fmt.Fprintln(e.out, "Summary:")
+	for i, s := range p.steps {
+		e.printReport(s.cmd.Name, reports[i])
+	}
+	for i, cmd := range finally {
+		e.printReport("finally: "+cmd.Name, finallyReports[i])
+	}
+}
+
+// printReport prints the outcome of one step
+func (e *Engine) printReport(name string, report stepReport) {
+	switch report.outcome {
+	case stepSucceeded:
+		fmt.Fprintf(e.out, "  ✓ %s\n", name)
+	case stepFailed:
+		fmt.Fprintf(e.out, "  ❌ %s: %s\n", name, report.detail)
+	case stepFailedOptional:
+		fmt.Fprintf(e.out, "  ⚠ %s: %s (optional)\n", name, report.detail)
+	case stepSkipped:
+		fmt.Fprintf(e.out, "  ⏭  %s: skipped (%s)\n", name, report.detail)
+	case stepBlocked:
+		fmt.Fprintf(e.out, "  ⏭  %s: not run (%s)\n", name, report.detail)
+	default:
+		fmt.Fprintf(e.out, "  · %s: not run\n", name)
+	}
+	if report.onFailure != "" {
+		fmt.Fprintf(e.out, "     on_failure %s\n", report.onFailure)
+	}
+	if report.undo != "" {
+		fmt.Fprintf(e.out, "     undo %s\n", report.undo)
+	}
+}
+
+// newRitualRun builds the history record of the steps and finally commands of a ritual run
+func newRitualRun(p *plan, reports []stepReport, finally []config.Command, finallyReports []stepReport) *tracking.RitualRun {
+	run := &tracking.RitualRun{}
+	for i, s := range p.steps {
+		run.Steps = append(run.Steps, newStepRun(s.cmd, reports[i], false))
+	}
+	for i, cmd := range finally {
+		run.Steps = append(run.Steps, newStepRun(cmd, finallyReports[i], true))
+	}
+	return run
+}
+
+// newStepRun builds the history record of one step
+func newStepRun(cmd config.Command, report stepReport, finally bool) tracking.RitualStepRun {
+	return tracking.RitualStepRun{
+		Name:      cmd.Name,
+		Command:   cmd.Command,
+		Finally:   finally,
+		Outcome:   report.outcome.String(),
+		Detail:    report.detail,
+		ExitCode:  report.run.exitCode,
+		Attempts:  report.run.attempts,
+		Duration:  report.run.duration,
+		Stdout:    string(report.run.stdout),
+		Stderr:    string(report.run.stderr),
+		OnFailure: report.onFailure,
+		Undo:      report.undo,
+	}
+}
@@ -0,0 +1,76 @@
+package rituals
+
+import (
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/ferg-cod3s/rune/internal/calendar"
+	"github.com/ferg-cod3s/rune/internal/config"
+)
+
+// templateData returns the values ritual templates can use when running for a project
+func (e *Engine) templateData(project string) config.TemplateData {
+	now := time.Now()
+	cal, err := e.config.Calendar()
+	if err != nil {
+		cal = calendar.Default()
+	}
+
+	data := config.TemplateData{
+		Project: project,
+		Today:   cal.Date(now).Format("2006-01-02"),
+		Config:  e.config,
+	}
+	if e.session != nil {
+		data.SessionID = e.session.ID
+		data.StartTime = e.session.StartTime
+		data.Duration = config.TemplateDuration(e.session.Duration)
+		if e.session.EndTime == nil {
+			data.Duration = config.TemplateDuration(e.session.WorkedDuration(now))
+		}
+	}
+	// Outside a git repository these stay empty
+	branch, _ := currentBranch("")
+	data.GitBranch = config.UntrustedString(branch)
+	data.GitRoot, _ = gitRoot()
+	return data
+}
+
+// expandTemplates expands the templates of the commands a ritual runs
+func (e *Engine) expandTemplates(sections []section, finally []config.Command, project string) ([]section, []config.Command, error) {
+	data := e.templateData(project)
+	expand := func(commands []config.Command) ([]config.Command, error) {
+		expanded := make([]config.Command, len(commands))
+		for i, cmd := range commands {
+			var err error
+			if expanded[i], err = config.ExpandCommand(cmd, data); err != nil {
+				return nil, err
+			}
+		}
+		return expanded, nil
+	}
+
+	result := make([]section, len(sections))
+	for i, sec := range sections {
+		commands, err := expand(sec.commands)
+		if err != nil {
+			return nil, nil, err
+		}
+		result[i] = section{scope: sec.scope, commands: commands}
+	}
+	expandedFinally, err := expand(finally)
+	if err != nil {
+		return nil, nil, err
+	}
+	return result, expandedFinally, nil
+}
+
+// gitRoot returns the top-level directory of the current git repository
+func gitRoot() (string, error) {
+	output, err := exec.Command("git", "rev-parse", "--show-toplevel").Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
+}
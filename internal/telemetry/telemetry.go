@@ -307,8 +307,7 @@ func getUserID() string {
 
 	// Try to save it to config
 	if cfg != nil {
-		cfg.UserID = userID
-		_ = config.SaveConfig(cfg) // Ignore errors
+		_ = config.SetValue("user_id", userID) // Ignore errors
 	}
 
 	return userID
@@ -0,0 +1,343 @@
+package tracking
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+var journalBucket = []byte("journal")
+
+// maxJournalEntries is how many changes the undo journal keeps; older ones can no longer be undone
+const maxJournalEntries = 100
+
+// JournalAction identifies the kind of change recorded in the undo journal
+type JournalAction string
+
+const (
+	JournalLog    JournalAction = "log"
+	JournalEdit   JournalAction = "edit"
+	JournalDelete JournalAction = "delete"
+)
+
+// JournalEntry records a manual change to the session history so it can be undone.
+// Before is nil for newly logged sessions and After is nil for deletions.
+type JournalEntry struct {
+	Action JournalAction `json:"action"`
+	Time   time.Time     `json:"time"`
+	Before *Session      `json:"before,omitempty"`
+	After  *Session      `json:"after,omitempty"`
+}
+
+// SessionEdit describes changes to a completed session. Nil fields are left unchanged.
+type SessionEdit struct {
+	Project *string
+	Start   *time.Time
+	End     *time.Time
+	Note    *string
+}
+
+// GetSession returns the session with the given ID
+func (t *Tracker) GetSession(id string) (*Session, error) {
+	var session *Session
+
+	err := t.db.View(func(tx *bbolt.Tx) error {
+		var err error
+		session, err = getSession(tx, id)
+		return err
+	})
+
+	return session, err
+}
+
+// LogSession records a completed session retroactively
+func (t *Tracker) LogSession(project string, start, end time.Time, note string, tags []string) (*Session, error) {
+	session, err := NewStoppedSession(project, start, end, note, tags)
+	if err != nil {
+		return nil, err
+	}
+	session.ID = generateSessionID()
+
+	err = t.db.Update(func(tx *bbolt.Tx) error {
+		if err := checkOverlap(tx, session); err != nil {
+			return err
+		}
+		if err := putSession(tx, session); err != nil {
+			return err
+		}
+		return appendJournal(tx, JournalEntry{Action: JournalLog, Time: time.Now(), After: session})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+// EditSession changes the project, times or note of a completed session
+func (t *Tracker) EditSession(id string, edit SessionEdit) (*Session, error) {
+	var updated *Session
+
+	err := t.db.Update(func(tx *bbolt.Tx) error {
+		before, err := getSession(tx, id)
+		if err != nil {
+			return err
+		}
+		if before.State != StateStopped || before.EndTime == nil {
+			return fmt.Errorf("cannot edit an active session (state: %s)", before.State)
+		}
+
+		after := before.clone()
+		if edit.Project != nil {
+			if *edit.Project == "" {
+				return fmt.Errorf("project cannot be empty")
+			}
+			after.Project = *edit.Project
+		}
+		if edit.Note != nil {
+			after.Note = *edit.Note
+		}
+		if edit.Start != nil || edit.End != nil {
+			start, end := after.StartTime, *after.EndTime
+			if edit.Start != nil {
+				start = *edit.Start
+			}
+			if edit.End != nil {
+				end = *edit.End
+			}
+			if err := after.retime(start, end); err != nil {
+				return err
+			}
+			if err := checkOverlap(tx, after); err != nil {
+				return err
+			}
+		}
+
+		if err := putSession(tx, after); err != nil {
+			return err
+		}
+		updated = after
+		return appendJournal(tx, JournalEntry{Action: JournalEdit, Time: time.Now(), Before: before, After: after})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return updated, nil
+}
+
+// DeleteSession removes a completed session
+func (t *Tracker) DeleteSession(id string) (*Session, error) {
+	var deleted *Session
+
+	err := t.db.Update(func(tx *bbolt.Tx) error {
+		session, err := getSession(tx, id)
+		if err != nil {
+			return err
+		}
+		if session.State != StateStopped {
+			return fmt.Errorf("cannot delete an active session (state: %s); stop it first", session.State)
+		}
+
+		if err := deleteSession(tx, id); err != nil {
+			return err
+		}
+		deleted = session
+		return appendJournal(tx, JournalEntry{Action: JournalDelete, Time: time.Now(), Before: session})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return deleted, nil
+}
+
+// Undo reverts the most recent manual change recorded in the journal
+func (t *Tracker) Undo() (*JournalEntry, error) {
+	var undone *JournalEntry
+
+	err := t.db.Update(func(tx *bbolt.Tx) error {
+		journal := tx.Bucket(journalBucket)
+		k, v := journal.Cursor().Last()
+		if k == nil {
+			return fmt.Errorf("nothing to undo")
+		}
+
+		var entry JournalEntry
+		if err := json.Unmarshal(v, &entry); err != nil {
+			return fmt.Errorf("failed to read journal entry: %w", err)
+		}
+
+		if entry.After != nil {
+			if err := deleteSession(tx, entry.After.ID); err != nil {
+				return err
+			}
+		}
+		if entry.Before != nil {
+			// Time the change freed may have been recorded since
+			if err := checkOverlap(tx, entry.Before); err != nil {
+				return fmt.Errorf("cannot undo %s of session %s: the restored session %w", entry.Action, entry.Before.ID, err)
+			}
+			if err := putSession(tx, entry.Before); err != nil {
+				return err
+			}
+		}
+
+		undone = &entry
+		return journal.Delete(k)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return undone, nil
+}
+
+// retime moves a completed session to [start, end], trimming its intervals to fit
+func (s *Session) retime(start, end time.Time) error {
+	if !end.After(start) {
+		return fmt.Errorf("end time %s must be after start time %s", end.Format(time.RFC3339), start.Format(time.RFC3339))
+	}
+	if end.After(time.Now()) {
+		return fmt.Errorf("end time %s is in the future", end.Format(time.RFC3339))
+	}
+
+	var intervals []Interval
+	for _, interval := range s.Intervals {
+		if interval.End == nil || !interval.End.After(start) || !interval.Start.Before(end) {
+			continue
+		}
+		if interval.Start.Before(start) {
+			interval.Start = start
+		}
+		if interval.End.After(end) {
+			clamped := end
+			interval.End = &clamped
+		}
+		intervals = append(intervals, interval)
+	}
+
+	// Without any surviving work the whole range counts as work
+	hasWork := false
+	for _, interval := range intervals {
+		hasWork = hasWork || interval.Kind == IntervalWork
+	}
+	if !hasWork {
+		workEnd := end
+		intervals = []Interval{{Kind: IntervalWork, Start: start, End: &workEnd}}
+	}
+
+	// Extend the outermost intervals so the session covers exactly [start, end]
+	intervals[0].Start = start
+	last := end
+	intervals[len(intervals)-1].End = &last
+
+	s.Intervals = intervals
+	s.StartTime = start
+	s.EndTime = &last
+	s.PausedAt = nil
+	s.Duration = s.WorkedDuration(end)
+	return nil
+}
+
+// clone returns a deep copy of the session
+func (s *Session) clone() *Session {
+	data, _ := json.Marshal(s)
+	var copied Session
+	_ = json.Unmarshal(data, &copied)
+	return &copied
+}
+
+// span returns the time range covered by a session, treating active sessions as ending now
+func (s *Session) span() (time.Time, time.Time) {
+	if s.EndTime != nil {
+		return s.StartTime, *s.EndTime
+	}
+	return s.StartTime, time.Now()
+}
+
+// checkOverlap returns an error if the session overlaps any other recorded session
+func checkOverlap(tx *bbolt.Tx, session *Session) error {
+	start, end := session.span()
+
+	overlaps := func(other *Session) error {
+		if other.ID == session.ID {
+			return nil
+		}
+		otherStart, otherEnd := other.span()
+		if start.Before(otherEnd) && otherStart.Before(end) {
+			return fmt.Errorf("overlaps session %s (%s, %s - %s)",
+				other.ID, other.Project, otherStart.Format("2006-01-02 15:04"), otherEnd.Format("15:04"))
+		}
+		return nil
+	}
+
+	// The active session may have run past the days it was indexed under
+	if data := tx.Bucket(currentBucket).Get([]byte("session")); data != nil {
+		var current Session
+		if err := json.Unmarshal(data, &current); err == nil {
+			if err := overlaps(&current); err != nil {
+				return err
+			}
+		}
+	}
+
+	primary := tx.Bucket(sessionsBucket)
+	for _, day := range sessionDays(session) {
+		index := tx.Bucket(daysBucket).Bucket(day)
+		if index == nil {
+			continue
+		}
+		err := index.ForEach(func(k, _ []byte) error {
+			var other Session
+			if err := json.Unmarshal(primary.Get(k), &other); err != nil {
+				return nil
+			}
+			return overlaps(&other)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// appendJournal records an entry in the undo journal
+func appendJournal(tx *bbolt.Tx, entry JournalEntry) error {
+	journal := tx.Bucket(journalBucket)
+	seq, err := journal.NextSequence()
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, seq)
+	if err := journal.Put(key, data); err != nil {
+		return err
+	}
+
+	// Only the most recent entries are kept
+	if seq <= maxJournalEntries {
+		return nil
+	}
+	oldest := make([]byte, 8)
+	binary.BigEndian.PutUint64(oldest, seq-maxJournalEntries+1)
+	var expired [][]byte
+	cursor := journal.Cursor()
+	for k, _ := cursor.First(); k != nil && bytes.Compare(k, oldest) < 0; k, _ = cursor.Next() {
+		expired = append(expired, append([]byte{}, k...))
+	}
+	for _, k := range expired {
+		if err := journal.Delete(k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,179 @@
+package tracking
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/bbolt"
+)
+
+func TestTracker_LogSession(t *testing.T) {
+	tracker := setupTestTracker(t)
+	defer tracker.Close()
+
+	start := time.Now().Add(-4 * time.Hour).Truncate(time.Minute)
+	end := start.Add(90 * time.Minute)
+
+	session, err := tracker.LogSession("api", start, end, "forgot to start", nil)
+	require.NoError(t, err)
+	assert.Equal(t, StateStopped, session.State)
+	assert.Equal(t, 90*time.Minute, session.Duration)
+	assert.Equal(t, "forgot to start", session.Note)
+
+	stored, err := tracker.GetSession(session.ID)
+	require.NoError(t, err)
+	assert.Equal(t, session.Duration, stored.Duration)
+
+	t.Run("rejects overlapping sessions", func(t *testing.T) {
+		_, err := tracker.LogSession("web", start.Add(30*time.Minute), end.Add(30*time.Minute), "", nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "overlaps session "+session.ID)
+	})
+
+	t.Run("allows adjacent sessions", func(t *testing.T) {
+		_, err := tracker.LogSession("web", end, end.Add(30*time.Minute), "", nil)
+		require.NoError(t, err)
+	})
+
+	t.Run("rejects invalid ranges", func(t *testing.T) {
+		_, err := tracker.LogSession("web", end, start, "", nil)
+		assert.Error(t, err)
+
+		_, err = tracker.LogSession("web", time.Now(), time.Now().Add(time.Hour), "", nil)
+		assert.Error(t, err)
+	})
+}
+
+func TestTracker_EditDeleteUndo(t *testing.T) {
+	tracker := setupTestTracker(t)
+	defer tracker.Close()
+
+	start := time.Now().Add(-6 * time.Hour).Truncate(time.Minute)
+	session, err := tracker.LogSession("api", start, start.Add(time.Hour), "", nil)
+	require.NoError(t, err)
+	other, err := tracker.LogSession("web", start.Add(2*time.Hour), start.Add(3*time.Hour), "", nil)
+	require.NoError(t, err)
+
+	// Edit project, note and end time
+	project, note, end := "backend", "pairing", start.Add(90*time.Minute)
+	edited, err := tracker.EditSession(session.ID, SessionEdit{Project: &project, Note: &note, End: &end})
+	require.NoError(t, err)
+	assert.Equal(t, "backend", edited.Project)
+	assert.Equal(t, "pairing", edited.Note)
+	assert.Equal(t, 90*time.Minute, edited.Duration)
+
+	// Editing into another session is rejected
+	overlapEnd := start.Add(150 * time.Minute)
+	_, err = tracker.EditSession(session.ID, SessionEdit{End: &overlapEnd})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), other.ID)
+
+	// Delete, then undo the delete and the edit
+	_, err = tracker.DeleteSession(other.ID)
+	require.NoError(t, err)
+	_, err = tracker.GetSession(other.ID)
+	assert.Error(t, err)
+
+	entry, err := tracker.Undo()
+	require.NoError(t, err)
+	assert.Equal(t, JournalDelete, entry.Action)
+	_, err = tracker.GetSession(other.ID)
+	assert.NoError(t, err)
+
+	entry, err = tracker.Undo()
+	require.NoError(t, err)
+	assert.Equal(t, JournalEdit, entry.Action)
+	restored, err := tracker.GetSession(session.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "api", restored.Project)
+	assert.Equal(t, time.Hour, restored.Duration)
+
+	// Undoing the logs removes the sessions entirely
+	_, err = tracker.Undo()
+	require.NoError(t, err)
+	_, err = tracker.Undo()
+	require.NoError(t, err)
+	_, err = tracker.GetSession(session.ID)
+	assert.Error(t, err)
+
+	_, err = tracker.Undo()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "nothing to undo")
+}
+
+func TestTracker_UndoRefusesToOverlap(t *testing.T) {
+	tracker := setupTestTracker(t)
+	defer tracker.Close()
+
+	start := time.Now().Add(-6 * time.Hour).Truncate(time.Minute)
+	session, err := tracker.LogSession("api", start, start.Add(2*time.Hour), "", nil)
+	require.NoError(t, err)
+
+	// Shorten the session, then import another into the time that freed up
+	end := start.Add(time.Hour)
+	_, err = tracker.EditSession(session.ID, SessionEdit{End: &end})
+	require.NoError(t, err)
+	imported, err := NewStoppedSession("web", end, end.Add(30*time.Minute), "", nil)
+	require.NoError(t, err)
+	_, err = tracker.ImportSessions([]*Session{imported}, false)
+	require.NoError(t, err)
+
+	_, err = tracker.Undo()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "cannot undo edit of session "+session.ID)
+	assert.Contains(t, err.Error(), "overlaps session")
+	assert.Contains(t, err.Error(), "(web, ")
+
+	kept, err := tracker.GetSession(session.ID)
+	require.NoError(t, err)
+	assert.Equal(t, time.Hour, kept.Duration)
+}
+
+func TestTracker_JournalKeepsRecentEntries(t *testing.T) {
+	tracker := setupTestTracker(t)
+	defer tracker.Close()
+
+	require.NoError(t, tracker.db.Update(func(tx *bbolt.Tx) error {
+		for i := 0; i < maxJournalEntries+5; i++ {
+			if err := appendJournal(tx, JournalEntry{Action: JournalLog, Time: time.Now()}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+	require.NoError(t, tracker.db.View(func(tx *bbolt.Tx) error {
+		assert.Equal(t, maxJournalEntries, tx.Bucket(journalBucket).Stats().KeyN)
+		return nil
+	}))
+}
+
+func TestSession_RetimeTrimsIntervals(t *testing.T) {
+	base := time.Date(2025, 1, 6, 9, 0, 0, 0, time.UTC)
+	at := func(minutes int) *time.Time {
+		t := base.Add(time.Duration(minutes) * time.Minute)
+		return &t
+	}
+
+	session := &Session{
+		StartTime: base,
+		EndTime:   at(180),
+		State:     StateStopped,
+		Intervals: []Interval{
+			{Kind: IntervalWork, Start: base, End: at(60)},
+			{Kind: IntervalPause, Start: *at(60), End: at(90), Reason: PauseBreak},
+			{Kind: IntervalWork, Start: *at(90), End: at(180)},
+		},
+	}
+
+	require.NoError(t, session.retime(*at(30), *at(120)))
+	require.Len(t, session.Intervals, 3)
+	assert.Equal(t, 60*time.Minute, session.Duration)
+	assert.Equal(t, 30*time.Minute, session.PausedDuration(*at(120)))
+
+	require.NoError(t, session.retime(*at(70), *at(80)))
+	require.Len(t, session.Intervals, 1)
+	assert.Equal(t, IntervalWork, session.Intervals[0].Kind)
+	assert.Equal(t, 10*time.Minute, session.Duration)
+}
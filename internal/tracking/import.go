@@ -0,0 +1,120 @@
+package tracking
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+// errDryRun rolls back the import transaction after a dry run
+var errDryRun = errors.New("dry run")
+
+// ImportConflict is an imported session that overlaps a different recorded session
+type ImportConflict struct {
+	Session *Session
+	Reason  string
+}
+
+// ImportResult summarizes an import
+type ImportResult struct {
+	Imported   []*Session
+	Duplicates []*Session
+	Conflicts  []ImportConflict
+}
+
+// NewStoppedSession builds a completed session covering [start, end] without storing it
+func NewStoppedSession(project string, start, end time.Time, note string, tags []string) (*Session, error) {
+	if project == "" {
+		return nil, fmt.Errorf("project cannot be empty")
+	}
+
+	session := &Session{
+		Project:   project,
+		StartTime: start,
+		Note:      note,
+		Tags:      NormalizeTags(tags),
+		State:     StateStopped,
+	}
+	if err := session.retime(start, end); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// ImportSessions stores completed sessions from another tool. Sessions with
+// the same start and end (to the second) as a recorded session are skipped as
+// duplicates, and sessions overlapping any other session are reported as
+// conflicts. With dryRun nothing is stored.
+func (t *Tracker) ImportSessions(sessions []*Session, dryRun bool) (*ImportResult, error) {
+	result := &ImportResult{}
+
+	err := t.db.Update(func(tx *bbolt.Tx) error {
+		for _, session := range sessions {
+			if session.State != StateStopped || session.EndTime == nil {
+				return fmt.Errorf("cannot import an active session (project: %s)", session.Project)
+			}
+			if findDuplicate(tx, session) != nil {
+				result.Duplicates = append(result.Duplicates, session)
+				continue
+			}
+			if err := checkOverlap(tx, session); err != nil {
+				result.Conflicts = append(result.Conflicts, ImportConflict{Session: session, Reason: err.Error()})
+				continue
+			}
+
+			session.ID = importSessionID(tx, session)
+			if err := putSession(tx, session); err != nil {
+				return fmt.Errorf("failed to store session: %w", err)
+			}
+			result.Imported = append(result.Imported, session)
+		}
+
+		if dryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errDryRun) {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// findDuplicate returns a recorded session covering the same time as session, if any
+func findDuplicate(tx *bbolt.Tx, session *Session) *Session {
+	start, end := session.span()
+
+	index := tx.Bucket(daysBucket).Bucket(sessionDays(session)[0])
+	if index == nil {
+		return nil
+	}
+
+	primary := tx.Bucket(sessionsBucket)
+	cursor := index.Cursor()
+	for k, _ := cursor.First(); k != nil; k, _ = cursor.Next() {
+		var other Session
+		if err := json.Unmarshal(primary.Get(k), &other); err != nil || other.EndTime == nil {
+			continue
+		}
+		if other.StartTime.Truncate(time.Second).Equal(start.Truncate(time.Second)) &&
+			other.EndTime.Truncate(time.Second).Equal(end.Truncate(time.Second)) {
+			return &other
+		}
+	}
+	return nil
+}
+
+// importSessionID derives an unused session ID from the session's start time
+func importSessionID(tx *bbolt.Tx, session *Session) string {
+	ids := tx.Bucket(sessionIDsBucket)
+	for n := session.StartTime.UnixNano(); ; n++ {
+		id := fmt.Sprintf("session_%d", n)
+		if ids.Get([]byte(id)) == nil {
+			return id
+		}
+	}
+}
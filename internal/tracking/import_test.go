@@ -0,0 +1,64 @@
+package tracking
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTracker_ImportSessions(t *testing.T) {
+	tracker := setupTestTracker(t)
+	defer tracker.Close()
+
+	day := time.Now().AddDate(0, 0, -2).Truncate(time.Hour)
+	_, err := tracker.LogSession("api", day.Add(time.Hour), day.Add(2*time.Hour), "", nil)
+	require.NoError(t, err)
+
+	imported := func(project string, from, to time.Duration) *Session {
+		session, err := NewStoppedSession(project, day.Add(from), day.Add(to), "from watson", []string{"legacy"})
+		require.NoError(t, err)
+		return session
+	}
+	sessions := []*Session{
+		imported("api", time.Hour, 2*time.Hour),                   // already logged
+		imported("web", 90*time.Minute, 3*time.Hour),              // overlaps the logged session
+		imported("web", 3*time.Hour, 4*time.Hour),                 // new
+		imported("web", 3*time.Hour, 4*time.Hour),                 // repeated within the import
+		imported("docs", 4*time.Hour+30*time.Minute, 5*time.Hour), // new
+	}
+
+	t.Run("dry run stores nothing", func(t *testing.T) {
+		result, err := tracker.ImportSessions(sessions, true)
+		require.NoError(t, err)
+		assert.Len(t, result.Imported, 2)
+		assert.Len(t, result.Duplicates, 2)
+		assert.Len(t, result.Conflicts, 1)
+
+		stored, err := tracker.Sessions(day, day.Add(24*time.Hour), SessionFilter{})
+		require.NoError(t, err)
+		assert.Len(t, stored, 1)
+	})
+
+	t.Run("import", func(t *testing.T) {
+		result, err := tracker.ImportSessions(sessions, false)
+		require.NoError(t, err)
+		require.Len(t, result.Imported, 2)
+		assert.Contains(t, result.Conflicts[0].Reason, "overlaps session")
+
+		stored, err := tracker.Sessions(day, day.Add(24*time.Hour), SessionFilter{Tags: []string{"legacy"}})
+		require.NoError(t, err)
+		require.Len(t, stored, 2)
+		assert.Equal(t, "from watson", stored[0].Note)
+		assert.NotEmpty(t, stored[0].ID)
+		assert.NotEqual(t, stored[0].ID, stored[1].ID)
+	})
+
+	t.Run("importing again finds only duplicates", func(t *testing.T) {
+		result, err := tracker.ImportSessions(sessions, false)
+		require.NoError(t, err)
+		assert.Empty(t, result.Imported)
+		assert.Len(t, result.Duplicates, 4)
+	})
+}
@@ -0,0 +1,140 @@
+package tracking
+
+import "time"
+
+// IntervalKind distinguishes work time from pauses within a session
+type IntervalKind string
+
+const (
+	IntervalWork  IntervalKind = "work"
+	IntervalPause IntervalKind = "pause"
+)
+
+// PauseReason records why a session was paused
+type PauseReason string
+
+const (
+	PauseManual PauseReason = "manual"
+	PauseIdle   PauseReason = "idle"
+	PauseBreak  PauseReason = "break"
+)
+
+// Interval is a contiguous stretch of work or pause within a session
+type Interval struct {
+	Kind   IntervalKind `json:"kind"`
+	Start  time.Time    `json:"start"`
+	End    *time.Time   `json:"end,omitempty"`
+	Reason PauseReason  `json:"reason,omitempty"`
+}
+
+// Length returns the interval length, treating an open interval as ending at now
+func (i Interval) Length(now time.Time) time.Duration {
+	if i.End != nil {
+		return i.End.Sub(i.Start)
+	}
+	return now.Sub(i.Start)
+}
+
+// openInterval starts a new interval at the given time
+func (s *Session) openInterval(kind IntervalKind, reason PauseReason, at time.Time) {
+	s.Intervals = append(s.Intervals, Interval{Kind: kind, Start: at, Reason: reason})
+}
+
+// closeInterval ends the last interval, if it is still open
+func (s *Session) closeInterval(at time.Time) {
+	if len(s.Intervals) == 0 {
+		return
+	}
+	last := &s.Intervals[len(s.Intervals)-1]
+	if last.End == nil {
+		end := at
+		last.End = &end
+	}
+}
+
+// WorkedDuration returns the total time spent in work intervals up to now
+func (s *Session) WorkedDuration(now time.Time) time.Duration {
+	var total time.Duration
+	for _, interval := range s.Intervals {
+		if interval.Kind == IntervalWork {
+			total += interval.Length(now)
+		}
+	}
+	return total
+}
+
+// PausedDuration returns the total time spent in pause intervals up to now
+func (s *Session) PausedDuration(now time.Time) time.Duration {
+	var total time.Duration
+	for _, interval := range s.Intervals {
+		if interval.Kind == IntervalPause {
+			total += interval.Length(now)
+		}
+	}
+	return total
+}
+
+// PauseCount returns the number of times the session was paused
+func (s *Session) PauseCount() int {
+	count := 0
+	for _, interval := range s.Intervals {
+		if interval.Kind == IntervalPause {
+			count++
+		}
+	}
+	return count
+}
+
+// CurrentWorkStart returns when the current work interval began, or the zero
+// time if the session is not currently running
+func (s *Session) CurrentWorkStart() time.Time {
+	if len(s.Intervals) == 0 {
+		return time.Time{}
+	}
+	last := s.Intervals[len(s.Intervals)-1]
+	if last.Kind != IntervalWork || last.End != nil {
+		return time.Time{}
+	}
+	return last.Start
+}
+
+// legacyIntervals reconstructs intervals for a session recorded before
+// interval tracking existed. Old versions shifted StartTime forward on every
+// resume, so the pauses themselves cannot be recovered; the best we can do is
+// a single work interval of the recorded length followed by any trailing pause.
+func legacyIntervals(s *Session) []Interval {
+	start := s.StartTime
+
+	switch s.State {
+	case StateRunning:
+		return []Interval{{Kind: IntervalWork, Start: start}}
+	case StatePaused:
+		if s.PausedAt == nil {
+			return []Interval{{Kind: IntervalWork, Start: start}}
+		}
+		workEnd := *s.PausedAt
+		return []Interval{
+			{Kind: IntervalWork, Start: start, End: &workEnd},
+			{Kind: IntervalPause, Start: workEnd, Reason: PauseManual},
+		}
+	default:
+		workEnd := start.Add(s.Duration)
+		intervals := []Interval{{Kind: IntervalWork, Start: start, End: &workEnd}}
+		if s.EndTime != nil && s.EndTime.After(workEnd) {
+			end := *s.EndTime
+			intervals = append(intervals, Interval{Kind: IntervalPause, Start: workEnd, End: &end, Reason: PauseManual})
+		}
+		return intervals
+	}
+}
+
+// LastPauseReason returns why the session was last paused, or an empty reason
+// if it never was
+func (s *Session) LastPauseReason() PauseReason {
+	for i := len(s.Intervals) - 1; i >= 0; i-- {
+		if s.Intervals[i].Kind == IntervalPause {
+			return s.Intervals[i].Reason
+		}
+	}
+	return ""
+}
@@ -0,0 +1,139 @@
+package tracking
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+
+	"go.etcd.io/bbolt"
+)
+
+var (
+	metaBucket       = []byte("meta")
+	schemaVersionKey = []byte("schema_version")
+)
+
+// migration upgrades the database by one schema version inside a single transaction
+type migration struct {
+	description string
+	apply       func(tx *bbolt.Tx) error
+}
+
+// migrations are applied in order; the database schema version is the number applied so far
+var migrations = []migration{
+	{description: "add work/pause intervals to sessions", apply: migrateIntervals},
+	{description: "store sessions under time-ordered keys with project and day indexes", apply: migrateTimeKeys},
+}
+
+// migrate brings the database up to the latest schema version
+func (t *Tracker) migrate() error {
+	return t.db.Update(func(tx *bbolt.Tx) error {
+		meta, err := tx.CreateBucketIfNotExists(metaBucket)
+		if err != nil {
+			return err
+		}
+
+		version := 0
+		if data := meta.Get(schemaVersionKey); data != nil {
+			version, err = strconv.Atoi(string(data))
+			if err != nil {
+				return fmt.Errorf("invalid schema version %q: %w", data, err)
+			}
+		}
+		if version > len(migrations) {
+			return fmt.Errorf("database schema version %d is newer than supported version %d", version, len(migrations))
+		}
+
+		for i := version; i < len(migrations); i++ {
+			if err := migrations[i].apply(tx); err != nil {
+				return fmt.Errorf("migration %d (%s) failed: %w", i+1, migrations[i].description, err)
+			}
+		}
+
+		return meta.Put(schemaVersionKey, []byte(strconv.Itoa(len(migrations))))
+	})
+}
+
+// migrateIntervals synthesizes intervals for sessions recorded without them
+func migrateIntervals(tx *bbolt.Tx) error {
+	for _, name := range [][]byte{sessionsBucket, currentBucket} {
+		bucket := tx.Bucket(name)
+		updates := make(map[string][]byte)
+
+		err := bucket.ForEach(func(k, v []byte) error {
+			var session Session
+			if err := json.Unmarshal(v, &session); err != nil {
+				return nil
+			}
+			if len(session.Intervals) > 0 {
+				return nil
+			}
+			session.Intervals = legacyIntervals(&session)
+			data, err := json.Marshal(&session)
+			if err != nil {
+				return err
+			}
+			updates[string(k)] = data
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for k, v := range updates {
+			if err := bucket.Put([]byte(k), v); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// migrateTimeKeys rewrites sessions stored under their IDs to time-ordered
+// keys and rebuilds the ID, project, day and tag indexes from scratch.
+// Records that cannot be read are kept unchanged under their old keys, where
+// readers skip them as they skip any unreadable record.
+func migrateTimeKeys(tx *bbolt.Tx) error {
+	var sessions []*Session
+	unreadable := make(map[string][]byte)
+	err := tx.Bucket(sessionsBucket).ForEach(func(k, v []byte) error {
+		var session Session
+		if err := json.Unmarshal(v, &session); err != nil {
+			fmt.Fprintf(os.Stderr, "⚠ Keeping unreadable session %s as it is: %v\n", k, err)
+			unreadable[string(k)] = append([]byte{}, v...)
+			return nil
+		}
+		if session.ID == "" {
+			session.ID = string(k)
+		}
+		sessions = append(sessions, &session)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, name := range [][]byte{sessionsBucket, sessionIDsBucket, projectsBucket, daysBucket, tagsBucket} {
+		if tx.Bucket(name) != nil {
+			if err := tx.DeleteBucket(name); err != nil {
+				return err
+			}
+		}
+		if _, err := tx.CreateBucket(name); err != nil {
+			return err
+		}
+	}
+
+	for _, session := range sessions {
+		if err := putSession(tx, session); err != nil {
+			return fmt.Errorf("failed to rewrite session %s: %w", session.ID, err)
+		}
+	}
+	for k, v := range unreadable {
+		if err := tx.Bucket(sessionsBucket).Put([]byte(k), v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
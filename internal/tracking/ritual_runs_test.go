@@ -0,0 +1,66 @@
+package tracking
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTracker_RitualRuns(t *testing.T) {
+	tracker := setupTestTracker(t)
+	defer tracker.Close()
+
+	start := time.Now().Add(-time.Hour)
+	ok := &RitualRun{Ritual: "start", Project: "api", StartTime: start, Steps: []RitualStepRun{
+		{Name: "pull", Command: "git pull", Outcome: StepSucceeded, Attempts: 1, Stdout: "Already up to date.\n"},
+	}}
+	failed := &RitualRun{Ritual: "stop", Project: "api", SessionID: "session_1", StartTime: start.Add(time.Minute),
+		Error: "command 'test' failed", Steps: []RitualStepRun{
+			{Name: "test", Command: "go test ./...", Outcome: StepFailed, ExitCode: 1, Attempts: 1, Stderr: "FAIL"},
+		}}
+	require.NoError(t, tracker.RecordRitualRun(ok))
+	require.NoError(t, tracker.RecordRitualRun(failed))
+	assert.NotEmpty(t, ok.ID)
+
+	runs, err := tracker.RitualRuns(0, false)
+	require.NoError(t, err)
+	require.Len(t, runs, 2)
+	assert.Equal(t, failed.ID, runs[0].ID, "newest first")
+
+	runs, err = tracker.RitualRuns(0, true)
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	assert.Equal(t, "session_1", runs[0].SessionID)
+
+	stored, err := tracker.GetRitualRun(failed.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stored.Steps[0].ExitCode)
+	assert.Equal(t, "FAIL", stored.Steps[0].Stderr)
+
+	_, err = tracker.GetRitualRun("ritual_missing")
+	assert.ErrorContains(t, err, "ritual run not found")
+
+	t.Run("prunes old runs", func(t *testing.T) {
+		for i := 0; i < MaxRitualRuns; i++ {
+			require.NoError(t, tracker.RecordRitualRun(&RitualRun{Ritual: "start", StartTime: start.Add(time.Duration(i+2) * time.Minute)}))
+		}
+		runs, err := tracker.RitualRuns(0, false)
+		require.NoError(t, err)
+		assert.Len(t, runs, MaxRitualRuns)
+		_, err = tracker.GetRitualRun(ok.ID)
+		assert.Error(t, err)
+	})
+}
+
+func TestTruncateOutput(t *testing.T) {
+	assert.Equal(t, "short", TruncateOutput([]byte("short")))
+
+	long := strings.Repeat("é", MaxRitualOutput)
+	truncated := TruncateOutput([]byte(long + "the end"))
+	assert.LessOrEqual(t, len(truncated), MaxRitualOutput)
+	assert.True(t, strings.HasPrefix(truncated, "[... truncated ...]\n"))
+	assert.True(t, strings.HasSuffix(truncated, "éthe end"))
+}
@@ -0,0 +1,205 @@
+package tracking
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// DetectRule maps a detection pattern to a configured project name.
+//
+// Supported patterns:
+//   - dir:<glob>     the working directory or one of its parents matches the glob (~ is expanded)
+//   - git:<regex>    the repository name or origin remote URL fully matches the regex
+//   - branch:<glob>  the current git branch matches the glob
+//   - env:<VAR>      the environment variable is set and non-empty
+//   - env:<VAR>=<glob> the environment variable's value matches the glob
+//   - file:<glob>    a file matching the glob exists in the working directory or git root
+//
+// Patterns without a recognized prefix are treated as file: patterns.
+type DetectRule struct {
+	Project string
+	Pattern string
+}
+
+// RuleResult records the outcome of evaluating one rule
+type RuleResult struct {
+	Rule    DetectRule
+	Matched bool
+	Reason  string
+}
+
+// Detection explains how the current project was determined
+type Detection struct {
+	Project string
+	// Rule is the configured rule that matched, or nil if a built-in heuristic was used
+	Rule *DetectRule
+	// Declared is set when the name came from the repository's own config
+	Declared  bool
+	Reason    string
+	Evaluated []RuleResult
+}
+
+// detectContext lazily gathers facts about the working directory for rule evaluation
+type detectContext struct {
+	cwd     string
+	gitRoot string
+
+	gitLoaded bool
+	remote    string
+	repoName  string
+	branch    string
+}
+
+// gitInfo loads the origin remote, repository name and current branch
+func (dc *detectContext) gitInfo() {
+	if dc.gitLoaded || dc.gitRoot == "" {
+		return
+	}
+	dc.gitLoaded = true
+
+	dc.repoName = filepath.Base(dc.gitRoot)
+	if output, err := gitOutput(dc.gitRoot, "remote", "get-url", "origin"); err == nil && output != "" {
+		dc.remote = output
+		name := strings.TrimSuffix(output, ".git")
+		if i := strings.LastIndexAny(name, "/:"); i >= 0 {
+			name = name[i+1:]
+		}
+		if name != "" {
+			dc.repoName = name
+		}
+	}
+	// symbolic-ref also works on a branch without commits; it fails when HEAD is detached
+	if output, err := gitOutput(dc.gitRoot, "symbolic-ref", "--short", "-q", "HEAD"); err == nil {
+		dc.branch = output
+	}
+}
+
+// gitOutput runs a git command in dir and returns its trimmed output
+func gitOutput(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// evaluate checks a single rule against the context
+func (dc *detectContext) evaluate(rule DetectRule) (bool, string) {
+	kind, value, found := strings.Cut(rule.Pattern, ":")
+	if !found || !isRuleKind(kind) {
+		kind, value = "file", rule.Pattern
+	}
+
+	switch kind {
+	case "dir":
+		pattern := filepath.Clean(expandHome(value))
+		for dir := dc.cwd; ; dir = filepath.Dir(dir) {
+			if matched, err := filepath.Match(pattern, dir); err != nil {
+				return false, fmt.Sprintf("invalid glob: %v", err)
+			} else if matched {
+				return true, fmt.Sprintf("directory %s matches %s", dir, pattern)
+			}
+			if filepath.Dir(dir) == dir {
+				break
+			}
+		}
+		return false, fmt.Sprintf("%s is not inside %s", dc.cwd, pattern)
+
+	case "git":
+		re, err := regexp.Compile("^(?:" + value + ")$")
+		if err != nil {
+			return false, fmt.Sprintf("invalid regex: %v", err)
+		}
+		if dc.gitRoot == "" {
+			return false, "not in a git repository"
+		}
+		dc.gitInfo()
+		if re.MatchString(dc.repoName) {
+			return true, fmt.Sprintf("repository name %q matches", dc.repoName)
+		}
+		if dc.remote != "" && re.MatchString(dc.remote) {
+			return true, fmt.Sprintf("remote %q matches", dc.remote)
+		}
+		return false, fmt.Sprintf("repository %q (remote %q) does not match", dc.repoName, dc.remote)
+
+	case "branch":
+		if dc.gitRoot == "" {
+			return false, "not in a git repository"
+		}
+		dc.gitInfo()
+		if dc.branch == "" {
+			return false, "could not determine current branch"
+		}
+		matched, err := path.Match(value, dc.branch)
+		if err != nil {
+			return false, fmt.Sprintf("invalid glob: %v", err)
+		}
+		if matched {
+			return true, fmt.Sprintf("branch %q matches", dc.branch)
+		}
+		return false, fmt.Sprintf("branch %q does not match", dc.branch)
+
+	case "env":
+		name, pattern, hasValue := strings.Cut(value, "=")
+		actual, set := os.LookupEnv(name)
+		if !hasValue {
+			if set && actual != "" {
+				return true, fmt.Sprintf("$%s is set", name)
+			}
+			return false, fmt.Sprintf("$%s is not set", name)
+		}
+		matched, err := path.Match(pattern, actual)
+		if err != nil {
+			return false, fmt.Sprintf("invalid glob: %v", err)
+		}
+		if set && matched {
+			return true, fmt.Sprintf("$%s=%q matches", name, actual)
+		}
+		return false, fmt.Sprintf("$%s=%q does not match", name, actual)
+
+	default:
+		pattern := strings.TrimSuffix(value, "/")
+		dirs := []string{dc.cwd}
+		if dc.gitRoot != "" && dc.gitRoot != dc.cwd {
+			dirs = append(dirs, dc.gitRoot)
+		}
+		for _, dir := range dirs {
+			matches, err := filepath.Glob(filepath.Join(dir, pattern))
+			if err != nil {
+				return false, fmt.Sprintf("invalid glob: %v", err)
+			}
+			if len(matches) > 0 {
+				return true, fmt.Sprintf("found %s", matches[0])
+			}
+		}
+		return false, fmt.Sprintf("no file matching %s", pattern)
+	}
+}
+
+// isRuleKind reports whether kind is a recognized rule prefix
+func isRuleKind(kind string) bool {
+	switch kind {
+	case "dir", "git", "branch", "env", "file":
+		return true
+	}
+	return false
+}
+
+// expandHome replaces a leading ~ with the user's home directory
+func expandHome(p string) string {
+	if p != "~" && !strings.HasPrefix(p, "~/") {
+		return p
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return p
+	}
+	return filepath.Join(home, strings.TrimPrefix(p, "~"))
+}
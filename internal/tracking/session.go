@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"time"
 
+	"github.com/ferg-cod3s/rune/internal/calendar"
 	"go.etcd.io/bbolt"
 )
 
@@ -41,6 +42,9 @@ type Session struct {
 	PausedAt  *time.Time    `json:"paused_at,omitempty"`
 	Duration  time.Duration `json:"duration"`
 	State     SessionState  `json:"state"`
+	Tags      []string      `json:"tags,omitempty"`
+	Note      string        `json:"note,omitempty"`
+	Intervals []Interval    `json:"intervals,omitempty"`
 }
 
 // Tracker manages time tracking sessions
@@ -48,6 +52,7 @@ type Tracker struct {
 	db           *bbolt.DB
 	idleDetector *IdleDetector
 	idleStop     chan struct{}
+	calendar     calendar.Calendar
 }
 
 var (
@@ -83,11 +88,16 @@ func NewTrackerWithIdleThreshold(idleThreshold time.Duration) (*Tracker, error)
 	tracker := &Tracker{
 		db:           db,
 		idleDetector: idleDetector,
+		calendar:     calendar.Default(),
 	}
 	if err := tracker.initBuckets(); err != nil {
 		db.Close()
 		return nil, err
 	}
+	if err := tracker.migrate(); err != nil {
+		db.Close()
+		return nil, err
+	}
 
 	return tracker, nil
 }
@@ -103,160 +113,121 @@ func (t *Tracker) Close() error {
 // initBuckets creates the necessary database buckets
 func (t *Tracker) initBuckets() error {
 	return t.db.Update(func(tx *bbolt.Tx) error {
-		if _, err := tx.CreateBucketIfNotExists(sessionsBucket); err != nil {
-			return err
-		}
-		if _, err := tx.CreateBucketIfNotExists(currentBucket); err != nil {
-			return err
+		for _, name := range [][]byte{
+			sessionsBucket, currentBucket, sessionIDsBucket, projectsBucket,
+			daysBucket, tagsBucket, journalBucket, ritualRunsBucket,
+		} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
 		}
 		return nil
 	})
 }
 
+// StartOptions carries optional details for a new session
+type StartOptions struct {
+	Tags []string
+	Note string
+}
+
 // Start starts a new work session
 func (t *Tracker) Start(project string) (*Session, error) {
-	// Check if there's already an active session
-	current, err := t.GetCurrentSession()
-	if err != nil {
-		return nil, err
-	}
-	if current != nil && current.State != StateStopped {
-		return nil, fmt.Errorf("session already active (state: %s)", current.State)
-	}
-
-	session := &Session{
-		ID:        generateSessionID(),
-		Project:   project,
-		StartTime: time.Now(),
-		State:     StateRunning,
-	}
-
-	if err := t.saveSession(session); err != nil {
-		return nil, err
-	}
-
-	if err := t.setCurrentSession(session); err != nil {
-		return nil, err
-	}
+	return t.StartWithOptions(project, StartOptions{})
+}
 
-	// Start idle monitoring when a session starts
-	if err := t.StartIdleMonitoring(); err != nil {
-		// Log error but don't fail the session start
-		fmt.Printf("Warning: Failed to start idle monitoring: %v\n", err)
-	}
+// StartWithOptions starts a new work session with tags and a note
+func (t *Tracker) StartWithOptions(project string, opts StartOptions) (*Session, error) {
+	return t.updateCurrent(func(current *Session) (*Session, error) {
+		// Check if there's already an active session
+		if current != nil && current.State != StateStopped {
+			return nil, fmt.Errorf("session already active (state: %s)", current.State)
+		}
 
-	return session, nil
+		now := time.Now()
+		session := &Session{
+			ID:        generateSessionID(),
+			Project:   project,
+			StartTime: now,
+			State:     StateRunning,
+			Tags:      NormalizeTags(opts.Tags),
+			Note:      opts.Note,
+		}
+		session.openInterval(IntervalWork, "", now)
+		return session, nil
+	})
 }
 
 // Stop stops the current work session
 func (t *Tracker) Stop() (*Session, error) {
-	session, err := t.GetCurrentSession()
-	if err != nil {
-		return nil, err
-	}
-	if session == nil {
-		return nil, fmt.Errorf("no active session to stop")
-	}
-
-	now := time.Now()
-	session.EndTime = &now
-	session.State = StateStopped
-
-	// Calculate total duration
-	if session.PausedAt != nil {
-		// If paused, don't include time since pause
-		session.Duration = session.PausedAt.Sub(session.StartTime)
-	} else {
-		session.Duration = now.Sub(session.StartTime)
-	}
-
-	if err := t.saveSession(session); err != nil {
-		return nil, err
-	}
-
-	if err := t.clearCurrentSession(); err != nil {
-		return nil, err
-	}
+	return t.updateCurrent(func(session *Session) (*Session, error) {
+		if session == nil {
+			return nil, fmt.Errorf("no active session to stop")
+		}
 
-	// Stop idle monitoring when session stops
-	t.StopIdleMonitoring()
+		now := time.Now()
+		session.closeInterval(now)
+		session.EndTime = &now
+		session.PausedAt = nil
+		session.State = StateStopped
 
-	return session, nil
+		// Total duration only counts work intervals
+		session.Duration = session.WorkedDuration(now)
+		return session, nil
+	})
 }
 
 // Pause pauses the current work session
 func (t *Tracker) Pause() (*Session, error) {
-	session, err := t.GetCurrentSession()
-	if err != nil {
-		return nil, err
-	}
-	if session == nil {
-		return nil, fmt.Errorf("no active session to pause")
-	}
-	if session.State != StateRunning {
-		return nil, fmt.Errorf("session is not running (state: %s)", session.State)
-	}
-
-	now := time.Now()
-	session.PausedAt = &now
-	session.State = StatePaused
-
-	if err := t.saveSession(session); err != nil {
-		return nil, err
-	}
+	return t.PauseWithReason(PauseManual)
+}
 
-	if err := t.setCurrentSession(session); err != nil {
-		return nil, err
-	}
+// PauseWithReason pauses the current work session, recording why it was paused
+func (t *Tracker) PauseWithReason(reason PauseReason) (*Session, error) {
+	return t.updateCurrent(func(session *Session) (*Session, error) {
+		if session == nil {
+			return nil, fmt.Errorf("no active session to pause")
+		}
+		if session.State != StateRunning {
+			return nil, fmt.Errorf("session is not running (state: %s)", session.State)
+		}
 
-	return session, nil
+		now := time.Now()
+		session.closeInterval(now)
+		session.openInterval(IntervalPause, reason, now)
+		session.PausedAt = &now
+		session.State = StatePaused
+		return session, nil
+	})
 }
 
 // Resume resumes a paused work session
 func (t *Tracker) Resume() (*Session, error) {
-	session, err := t.GetCurrentSession()
-	if err != nil {
-		return nil, err
-	}
-	if session == nil {
-		return nil, fmt.Errorf("no session to resume")
-	}
-	if session.State != StatePaused {
-		return nil, fmt.Errorf("session is not paused (state: %s)", session.State)
-	}
-
-	// Calculate duration while paused and adjust start time
-	pauseDuration := time.Since(*session.PausedAt)
-	session.StartTime = session.StartTime.Add(pauseDuration)
-	session.PausedAt = nil
-	session.State = StateRunning
-
-	if err := t.saveSession(session); err != nil {
-		return nil, err
-	}
-
-	if err := t.setCurrentSession(session); err != nil {
-		return nil, err
-	}
+	return t.updateCurrent(func(session *Session) (*Session, error) {
+		if session == nil {
+			return nil, fmt.Errorf("no session to resume")
+		}
+		if session.State != StatePaused {
+			return nil, fmt.Errorf("session is not paused (state: %s)", session.State)
+		}
 
-	return session, nil
+		now := time.Now()
+		session.closeInterval(now)
+		session.openInterval(IntervalWork, "", now)
+		session.PausedAt = nil
+		session.State = StateRunning
+		return session, nil
+	})
 }
 
 // GetCurrentSession returns the current active session
 func (t *Tracker) GetCurrentSession() (*Session, error) {
 	var session *Session
-
 	err := t.db.View(func(tx *bbolt.Tx) error {
-		bucket := tx.Bucket(currentBucket)
-		data := bucket.Get([]byte("session"))
-		if data == nil {
-			return nil
-		}
-
-		session = &Session{}
-		return json.Unmarshal(data, session)
+		var err error
+		session, err = currentSession(tx)
+		return err
 	})
-
 	return session, err
 }
 
@@ -271,13 +242,8 @@ func (t *Tracker) GetSessionDuration() (time.Duration, error) {
 	}
 
 	switch session.State {
-	case StateRunning:
-		return time.Since(session.StartTime), nil
-	case StatePaused:
-		if session.PausedAt != nil {
-			return session.PausedAt.Sub(session.StartTime), nil
-		}
-		return time.Since(session.StartTime), nil
+	case StateRunning, StatePaused:
+		return session.WorkedDuration(time.Now()), nil
 	case StateStopped:
 		return session.Duration, nil
 	default:
@@ -285,133 +251,103 @@ func (t *Tracker) GetSessionDuration() (time.Duration, error) {
 	}
 }
 
-// saveSession saves a session to the database
-func (t *Tracker) saveSession(session *Session) error {
-	return t.db.Update(func(tx *bbolt.Tx) error {
-		bucket := tx.Bucket(sessionsBucket)
-		data, err := json.Marshal(session)
+// updateCurrent passes the current session, or nil, to change and stores
+// the session it returns, as the current one unless it has stopped. It all
+// happens in one transaction, so concurrent changes such as a start racing
+// another or an idle pause racing a resume cannot both pass their checks.
+func (t *Tracker) updateCurrent(change func(current *Session) (*Session, error)) (*Session, error) {
+	var session *Session
+	err := t.db.Update(func(tx *bbolt.Tx) error {
+		current, err := currentSession(tx)
 		if err != nil {
 			return err
 		}
-		return bucket.Put([]byte(session.ID), data)
-	})
-}
+		if session, err = change(current); err != nil {
+			return err
+		}
+		if err := putSession(tx, session); err != nil {
+			return err
+		}
 
-// setCurrentSession sets the current active session
-func (t *Tracker) setCurrentSession(session *Session) error {
-	return t.db.Update(func(tx *bbolt.Tx) error {
 		bucket := tx.Bucket(currentBucket)
+		if session.State == StateStopped {
+			return bucket.Delete([]byte("session"))
+		}
 		data, err := json.Marshal(session)
 		if err != nil {
 			return err
 		}
 		return bucket.Put([]byte("session"), data)
 	})
+	if err != nil {
+		return nil, err
+	}
+	return session, nil
 }
 
-// clearCurrentSession clears the current session
-func (t *Tracker) clearCurrentSession() error {
-	return t.db.Update(func(tx *bbolt.Tx) error {
-		bucket := tx.Bucket(currentBucket)
-		return bucket.Delete([]byte("session"))
-	})
+// currentSession reads the current session within a transaction
+func currentSession(tx *bbolt.Tx) (*Session, error) {
+	data := tx.Bucket(currentBucket).Get([]byte("session"))
+	if data == nil {
+		return nil, nil
+	}
+	session := &Session{}
+	if err := json.Unmarshal(data, session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// SetCalendar sets the calendar used to compute day and week boundaries
+func (t *Tracker) SetCalendar(cal calendar.Calendar) {
+	t.calendar = cal
 }
 
 // GetDailyTotal returns the total time worked today
 func (t *Tracker) GetDailyTotal() (time.Duration, error) {
-	var total time.Duration
-	today := time.Now().Truncate(24 * time.Hour)
-	tomorrow := today.Add(24 * time.Hour)
-
-	err := t.db.View(func(tx *bbolt.Tx) error {
-		bucket := tx.Bucket(sessionsBucket)
-		cursor := bucket.Cursor()
-
-		for k, v := cursor.First(); k != nil; k, v = cursor.Next() {
-			var session Session
-			if err := json.Unmarshal(v, &session); err != nil {
-				continue
-			}
-
-			// Only count completed sessions from today
-			if session.State == StateStopped &&
-				session.StartTime.After(today) &&
-				session.StartTime.Before(tomorrow) {
-				total += session.Duration
-			}
-		}
-		return nil
-	})
-
-	return total, err
+	return t.totalIn(t.calendar.Today())
 }
 
 // GetWeeklyTotal returns the total time worked this week
 func (t *Tracker) GetWeeklyTotal() (time.Duration, error) {
-	var total time.Duration
-	now := time.Now()
-	weekStart := now.AddDate(0, 0, -int(now.Weekday()))
-	weekStart = weekStart.Truncate(24 * time.Hour)
-	weekEnd := weekStart.Add(7 * 24 * time.Hour)
-
-	err := t.db.View(func(tx *bbolt.Tx) error {
-		bucket := tx.Bucket(sessionsBucket)
-		cursor := bucket.Cursor()
-
-		for k, v := cursor.First(); k != nil; k, v = cursor.Next() {
-			var session Session
-			if err := json.Unmarshal(v, &session); err != nil {
-				continue
-			}
+	return t.totalIn(t.calendar.ThisWeek())
+}
 
-			if session.State == StateStopped &&
-				session.StartTime.After(weekStart) &&
-				session.StartTime.Before(weekEnd) {
-				total += session.Duration
-			}
-		}
-		return nil
-	})
+// totalIn sums the work time of completed sessions that falls within the period,
+// so sessions spanning a boundary only count the part inside it
+func (t *Tracker) totalIn(period calendar.Period) (time.Duration, error) {
+	sessions, err := t.Sessions(period.Start, period.End, SessionFilter{})
+	if err != nil {
+		return 0, err
+	}
 
-	return total, err
+	var total time.Duration
+	for _, session := range sessions {
+		total += session.WorkedBetween(period.Start, period.End)
+	}
+	return total, nil
 }
 
-// GetSessionHistory returns recent sessions
+// GetSessionHistory returns the most recent completed sessions, newest first.
+// A limit of zero or less returns every session.
 func (t *Tracker) GetSessionHistory(limit int) ([]*Session, error) {
 	var sessions []*Session
 
 	err := t.db.View(func(tx *bbolt.Tx) error {
-		bucket := tx.Bucket(sessionsBucket)
-		cursor := bucket.Cursor()
-
-		// Collect all sessions first
-		var allSessions []*Session
-		for k, v := cursor.First(); k != nil; k, v = cursor.Next() {
+		cursor := tx.Bucket(sessionsBucket).Cursor()
+		for k, v := cursor.Last(); k != nil; k, v = cursor.Prev() {
 			var session Session
 			if err := json.Unmarshal(v, &session); err != nil {
 				continue
 			}
-			if session.State == StateStopped {
-				allSessions = append(allSessions, &session)
+			if session.State != StateStopped {
+				continue
 			}
-		}
-
-		// Sort by start time (most recent first)
-		for i := 0; i < len(allSessions)-1; i++ {
-			for j := i + 1; j < len(allSessions); j++ {
-				if allSessions[i].StartTime.Before(allSessions[j].StartTime) {
-					allSessions[i], allSessions[j] = allSessions[j], allSessions[i]
-				}
+			sessions = append(sessions, &session)
+			if limit > 0 && len(sessions) == limit {
+				break
 			}
 		}
-
-		// Take only the requested number
-		if limit > 0 && len(allSessions) > limit {
-			sessions = allSessions[:limit]
-		} else {
-			sessions = allSessions
-		}
-
 		return nil
 	})
 
@@ -423,20 +359,21 @@ func (t *Tracker) GetProjectStats() (map[string]time.Duration, error) {
 	stats := make(map[string]time.Duration)
 
 	err := t.db.View(func(tx *bbolt.Tx) error {
-		bucket := tx.Bucket(sessionsBucket)
-		cursor := bucket.Cursor()
-
-		for k, v := cursor.First(); k != nil; k, v = cursor.Next() {
-			var session Session
-			if err := json.Unmarshal(v, &session); err != nil {
-				continue
-			}
-
-			if session.State == StateStopped {
-				stats[session.Project] += session.Duration
-			}
-		}
-		return nil
+		primary := tx.Bucket(sessionsBucket)
+		projects := tx.Bucket(projectsBucket)
+
+		return projects.ForEach(func(name, _ []byte) error {
+			return projects.Bucket(name).ForEach(func(k, _ []byte) error {
+				var session Session
+				if err := json.Unmarshal(primary.Get(k), &session); err != nil {
+					return nil
+				}
+				if session.State == StateStopped {
+					stats[session.Project] += session.Duration
+				}
+				return nil
+			})
+		})
 	})
 
 	return stats, err
@@ -447,8 +384,9 @@ func (t *Tracker) SetIdleThreshold(threshold time.Duration) {
 	t.idleDetector = NewIdleDetector(threshold)
 }
 
-// StartIdleMonitoring starts monitoring for idle state changes
-func (t *Tracker) StartIdleMonitoring() error {
+// StartIdleMonitoring starts monitoring for idle state changes, pausing the
+// running session when the user goes idle and passing it to onPause
+func (t *Tracker) StartIdleMonitoring(onPause func(*Session)) error {
 	if t.idleStop != nil {
 		// Already monitoring
 		return nil
@@ -463,7 +401,10 @@ func (t *Tracker) StartIdleMonitoring() error {
 			}
 
 			// Auto-pause due to idle
-			_, _ = t.Pause()
+			paused, err := t.PauseWithReason(PauseIdle)
+			if err == nil && onPause != nil {
+				onPause(paused)
+			}
 		},
 		func() {
 			// On idle end - could potentially resume, but we'll leave that manual
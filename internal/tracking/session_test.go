@@ -1,13 +1,17 @@
 package tracking
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/ferg-cod3s/rune/internal/calendar"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.etcd.io/bbolt"
 )
 
 func TestSessionState_String(t *testing.T) {
@@ -95,6 +99,7 @@ func TestTracker_PauseResumeSession(t *testing.T) {
 	assert.Equal(t, session.ID, resumedSession.ID)
 	assert.Equal(t, StateRunning, resumedSession.State)
 	assert.Nil(t, resumedSession.PausedAt)
+	assert.Equal(t, PauseManual, resumedSession.LastPauseReason())
 
 	// Test resuming already running session should fail
 	_, err = tracker.Resume()
@@ -106,6 +111,161 @@ func TestTracker_PauseResumeSession(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestTracker_PauseResumeRecordsIntervals(t *testing.T) {
+	tracker := setupTestTracker(t)
+	defer tracker.Close()
+
+	session, err := tracker.Start("test-project")
+	require.NoError(t, err)
+	startTime := session.StartTime
+
+	_, err = tracker.PauseWithReason(PauseBreak)
+	require.NoError(t, err)
+	time.Sleep(10 * time.Millisecond)
+
+	resumed, err := tracker.Resume()
+	require.NoError(t, err)
+	assert.True(t, startTime.Equal(resumed.StartTime), "resume must not shift the start time")
+
+	_, err = tracker.PauseWithReason(PauseIdle)
+	require.NoError(t, err)
+	_, err = tracker.Resume()
+	require.NoError(t, err)
+
+	stopped, err := tracker.Stop()
+	require.NoError(t, err)
+
+	require.Len(t, stopped.Intervals, 5)
+	kinds := []IntervalKind{IntervalWork, IntervalPause, IntervalWork, IntervalPause, IntervalWork}
+	for i, interval := range stopped.Intervals {
+		assert.Equal(t, kinds[i], interval.Kind)
+		require.NotNil(t, interval.End, "interval %d should be closed", i)
+	}
+	assert.Equal(t, PauseBreak, stopped.Intervals[1].Reason)
+	assert.Equal(t, PauseIdle, stopped.Intervals[3].Reason)
+	assert.Equal(t, 2, stopped.PauseCount())
+
+	// Duration excludes pauses
+	assert.Equal(t, stopped.WorkedDuration(*stopped.EndTime), stopped.Duration)
+	assert.True(t, stopped.PausedDuration(*stopped.EndTime) >= 10*time.Millisecond)
+	assert.Equal(t, stopped.EndTime.Sub(stopped.StartTime), stopped.Duration+stopped.PausedDuration(*stopped.EndTime))
+}
+
+func TestTracker_MigratesLegacySessions(t *testing.T) {
+	tempDir := t.TempDir()
+	originalHome := os.Getenv("HOME")
+	os.Setenv("HOME", tempDir)
+	t.Cleanup(func() {
+		os.Setenv("HOME", originalHome)
+	})
+
+	// Write a pre-interval record directly, as older versions did
+	start := time.Date(2025, 1, 6, 9, 0, 0, 0, time.UTC)
+	end := start.Add(3 * time.Hour)
+	legacy := Session{
+		ID:        "session_1",
+		Project:   "legacy",
+		StartTime: start,
+		EndTime:   &end,
+		Duration:  2 * time.Hour,
+		State:     StateStopped,
+	}
+	tagged := Session{
+		ID:        "session_2",
+		Project:   "legacy",
+		StartTime: end.Add(time.Hour),
+		Duration:  time.Hour,
+		State:     StateStopped,
+		Tags:      []string{"bug"},
+	}
+	taggedEnd := tagged.StartTime.Add(time.Hour)
+	tagged.EndTime = &taggedEnd
+
+	tracker, err := NewTracker()
+	require.NoError(t, err)
+	require.NoError(t, tracker.db.Update(func(tx *bbolt.Tx) error {
+		// Older versions keyed sessions by ID and kept no indexes
+		for _, session := range []Session{legacy, tagged} {
+			data, err := json.Marshal(session)
+			if err != nil {
+				return err
+			}
+			if err := tx.Bucket(sessionsBucket).Put([]byte(session.ID), data); err != nil {
+				return err
+			}
+		}
+		return tx.Bucket(metaBucket).Delete(schemaVersionKey)
+	}))
+	require.NoError(t, tracker.Close())
+
+	tracker, err = NewTracker()
+	require.NoError(t, err)
+	defer tracker.Close()
+
+	sessions, err := tracker.GetSessionHistory(0)
+	require.NoError(t, err)
+	require.Len(t, sessions, 2)
+	assert.Equal(t, "session_2", sessions[0].ID)
+
+	withTag, err := tracker.SessionsWithTag("bug")
+	require.NoError(t, err)
+	require.Len(t, withTag, 1)
+	assert.Equal(t, "session_2", withTag[0].ID)
+
+	migrated, err := tracker.GetSession("session_1")
+	require.NoError(t, err)
+	require.Len(t, migrated.Intervals, 2)
+	assert.Equal(t, IntervalWork, migrated.Intervals[0].Kind)
+	assert.Equal(t, IntervalPause, migrated.Intervals[1].Kind)
+	assert.Equal(t, 2*time.Hour, migrated.WorkedDuration(end))
+	assert.Equal(t, time.Hour, migrated.PausedDuration(end))
+}
+
+func TestTracker_MigrationKeepsUnreadableSessions(t *testing.T) {
+	tempDir := t.TempDir()
+	originalHome := os.Getenv("HOME")
+	os.Setenv("HOME", tempDir)
+	t.Cleanup(func() {
+		os.Setenv("HOME", originalHome)
+	})
+
+	start := time.Date(2025, 1, 6, 9, 0, 0, 0, time.UTC)
+	end := start.Add(time.Hour)
+	good := Session{ID: "session_1", Project: "legacy", StartTime: start, EndTime: &end, Duration: time.Hour, State: StateStopped}
+
+	tracker, err := NewTracker()
+	require.NoError(t, err)
+	require.NoError(t, tracker.db.Update(func(tx *bbolt.Tx) error {
+		data, err := json.Marshal(good)
+		if err != nil {
+			return err
+		}
+		if err := tx.Bucket(sessionsBucket).Put([]byte(good.ID), data); err != nil {
+			return err
+		}
+		if err := tx.Bucket(sessionsBucket).Put([]byte("session_bad"), []byte("{not json")); err != nil {
+			return err
+		}
+		return tx.Bucket(metaBucket).Delete(schemaVersionKey)
+	}))
+	require.NoError(t, tracker.Close())
+
+	// One unreadable record must not make the whole database unusable
+	tracker, err = NewTracker()
+	require.NoError(t, err)
+	defer tracker.Close()
+
+	sessions, err := tracker.GetSessionHistory(0)
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, "session_1", sessions[0].ID)
+
+	require.NoError(t, tracker.db.View(func(tx *bbolt.Tx) error {
+		assert.Equal(t, "{not json", string(tx.Bucket(sessionsBucket).Get([]byte("session_bad"))))
+		return nil
+	}))
+}
+
 func TestTracker_GetSessionDuration(t *testing.T) {
 	tracker := setupTestTracker(t)
 	defer tracker.Close()
@@ -170,6 +330,48 @@ func TestTracker_ErrorCases(t *testing.T) {
 	assert.Contains(t, err.Error(), "no session to resume")
 }
 
+func TestTracker_ConcurrentTransitions(t *testing.T) {
+	tracker := setupTestTracker(t)
+	defer tracker.Close()
+
+	// Only one of several racing starts may win
+	var wg sync.WaitGroup
+	started := make(chan *Session, 8)
+	for i := 0; i < cap(started); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if session, err := tracker.Start("race"); err == nil {
+				started <- session
+			}
+		}()
+	}
+	wg.Wait()
+	close(started)
+	require.Len(t, started, 1)
+	winner := <-started
+
+	// Likewise only one of several racing pauses
+	var paused sync.WaitGroup
+	pauses := make(chan struct{}, 8)
+	for i := 0; i < cap(pauses); i++ {
+		paused.Add(1)
+		go func() {
+			defer paused.Done()
+			if _, err := tracker.PauseWithReason(PauseIdle); err == nil {
+				pauses <- struct{}{}
+			}
+		}()
+	}
+	paused.Wait()
+	assert.Len(t, pauses, 1)
+
+	current, err := tracker.GetCurrentSession()
+	require.NoError(t, err)
+	assert.Equal(t, winner.ID, current.ID)
+	assert.Len(t, current.Intervals, 2)
+}
+
 func TestTracker_GetDailyTotal(t *testing.T) {
 	tracker := setupTestTracker(t)
 	defer tracker.Close()
@@ -189,6 +391,28 @@ func TestTracker_GetDailyTotal(t *testing.T) {
 	assert.True(t, dailyTotal > 0)
 }
 
+func TestTracker_GetDailyTotalUsesCalendar(t *testing.T) {
+	tracker := setupTestTracker(t)
+	defer tracker.Close()
+
+	now := time.Now().UTC()
+	start := now.Add(-2 * time.Hour)
+	_, err := tracker.LogSession("late-night", start, start.Add(30*time.Minute), "", nil)
+	require.NoError(t, err)
+
+	// A day starting at the current hour began after the session
+	tracker.SetCalendar(calendar.Calendar{Location: time.UTC, RolloverHour: now.Hour()})
+	total, err := tracker.GetDailyTotal()
+	require.NoError(t, err)
+	assert.Equal(t, time.Duration(0), total)
+
+	// A day starting two hours earlier includes it
+	tracker.SetCalendar(calendar.Calendar{Location: time.UTC, RolloverHour: (now.Hour() + 22) % 24})
+	total, err = tracker.GetDailyTotal()
+	require.NoError(t, err)
+	assert.Equal(t, 30*time.Minute, total)
+}
+
 func TestTracker_GetWeeklyTotal(t *testing.T) {
 	tracker := setupTestTracker(t)
 	defer tracker.Close()
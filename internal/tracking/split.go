@@ -0,0 +1,101 @@
+package tracking
+
+import (
+	"time"
+
+	"github.com/ferg-cod3s/rune/internal/calendar"
+)
+
+// WorkedBetween returns the work time that falls within [from, to), treating an open interval as ending now
+func (s *Session) WorkedBetween(from, to time.Time) time.Duration {
+	now := time.Now()
+	var total time.Duration
+	for _, interval := range s.Intervals {
+		if interval.Kind != IntervalWork {
+			continue
+		}
+		end := now
+		if interval.End != nil {
+			end = *interval.End
+		}
+		if overlap := clampDuration(interval.Start, end, from, to); overlap > 0 {
+			total += overlap
+		}
+	}
+	return total
+}
+
+// Clip returns a copy of the session trimmed to [from, to), with its intervals
+// and duration limited to that range, or nil if the session lies outside it.
+// Clipped copies keep the session ID, so pieces of one session can be matched up.
+func (s *Session) Clip(from, to time.Time) *Session {
+	start, end := s.span()
+	clippedEnd := end.After(to)
+	if start.Before(from) {
+		start = from
+	}
+	if clippedEnd {
+		end = to
+	}
+	if !end.After(start) {
+		return nil
+	}
+
+	now := time.Now()
+	var intervals []Interval
+	for _, interval := range s.Intervals {
+		intervalEnd := now
+		if interval.End != nil {
+			intervalEnd = *interval.End
+		}
+		if !intervalEnd.After(start) || !interval.Start.Before(end) {
+			continue
+		}
+		if interval.Start.Before(start) {
+			interval.Start = start
+		}
+		// Open intervals stay open unless the range cuts them off
+		if interval.End != nil || intervalEnd.After(end) {
+			if intervalEnd.After(end) {
+				intervalEnd = end
+			}
+			interval.End = &intervalEnd
+		}
+		intervals = append(intervals, interval)
+	}
+
+	clipped := s.clone()
+	clipped.Intervals = intervals
+	clipped.StartTime = start
+	if s.EndTime != nil || clippedEnd {
+		clipped.EndTime = &end
+		clipped.PausedAt = nil
+	}
+	clipped.Duration = s.WorkedBetween(start, end)
+	return clipped
+}
+
+// SplitByDay splits the session at the calendar's day boundaries, returning
+// one clipped piece per day the session touches, in order
+func (s *Session) SplitByDay(cal calendar.Calendar) []*Session {
+	start, end := s.span()
+
+	var pieces []*Session
+	for day := cal.Day(start); day.Start.Before(end); day = cal.Day(day.End) {
+		if piece := s.Clip(day.Start, day.End); piece != nil {
+			pieces = append(pieces, piece)
+		}
+	}
+	return pieces
+}
+
+// clampDuration returns the length of the overlap between [start, end) and [from, to)
+func clampDuration(start, end, from, to time.Time) time.Duration {
+	if start.Before(from) {
+		start = from
+	}
+	if end.After(to) {
+		end = to
+	}
+	return end.Sub(start)
+}
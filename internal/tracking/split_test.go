@@ -0,0 +1,109 @@
+package tracking
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ferg-cod3s/rune/internal/calendar"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// overnightSession works 22:00-23:30, pauses until 00:30, then works until 02:00
+func overnightSession(day time.Time) *Session {
+	at := func(hour, minute int) *time.Time {
+		t := time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, day.Location())
+		return &t
+	}
+	session := &Session{
+		ID:        "session_overnight",
+		Project:   "api",
+		StartTime: *at(22, 0),
+		EndTime:   at(26, 0),
+		State:     StateStopped,
+		Intervals: []Interval{
+			{Kind: IntervalWork, Start: *at(22, 0), End: at(23, 30)},
+			{Kind: IntervalPause, Start: *at(23, 30), End: at(24, 30), Reason: PauseBreak},
+			{Kind: IntervalWork, Start: *at(24, 30), End: at(26, 0)},
+		},
+	}
+	session.Duration = session.WorkedDuration(*session.EndTime)
+	return session
+}
+
+func TestSession_SplitByDay(t *testing.T) {
+	day := time.Date(2025, 6, 10, 0, 0, 0, 0, time.UTC)
+	session := overnightSession(day)
+	require.Equal(t, 3*time.Hour, session.Duration)
+
+	t.Run("splits at midnight", func(t *testing.T) {
+		pieces := session.SplitByDay(calendar.Calendar{Location: time.UTC})
+		require.Len(t, pieces, 2)
+
+		assert.Equal(t, session.ID, pieces[0].ID)
+		assert.Equal(t, 90*time.Minute, pieces[0].Duration)
+		assert.Equal(t, time.Date(2025, 6, 11, 0, 0, 0, 0, time.UTC), *pieces[0].EndTime)
+		assert.Len(t, pieces[0].Intervals, 2)
+
+		assert.Equal(t, 90*time.Minute, pieces[1].Duration)
+		assert.Equal(t, time.Date(2025, 6, 11, 0, 0, 0, 0, time.UTC), pieces[1].StartTime)
+		assert.Equal(t, IntervalPause, pieces[1].Intervals[0].Kind)
+		assert.Equal(t, 30*time.Minute, pieces[1].PausedDuration(*pieces[1].EndTime))
+	})
+
+	t.Run("rollover hour keeps the whole session on one day", func(t *testing.T) {
+		pieces := session.SplitByDay(calendar.Calendar{Location: time.UTC, RolloverHour: 4})
+		require.Len(t, pieces, 1)
+		assert.Equal(t, 3*time.Hour, pieces[0].Duration)
+	})
+
+	t.Run("splits in the calendar's timezone", func(t *testing.T) {
+		tokyo, err := time.LoadLocation("Asia/Tokyo")
+		require.NoError(t, err)
+		// 22:00-02:00 UTC is 07:00-11:00 in Tokyo, all on one day
+		pieces := session.SplitByDay(calendar.Calendar{Location: tokyo})
+		assert.Len(t, pieces, 1)
+	})
+
+	t.Run("does not modify the original session", func(t *testing.T) {
+		assert.Equal(t, 3*time.Hour, session.Duration)
+		assert.Len(t, session.Intervals, 3)
+	})
+}
+
+func TestSession_Clip(t *testing.T) {
+	day := time.Date(2025, 6, 10, 0, 0, 0, 0, time.UTC)
+	session := overnightSession(day)
+
+	clipped := session.Clip(day.Add(23*time.Hour), day.Add(25*time.Hour))
+	require.NotNil(t, clipped)
+	assert.Equal(t, day.Add(23*time.Hour), clipped.StartTime)
+	assert.Equal(t, day.Add(25*time.Hour), *clipped.EndTime)
+	assert.Equal(t, time.Hour, clipped.Duration)
+
+	assert.Nil(t, session.Clip(day, day.Add(22*time.Hour)), "range ending at the start does not overlap")
+	assert.Equal(t, 3*time.Hour, session.Clip(day, day.Add(48*time.Hour)).Duration)
+}
+
+func TestTracker_SessionsSpanningMidnight(t *testing.T) {
+	tracker := setupTestTracker(t)
+	defer tracker.Close()
+
+	cal := calendar.Calendar{Location: time.Local}
+	yesterday := cal.Day(time.Now().AddDate(0, 0, -1))
+	start := yesterday.Start.Add(-2 * time.Hour)
+	_, err := tracker.LogSession("api", start, yesterday.Start.Add(3*time.Hour), "", nil)
+	require.NoError(t, err)
+
+	sessions, err := tracker.Sessions(yesterday.Start, yesterday.End, SessionFilter{})
+	require.NoError(t, err)
+	require.Len(t, sessions, 1, "sessions started before the range but running into it are included")
+
+	total, err := tracker.totalIn(yesterday)
+	require.NoError(t, err)
+	assert.Equal(t, 3*time.Hour, total)
+
+	sessions, err = tracker.Sessions(yesterday.Start, yesterday.End, SessionFilter{Project: "web"})
+	require.NoError(t, err)
+	assert.Empty(t, sessions)
+}
@@ -0,0 +1,252 @@
+package tracking
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+// Sessions are stored under time-ordered keys (big-endian start time in
+// nanoseconds followed by the session ID) so range queries are cursor seeks.
+// Secondary indexes map IDs, projects and days back to those keys.
+var (
+	sessionIDsBucket = []byte("session_ids")
+	projectsBucket   = []byte("by_project")
+	daysBucket       = []byte("by_day")
+)
+
+// dayKeyFormat names the per-day index buckets, one per UTC day a session touches
+const dayKeyFormat = "2006-01-02"
+
+// SessionFilter narrows a session range query. Zero values match everything.
+type SessionFilter struct {
+	Project string
+	// Tags must all be present on a session for it to match
+	Tags []string
+}
+
+// matches reports whether a session satisfies the filter
+func (f SessionFilter) matches(session *Session) bool {
+	if session.State != StateStopped {
+		return false
+	}
+	if f.Project != "" && session.Project != f.Project {
+		return false
+	}
+	for _, tag := range f.Tags {
+		if !session.HasTag(tag) {
+			return false
+		}
+	}
+	return true
+}
+
+// Sessions returns completed sessions that overlap [from, to), ordered by start
+// time. Sessions crossing either boundary are returned whole; use Clip or
+// SplitByDay to attribute their time to the range.
+func (t *Tracker) Sessions(from, to time.Time, filter SessionFilter) ([]*Session, error) {
+	var sessions []*Session
+
+	err := t.db.View(func(tx *bbolt.Tx) error {
+		var err error
+		sessions, err = sessionsInRange(tx, from, to, filter)
+		return err
+	})
+
+	return sessions, err
+}
+
+// sessionsInRange finds sessions that started before from but run into the
+// range through the day index, then scans sessions starting within the range
+// from the primary bucket, or the project index when filtering by project
+func sessionsInRange(tx *bbolt.Tx, from, to time.Time, filter SessionFilter) ([]*Session, error) {
+	var sessions []*Session
+
+	primary := tx.Bucket(sessionsBucket)
+	fromKey, toKey := timePrefix(from), timePrefix(to)
+
+	// Any session still running at from is indexed under from's day
+	if index := tx.Bucket(daysBucket).Bucket([]byte(from.UTC().Format(dayKeyFormat))); index != nil {
+		cursor := index.Cursor()
+		for k, _ := cursor.First(); k != nil && bytes.Compare(k, fromKey) < 0; k, _ = cursor.Next() {
+			var session Session
+			if err := json.Unmarshal(primary.Get(k), &session); err != nil {
+				continue
+			}
+			if _, end := session.span(); end.After(from) && filter.matches(&session) {
+				sessions = append(sessions, &session)
+			}
+		}
+	}
+
+	scan := primary
+	if filter.Project != "" {
+		scan = tx.Bucket(projectsBucket).Bucket([]byte(filter.Project))
+		if scan == nil {
+			return sessions, nil
+		}
+	}
+
+	cursor := scan.Cursor()
+	for k, v := cursor.Seek(fromKey); k != nil && bytes.Compare(k, toKey) < 0; k, v = cursor.Next() {
+		if scan != primary {
+			v = primary.Get(k)
+		}
+		var session Session
+		if err := json.Unmarshal(v, &session); err != nil {
+			continue
+		}
+		if filter.matches(&session) {
+			sessions = append(sessions, &session)
+		}
+	}
+
+	return sessions, nil
+}
+
+// timePrefix encodes a time as the sortable prefix of a session key
+func timePrefix(t time.Time) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, uint64(t.UnixNano()))
+	return key
+}
+
+// sessionKey returns the primary key of a session
+func sessionKey(session *Session) []byte {
+	return append(timePrefix(session.StartTime), session.ID...)
+}
+
+// sessionDays returns the index keys of every UTC day the session touches
+func sessionDays(session *Session) [][]byte {
+	start, end := session.span()
+	day := start.UTC().Truncate(24 * time.Hour)
+
+	var days [][]byte
+	for !day.After(end) {
+		days = append(days, []byte(day.Format(dayKeyFormat)))
+		day = day.Add(24 * time.Hour)
+	}
+	return days
+}
+
+// getSession reads a session inside a transaction
+func getSession(tx *bbolt.Tx, id string) (*Session, error) {
+	session := storedSession(tx, id)
+	if session == nil {
+		return nil, fmt.Errorf("session not found: %s", id)
+	}
+	return session, nil
+}
+
+// storedSession returns the stored copy of a session, or nil if there is none
+func storedSession(tx *bbolt.Tx, id string) *Session {
+	key := tx.Bucket(sessionIDsBucket).Get([]byte(id))
+	if key == nil {
+		return nil
+	}
+	data := tx.Bucket(sessionsBucket).Get(key)
+	if data == nil {
+		return nil
+	}
+	var session Session
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil
+	}
+	return &session
+}
+
+// putSession writes a session inside a transaction, keeping all indexes in sync
+func putSession(tx *bbolt.Tx, session *Session) error {
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+
+	var oldTags []string
+	if old := storedSession(tx, session.ID); old != nil {
+		oldTags = old.Tags
+		if err := unindexSession(tx, old); err != nil {
+			return err
+		}
+	}
+	if err := indexTags(tx, session.ID, oldTags, session.Tags); err != nil {
+		return err
+	}
+
+	key := sessionKey(session)
+	if err := tx.Bucket(sessionsBucket).Put(key, data); err != nil {
+		return err
+	}
+	if err := tx.Bucket(sessionIDsBucket).Put([]byte(session.ID), key); err != nil {
+		return err
+	}
+	if err := putIndex(tx.Bucket(projectsBucket), []byte(session.Project), key); err != nil {
+		return fmt.Errorf("failed to index project %q: %w", session.Project, err)
+	}
+	for _, day := range sessionDays(session) {
+		if err := putIndex(tx.Bucket(daysBucket), day, key); err != nil {
+			return fmt.Errorf("failed to index day %s: %w", day, err)
+		}
+	}
+	return nil
+}
+
+// deleteSession removes a session and all of its index entries inside a transaction
+func deleteSession(tx *bbolt.Tx, id string) error {
+	session := storedSession(tx, id)
+	if session == nil {
+		return nil
+	}
+	if err := indexTags(tx, id, session.Tags, nil); err != nil {
+		return err
+	}
+	return unindexSession(tx, session)
+}
+
+// unindexSession removes a stored session's primary record and project, day and ID entries
+func unindexSession(tx *bbolt.Tx, session *Session) error {
+	key := sessionKey(session)
+	if err := tx.Bucket(sessionsBucket).Delete(key); err != nil {
+		return err
+	}
+	if err := tx.Bucket(sessionIDsBucket).Delete([]byte(session.ID)); err != nil {
+		return err
+	}
+	if err := deleteIndex(tx.Bucket(projectsBucket), []byte(session.Project), key); err != nil {
+		return err
+	}
+	for _, day := range sessionDays(session) {
+		if err := deleteIndex(tx.Bucket(daysBucket), day, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// putIndex adds key to the nested index bucket called name
+func putIndex(parent *bbolt.Bucket, name, key []byte) error {
+	index, err := parent.CreateBucketIfNotExists(name)
+	if err != nil {
+		return err
+	}
+	return index.Put(key, []byte{})
+}
+
+// deleteIndex removes key from the nested index bucket called name, dropping the bucket once empty
+func deleteIndex(parent *bbolt.Bucket, name, key []byte) error {
+	index := parent.Bucket(name)
+	if index == nil {
+		return nil
+	}
+	if err := index.Delete(key); err != nil {
+		return err
+	}
+	if k, _ := index.Cursor().First(); k == nil {
+		return parent.DeleteBucket(name)
+	}
+	return nil
+}
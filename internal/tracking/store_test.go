@@ -0,0 +1,236 @@
+package tracking
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/bbolt"
+)
+
+func TestTracker_Sessions(t *testing.T) {
+	tracker := setupTestTracker(t)
+	defer tracker.Close()
+
+	base := time.Now().Add(-72 * time.Hour).Truncate(time.Hour)
+	logged := []struct {
+		project string
+		offset  time.Duration
+		tags    []string
+	}{
+		{"api", 0, []string{"bug"}},
+		{"web", 2 * time.Hour, nil},
+		{"api", 24 * time.Hour, []string{"bug", "urgent"}},
+		{"api", 48 * time.Hour, nil},
+	}
+	for _, l := range logged {
+		start := base.Add(l.offset)
+		_, err := tracker.LogSession(l.project, start, start.Add(time.Hour), "", l.tags)
+		require.NoError(t, err)
+	}
+
+	t.Run("returns sessions in range oldest first", func(t *testing.T) {
+		sessions, err := tracker.Sessions(base, base.Add(25*time.Hour), SessionFilter{})
+		require.NoError(t, err)
+		require.Len(t, sessions, 3)
+		assert.Equal(t, "api", sessions[0].Project)
+		assert.Equal(t, "web", sessions[1].Project)
+		assert.True(t, sessions[1].StartTime.Before(sessions[2].StartTime))
+	})
+
+	t.Run("range end is exclusive", func(t *testing.T) {
+		sessions, err := tracker.Sessions(base, base.Add(2*time.Hour), SessionFilter{})
+		require.NoError(t, err)
+		assert.Len(t, sessions, 1)
+	})
+
+	t.Run("filters by project using the project index", func(t *testing.T) {
+		sessions, err := tracker.Sessions(base, base.Add(72*time.Hour), SessionFilter{Project: "api"})
+		require.NoError(t, err)
+		assert.Len(t, sessions, 3)
+
+		sessions, err = tracker.Sessions(base, base.Add(72*time.Hour), SessionFilter{Project: "missing"})
+		require.NoError(t, err)
+		assert.Empty(t, sessions)
+	})
+
+	t.Run("filters by all tags", func(t *testing.T) {
+		sessions, err := tracker.Sessions(base, base.Add(72*time.Hour), SessionFilter{Tags: []string{"bug", "urgent"}})
+		require.NoError(t, err)
+		require.Len(t, sessions, 1)
+		assert.True(t, base.Add(24*time.Hour).Equal(sessions[0].StartTime))
+	})
+
+	t.Run("excludes the active session", func(t *testing.T) {
+		_, err := tracker.Start("api")
+		require.NoError(t, err)
+		defer func() { _, _ = tracker.Stop() }()
+
+		sessions, err := tracker.Sessions(base, time.Now().Add(time.Hour), SessionFilter{})
+		require.NoError(t, err)
+		assert.Len(t, sessions, 4)
+	})
+}
+
+func TestTracker_EditSessionReindexes(t *testing.T) {
+	tracker := setupTestTracker(t)
+	defer tracker.Close()
+
+	start := time.Now().Add(-48 * time.Hour).Truncate(time.Hour)
+	session, err := tracker.LogSession("api", start, start.Add(time.Hour), "", nil)
+	require.NoError(t, err)
+
+	project := "web"
+	newStart := start.Add(24 * time.Hour)
+	newEnd := newStart.Add(time.Hour)
+	_, err = tracker.EditSession(session.ID, SessionEdit{Project: &project, Start: &newStart, End: &newEnd})
+	require.NoError(t, err)
+
+	sessions, err := tracker.Sessions(start, newStart, SessionFilter{})
+	require.NoError(t, err)
+	assert.Empty(t, sessions, "old time key should be removed")
+
+	sessions, err = tracker.Sessions(newStart, newEnd, SessionFilter{Project: "web"})
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, session.ID, sessions[0].ID)
+
+	sessions, err = tracker.Sessions(start, newEnd, SessionFilter{Project: "api"})
+	require.NoError(t, err)
+	assert.Empty(t, sessions, "old project index entry should be removed")
+
+	// A session logged on the new day must still see the moved session as an overlap
+	_, err = tracker.LogSession("docs", newStart.Add(30*time.Minute), newEnd.Add(30*time.Minute), "", nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), session.ID)
+
+	// Deleting drops every index entry
+	_, err = tracker.DeleteSession(session.ID)
+	require.NoError(t, err)
+	require.NoError(t, tracker.db.View(func(tx *bbolt.Tx) error {
+		for _, name := range [][]byte{sessionsBucket, sessionIDsBucket, projectsBucket, daysBucket} {
+			k, _ := tx.Bucket(name).Cursor().First()
+			assert.Nil(t, k, "bucket %s should be empty", name)
+		}
+		return nil
+	}))
+}
+
+func TestSessionDays(t *testing.T) {
+	start := time.Date(2025, 3, 1, 22, 0, 0, 0, time.UTC)
+	end := start.Add(27 * time.Hour)
+	session := &Session{StartTime: start, EndTime: &end}
+
+	days := sessionDays(session)
+	require.Len(t, days, 3)
+	assert.Equal(t, "2025-03-01", string(days[0]))
+	assert.Equal(t, "2025-03-03", string(days[2]))
+}
+
+// benchmarkSessionCount is the number of sessions seeded for storage benchmarks
+const benchmarkSessionCount = 100_000
+
+// setupBenchmarkTracker creates a tracker holding benchmarkSessionCount
+// completed sessions, one every 30 minutes up to now, across 20 projects
+func setupBenchmarkTracker(b *testing.B) *Tracker {
+	b.Helper()
+
+	tempDir := b.TempDir()
+	originalHome := os.Getenv("HOME")
+	os.Setenv("HOME", tempDir)
+	b.Cleanup(func() {
+		os.Setenv("HOME", originalHome)
+	})
+
+	tracker, err := NewTracker()
+	require.NoError(b, err)
+	b.Cleanup(func() { tracker.Close() })
+
+	// Insert oldest first in batches; bbolt appends sorted keys cheaply
+	now := time.Now().Truncate(time.Minute)
+	const batch = 5000
+	for first := benchmarkSessionCount; first > 0; first -= batch {
+		err := tracker.db.Update(func(tx *bbolt.Tx) error {
+			for i := first; i > first-batch && i > 0; i-- {
+				start := now.Add(-time.Duration(i) * 30 * time.Minute)
+				end := start.Add(25 * time.Minute)
+				session := &Session{
+					ID:        fmt.Sprintf("session_%d", start.UnixNano()),
+					Project:   fmt.Sprintf("project-%d", i%20),
+					StartTime: start,
+					EndTime:   &end,
+					Duration:  25 * time.Minute,
+					State:     StateStopped,
+					Intervals: []Interval{{Kind: IntervalWork, Start: start, End: &end}},
+				}
+				if err := putSession(tx, session); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		require.NoError(b, err)
+	}
+
+	b.ResetTimer()
+	return tracker
+}
+
+func BenchmarkTracker_SessionsWeek(b *testing.B) {
+	tracker := setupBenchmarkTracker(b)
+	to := time.Now()
+	from := to.Add(-7 * 24 * time.Hour)
+
+	for i := 0; i < b.N; i++ {
+		if _, err := tracker.Sessions(from, to, SessionFilter{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkTracker_SessionsProjectMonth(b *testing.B) {
+	tracker := setupBenchmarkTracker(b)
+	to := time.Now()
+	from := to.AddDate(0, -1, 0)
+
+	for i := 0; i < b.N; i++ {
+		if _, err := tracker.Sessions(from, to, SessionFilter{Project: "project-3"}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkTracker_GetSessionHistory(b *testing.B) {
+	tracker := setupBenchmarkTracker(b)
+
+	for i := 0; i < b.N; i++ {
+		if _, err := tracker.GetSessionHistory(50); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkTracker_GetDailyTotal(b *testing.B) {
+	tracker := setupBenchmarkTracker(b)
+
+	for i := 0; i < b.N; i++ {
+		if _, err := tracker.GetDailyTotal(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkTracker_LogSession(b *testing.B) {
+	tracker := setupBenchmarkTracker(b)
+	start := time.Now().Add(-time.Duration(benchmarkSessionCount+b.N+1) * 30 * time.Minute)
+
+	for i := 0; i < b.N; i++ {
+		sessionStart := start.Add(time.Duration(i) * 30 * time.Minute)
+		if _, err := tracker.LogSession("bench", sessionStart, sessionStart.Add(10*time.Minute), "", nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package tracking
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+// tagsBucket holds one nested bucket per tag, keyed by the IDs of sessions carrying it
+var tagsBucket = []byte("tags")
+
+// TagStats summarizes the sessions carrying a tag
+type TagStats struct {
+	Tag      string        `json:"tag"`
+	Sessions int           `json:"sessions"`
+	Duration time.Duration `json:"duration"`
+}
+
+// NormalizeTags trims tags, drops empty ones and removes duplicates while preserving order
+func NormalizeTags(tags []string) []string {
+	var normalized []string
+	seen := make(map[string]bool)
+	for _, tag := range tags {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "#")
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	return normalized
+}
+
+// HasTag reports whether the session carries the given tag
+func (s *Session) HasTag(tag string) bool {
+	for _, t := range s.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// AddTags adds tags to the current session
+func (t *Tracker) AddTags(tags ...string) (*Session, error) {
+	return t.updateCurrentTags(func(session *Session) {
+		session.Tags = NormalizeTags(append(session.Tags, tags...))
+	})
+}
+
+// RemoveTags removes tags from the current session
+func (t *Tracker) RemoveTags(tags ...string) (*Session, error) {
+	remove := make(map[string]bool)
+	for _, tag := range NormalizeTags(tags) {
+		remove[tag] = true
+	}
+	return t.updateCurrentTags(func(session *Session) {
+		var kept []string
+		for _, tag := range session.Tags {
+			if !remove[tag] {
+				kept = append(kept, tag)
+			}
+		}
+		session.Tags = kept
+	})
+}
+
+// updateCurrentTags applies a tag change to the current session
+func (t *Tracker) updateCurrentTags(update func(session *Session)) (*Session, error) {
+	return t.updateCurrent(func(session *Session) (*Session, error) {
+		if session == nil {
+			return nil, fmt.Errorf("no active session to tag")
+		}
+		update(session)
+		return session, nil
+	})
+}
+
+// SessionsWithTag returns all completed sessions carrying the given tag, most recent first
+func (t *Tracker) SessionsWithTag(tag string) ([]*Session, error) {
+	var sessions []*Session
+
+	err := t.db.View(func(tx *bbolt.Tx) error {
+		index := tx.Bucket(tagsBucket).Bucket([]byte(tag))
+		if index == nil {
+			return nil
+		}
+		return index.ForEach(func(id, _ []byte) error {
+			session, err := getSession(tx, string(id))
+			if err != nil {
+				return nil
+			}
+			if session.State == StateStopped {
+				sessions = append(sessions, session)
+			}
+			return nil
+		})
+	})
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].StartTime.After(sessions[j].StartTime)
+	})
+	return sessions, err
+}
+
+// GetTagStats returns session counts and total time for every tag, sorted by tag
+func (t *Tracker) GetTagStats() ([]TagStats, error) {
+	var stats []TagStats
+
+	err := t.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(tagsBucket).ForEach(func(tag, _ []byte) error {
+			entry := TagStats{Tag: string(tag)}
+			err := tx.Bucket(tagsBucket).Bucket(tag).ForEach(func(id, _ []byte) error {
+				session, err := getSession(tx, string(id))
+				if err != nil || session.State != StateStopped {
+					return nil
+				}
+				entry.Sessions++
+				entry.Duration += session.Duration
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			if entry.Sessions > 0 {
+				stats = append(stats, entry)
+			}
+			return nil
+		})
+	})
+
+	return stats, err
+}
+
+// indexTags updates the tag index for a session whose tags changed from oldTags
+func indexTags(tx *bbolt.Tx, id string, oldTags, newTags []string) error {
+	tags := tx.Bucket(tagsBucket)
+
+	for _, tag := range oldTags {
+		if err := deleteIndex(tags, []byte(tag), []byte(id)); err != nil {
+			return err
+		}
+	}
+
+	for _, tag := range newTags {
+		if err := putIndex(tags, []byte(tag), []byte(id)); err != nil {
+			return fmt.Errorf("failed to index tag %q: %w", tag, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,74 @@
+package tracking
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizeTags(t *testing.T) {
+	assert.Equal(t, []string{"bug", "JIRA-123"}, NormalizeTags([]string{" bug", "#JIRA-123", "", "bug"}))
+	assert.Nil(t, NormalizeTags(nil))
+}
+
+func TestTracker_TagsAreIndexed(t *testing.T) {
+	tracker := setupTestTracker(t)
+	defer tracker.Close()
+
+	session, err := tracker.StartWithOptions("api", StartOptions{Tags: []string{"bug", "JIRA-123"}, Note: "fix login"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"bug", "JIRA-123"}, session.Tags)
+	assert.Equal(t, "fix login", session.Note)
+
+	session, err = tracker.AddTags("review", "bug")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"bug", "JIRA-123", "review"}, session.Tags)
+
+	session, err = tracker.RemoveTags("JIRA-123")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"bug", "review"}, session.Tags)
+
+	time.Sleep(5 * time.Millisecond)
+	stopped, err := tracker.Stop()
+	require.NoError(t, err)
+
+	start := time.Now().Add(-5 * time.Hour)
+	logged, err := tracker.LogSession("web", start, start.Add(time.Hour), "", []string{"bug"})
+	require.NoError(t, err)
+
+	bugs, err := tracker.SessionsWithTag("bug")
+	require.NoError(t, err)
+	require.Len(t, bugs, 2)
+	assert.Equal(t, stopped.ID, bugs[0].ID)
+	assert.Equal(t, logged.ID, bugs[1].ID)
+
+	removed, err := tracker.SessionsWithTag("JIRA-123")
+	require.NoError(t, err)
+	assert.Empty(t, removed)
+
+	stats, err := tracker.GetTagStats()
+	require.NoError(t, err)
+	require.Len(t, stats, 2)
+	assert.Equal(t, "bug", stats[0].Tag)
+	assert.Equal(t, 2, stats[0].Sessions)
+	assert.Equal(t, stopped.Duration+time.Hour, stats[0].Duration)
+	assert.Equal(t, "review", stats[1].Tag)
+
+	// Deleting a session removes it from the index
+	_, err = tracker.DeleteSession(logged.ID)
+	require.NoError(t, err)
+	bugs, err = tracker.SessionsWithTag("bug")
+	require.NoError(t, err)
+	assert.Len(t, bugs, 1)
+}
+
+func TestTracker_AddTagsRequiresActiveSession(t *testing.T) {
+	tracker := setupTestTracker(t)
+	defer tracker.Close()
+
+	_, err := tracker.AddTags("bug")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no active session")
+}